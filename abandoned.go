@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// flagAbandoned marks releases whose chart has not published a new version for a long time
+const flagAbandoned = "Abandoned"
+
+// abandonedAfterMonths returns the abandoned chart threshold in months, 0 when disabled
+func abandonedAfterMonths() int {
+	if abandonedAfter > 0 {
+		return abandonedAfter
+	}
+	return cfg.AbandonedAfterMonths
+}
+
+// checkAbandoned records when the chart of every result last published a
+// version and flags charts whose newest index entry is older than the given
+// number of months as possibly unmaintained. The chart is looked up in the
+// index of the repository the release was attributed to, or in the first index
+// listing it when the repository is unknown.
+func checkAbandoned(repositories []repoIndex, months int, now time.Time, result []ChartVersionInfo) {
+	cutoff := now.AddDate(0, -months, 0)
+
+	for i := range result {
+		if result[i].Status == statusUnknown {
+			continue
+		}
+
+		repoName := result[i].RepoName
+		for _, idx := range repositories {
+			// Other indexes are only searched when the repository is unknown
+			if repoName != "" && repoName != "unknown" && idx.name != repoName {
+				continue
+			}
+			entries, exists := idx.Entries[result[i].ChartName]
+			if !exists || len(entries) == 0 {
+				continue
+			}
+
+			var newest time.Time
+			for _, entry := range entries {
+				if entry.Created.After(newest) {
+					newest = entry.Created
+				}
+			}
+			if !newest.IsZero() {
+				result[i].LatestReleaseDate = &newest
+				if newest.Before(cutoff) {
+					result[i].Flags = append(result[i].Flags, flagAbandoned)
+				}
+			}
+			break
+		}
+	}
+}
+
+// abandonedHint returns the hint shown for releases of possibly unmaintained charts
+func abandonedHint(versionInfo ChartVersionInfo) string {
+	return fmt.Sprintf("Chart %s has not published a new version since %s and may be unmaintained. Consider looking for an alternative.",
+		versionInfo.ChartName,
+		versionInfo.LatestReleaseDate.Format("2006-01-02"))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that charts without a recent upstream release are flagged as possibly abandoned
+func TestCheckAbandoned(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(version string, created time.Time) *repo.ChartVersion {
+		return &repo.ChartVersion{Metadata: &chart.Metadata{Version: version}, Created: created}
+	}
+	idx := repoIndex{name: "stable", helmIndexFile: &repo.IndexFile{Entries: map[string]repo.ChartVersions{
+		"legacy": {entry("1.0.0", now.AddDate(-2, 0, 0)), entry("1.1.0", now.AddDate(-1, -6, 0))},
+		"nginx":  {entry("2.0.0", now.AddDate(0, -1, 0))},
+	}}}
+	result := []ChartVersionInfo{
+		{ReleaseName: "old", ChartName: "legacy", Status: statusUptodate},
+		{ReleaseName: "web", ChartName: "nginx", Status: statusOutdated},
+		{ReleaseName: "db", ChartName: "legacy", Status: statusUnknown},
+	}
+
+	checkAbandoned([]repoIndex{idx}, 12, now, result)
+
+	assert.True(t, hasFlag(result[0], flagAbandoned))
+	require.NotNil(t, result[0].LatestReleaseDate)
+	assert.Contains(t, abandonedHint(result[0]), "since 2022-12-01")
+	assert.False(t, hasFlag(result[1], flagAbandoned))
+	assert.NotNil(t, result[1].LatestReleaseDate)
+	assert.Empty(t, result[2].Flags)
+}
+
+// Test that the chart is looked up in the index of the repository the release was attributed to
+func TestCheckAbandonedRepoName(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	index := func(name string, created time.Time) repoIndex {
+		return repoIndex{name: name, helmIndexFile: &repo.IndexFile{Entries: map[string]repo.ChartVersions{
+			"nginx": {{Metadata: &chart.Metadata{Version: "1.0.0"}, Created: created}},
+		}}}
+	}
+	repositories := []repoIndex{index("mirror", now.AddDate(-2, 0, 0)), index("bitnami", now.AddDate(0, -1, 0))}
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", ChartName: "nginx", RepoName: "bitnami", Status: statusUptodate},
+		{ReleaseName: "proxy", ChartName: "nginx", RepoName: "unknown", Status: statusUptodate},
+	}
+
+	checkAbandoned(repositories, 12, now, result)
+
+	assert.False(t, hasFlag(result[0], flagAbandoned))
+	assert.Equal(t, now.AddDate(0, -1, 0), *result[0].LatestReleaseDate)
+	assert.True(t, hasFlag(result[1], flagAbandoned))
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"sigs.k8s.io/yaml"
+)
+
+// statusVulnerable marks releases whose installed version is affected by a known advisory
+const statusVulnerable = "VULNERABLE"
+
+// Constants for advisory feeds
+const (
+	advisoryTimeout  = 30 * time.Second
+	maxAdvisorySize  = 64 << 20
+	osvIntroducedAny = "0"
+)
+
+// Advisory is a security advisory affecting the installed version of a chart
+type Advisory struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// chartAdvisory is an entry of the simple advisory feed format. Versions is a
+// semver constraint matching the affected chart versions, e.g. "<4.11.5".
+type chartAdvisory struct {
+	ID       string `json:"id"`
+	Chart    string `json:"chart"`
+	Versions string `json:"versions"`
+	Summary  string `json:"summary"`
+	URL      string `json:"url"`
+
+	// exact lists affected versions that are not covered by Versions
+	exact []string
+}
+
+// affects reports whether the advisory applies to the given chart version
+func (a *chartAdvisory) affects(chartName, chartVersion string) bool {
+	if a.Chart != chartName || chartVersion == "" {
+		return false
+	}
+	if containsString(a.exact, chartVersion) {
+		return true
+	}
+	if a.Versions == "" {
+		return false
+	}
+	constraint, err := semver.NewConstraint(a.Versions)
+	if err != nil {
+		return false
+	}
+	version, err := semver.NewVersion(chartVersion)
+	return err == nil && constraint.Check(version)
+}
+
+// advisoryFeed is the simple YAML (or JSON) feed format:
+//
+//	advisories:
+//	  - id: CVE-2025-1974
+//	    chart: ingress-nginx
+//	    versions: "<4.11.5"
+//	    summary: Remote code execution in the admission controller
+//	    url: https://github.com/kubernetes/kubernetes/issues/131009
+type advisoryFeed struct {
+	Advisories []chartAdvisory `json:"advisories"`
+	Vulns      []osvRecord     `json:"vulns"`
+}
+
+// osvRecord is the subset of an OSV vulnerability record used by whatup
+type osvRecord struct {
+	ID         string         `json:"id"`
+	Summary    string         `json:"summary"`
+	Affected   []osvAffected  `json:"affected"`
+	References []osvReference `json:"references"`
+}
+
+// osvAffected lists the affected versions of a package
+type osvAffected struct {
+	Package  osvPackage `json:"package"`
+	Ranges   []osvRange `json:"ranges"`
+	Versions []string   `json:"versions"`
+}
+
+// osvPackage identifies the affected package. For charts the name is the chart name.
+type osvPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+// osvRange is a range of affected versions described by events
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+// osvEvent opens or closes a range of affected versions
+type osvEvent struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+}
+
+// osvReference links to more information about a vulnerability
+type osvReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// constraint converts the events of the range into a semver constraint
+func (r osvRange) constraint() string {
+	var ranges []string
+	lower := ""
+	open := false
+	for _, event := range r.Events {
+		switch {
+		case event.Introduced != "":
+			lower = ""
+			if event.Introduced != osvIntroducedAny {
+				lower = ">=" + event.Introduced
+			}
+			open = true
+		case event.Fixed != "" && open:
+			ranges = append(ranges, joinConstraints(lower, "<"+event.Fixed))
+			open = false
+		case event.LastAffected != "" && open:
+			ranges = append(ranges, joinConstraints(lower, "<="+event.LastAffected))
+			open = false
+		}
+	}
+	if open {
+		// Every version since the introduction is affected
+		if lower == "" {
+			lower = ">=0.0.0-0"
+		}
+		ranges = append(ranges, lower)
+	}
+	return strings.Join(ranges, " || ")
+}
+
+// joinConstraints combines the lower and upper bound of a range
+func joinConstraints(lower, upper string) string {
+	if lower == "" {
+		return upper
+	}
+	return lower + ", " + upper
+}
+
+// advisories converts the OSV record into one advisory per affected chart
+func (r osvRecord) advisories() []chartAdvisory {
+	url := ""
+	for _, reference := range r.References {
+		if url == "" || reference.Type == "ADVISORY" {
+			url = reference.URL
+		}
+		if reference.Type == "ADVISORY" {
+			break
+		}
+	}
+
+	var advisories []chartAdvisory
+	for _, affected := range r.Affected {
+		var constraints []string
+		for _, rng := range affected.Ranges {
+			if rng.Type == "GIT" {
+				continue
+			}
+			if c := rng.constraint(); c != "" {
+				constraints = append(constraints, c)
+			}
+		}
+		advisories = append(advisories, chartAdvisory{
+			ID:       r.ID,
+			Chart:    affected.Package.Name,
+			Versions: strings.Join(constraints, " || "),
+			Summary:  r.Summary,
+			URL:      url,
+			exact:    affected.Versions,
+		})
+	}
+	return advisories
+}
+
+// advisoryFeeds returns the advisory feeds given with --advisories or in the config file
+func advisoryFeeds() []string {
+	if len(advisoryFeedFlags) > 0 {
+		return advisoryFeedFlags
+	}
+	return cfg.AdvisoryFeeds
+}
+
+// loadAdvisories reads all advisory feeds. Feeds are local files or HTTP(S)
+// URLs in the simple advisory format or OSV JSON.
+func loadAdvisories(ctx context.Context, feeds []string) ([]chartAdvisory, error) {
+	var advisories []chartAdvisory
+	client := &http.Client{Timeout: advisoryTimeout}
+	for _, feed := range feeds {
+		data, err := readFileOrURL(ctx, client, feed, "advisory feed")
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parseAdvisoryFeed(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse advisory feed %s: %w", feed, err)
+		}
+		for i := range parsed {
+			if parsed[i].Versions == "" {
+				continue
+			}
+			if _, err := semver.NewConstraint(parsed[i].Versions); err != nil {
+				return nil, fmt.Errorf("invalid version range %q of advisory %s in %s: %w", parsed[i].Versions, parsed[i].ID, feed, err)
+			}
+		}
+		advisories = append(advisories, parsed...)
+	}
+	return advisories, nil
+}
+
+// readFileOrURL reads a local file or downloads it when location is an
+// HTTP(S) URL. what names the content in error messages.
+func readFileOrURL(ctx context.Context, client *http.Client, location, what string) ([]byte, error) {
+	if !isRepositoryURL(location) {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", what, err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", what, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", location, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", location, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAdvisorySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", location, err)
+	}
+	return data, nil
+}
+
+// parseAdvisoryFeed parses a feed in the simple advisory format, a single OSV
+// record, a list of OSV records or an OSV query response
+func parseAdvisoryFeed(data []byte) ([]chartAdvisory, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	jsonData = []byte(strings.TrimSpace(string(jsonData)))
+
+	var records []osvRecord
+	if strings.HasPrefix(string(jsonData), "[") {
+		if err := json.Unmarshal(jsonData, &records); err != nil {
+			return nil, err
+		}
+	} else {
+		var feed advisoryFeed
+		if err := json.Unmarshal(jsonData, &feed); err != nil {
+			return nil, err
+		}
+		if feed.Advisories != nil {
+			return feed.Advisories, nil
+		}
+		records = feed.Vulns
+		if records == nil {
+			var record osvRecord
+			if err := json.Unmarshal(jsonData, &record); err != nil {
+				return nil, err
+			}
+			records = []osvRecord{record}
+		}
+	}
+
+	var advisories []chartAdvisory
+	for _, record := range records {
+		advisories = append(advisories, record.advisories()...)
+	}
+	return advisories, nil
+}
+
+// checkAdvisories marks the results whose installed version is affected by an
+// advisory as VULNERABLE, whether or not a newer version is available.
+func checkAdvisories(advisories []chartAdvisory, result []ChartVersionInfo) {
+	for i := range result {
+		for j := range advisories {
+			if !advisories[j].affects(result[i].ChartName, result[i].InstalledVersion) {
+				continue
+			}
+			result[i].Advisories = append(result[i].Advisories, Advisory{
+				ID:      advisories[j].ID,
+				Summary: advisories[j].Summary,
+				URL:     advisories[j].URL,
+			})
+			result[i].Status = statusVulnerable
+		}
+	}
+}
+
+// advisoriesColumn renders the IDs of the advisories affecting a release
+func advisoriesColumn(versionInfo ChartVersionInfo) string {
+	ids := make([]string, 0, len(versionInfo.Advisories))
+	for _, advisory := range versionInfo.Advisories {
+		ids = append(ids, advisory.ID)
+	}
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that releases inside the range of an advisory are marked vulnerable, whatever their update status
+func TestCheckAdvisories(t *testing.T) {
+	advisories, err := parseAdvisoryFeed([]byte(`
+advisories:
+  - id: CVE-2025-1974
+    chart: ingress-nginx
+    versions: "<4.11.5"
+    summary: IngressNightmare
+`))
+	require.NoError(t, err)
+
+	result := []ChartVersionInfo{
+		{ReleaseName: "edge", ChartName: "ingress-nginx", InstalledVersion: "4.11.4", LatestVersion: "4.12.0", Status: statusOutdated},
+		{ReleaseName: "lab", ChartName: "ingress-nginx", InstalledVersion: "4.10.0", Status: statusUnknown},
+		{ReleaseName: "fixed", ChartName: "ingress-nginx", InstalledVersion: "4.11.5", LatestVersion: "4.11.5", Status: statusUptodate},
+		{ReleaseName: "db", ChartName: "postgresql", InstalledVersion: "1.0.0", Status: statusUptodate},
+	}
+	checkAdvisories(advisories, result)
+
+	assert.Equal(t, statusVulnerable, result[0].Status)
+	assert.Equal(t, "CVE-2025-1974", advisoriesColumn(result[0]))
+	assert.Equal(t, statusVulnerable, result[1].Status)
+	assert.Equal(t, statusUptodate, result[2].Status)
+	assert.Empty(t, result[3].Advisories)
+
+	err = outdatedError(result)
+	assert.EqualError(t, err, "0 release(s) are outdated, 2 are vulnerable")
+	assert.Equal(t, exitCodeOutdated, exitCode(err))
+}
+
+// Test that OSV ranges and explicit versions are translated into advisories
+func TestParseOSVAdvisories(t *testing.T) {
+	advisories, err := parseAdvisoryFeed([]byte(`{
+		"id": "GHSA-xxxx-yyyy-zzzz",
+		"summary": "Privilege escalation",
+		"affected": [{
+			"package": {"ecosystem": "Helm", "name": "vault"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.25.0"}, {"introduced": "0.27.0"}, {"last_affected": "0.27.2"}]}],
+			"versions": ["0.26.1"]
+		}],
+		"references": [{"type": "WEB", "url": "https://example.com/blog"}, {"type": "ADVISORY", "url": "https://example.com/advisory"}]
+	}`))
+	require.NoError(t, err)
+	require.Len(t, advisories, 1)
+
+	advisory := advisories[0]
+	assert.Equal(t, "<0.25.0 || >=0.27.0, <=0.27.2", advisory.Versions)
+	assert.Equal(t, "https://example.com/advisory", advisory.URL)
+	assert.True(t, advisory.affects("vault", "0.24.0"))
+	assert.True(t, advisory.affects("vault", "0.26.1"))
+	assert.True(t, advisory.affects("vault", "0.27.2"))
+	assert.False(t, advisory.affects("vault", "0.26.0"))
+	assert.False(t, advisory.affects("vault", "0.28.0"))
+	assert.False(t, advisory.affects("consul", "0.24.0"))
+
+	list, err := parseAdvisoryFeed([]byte(`[{"id": "A", "affected": [{"package": {"name": "vault"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "1.0.0"}]}]}]}]`))
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.True(t, list[0].affects("vault", "2.0.0"))
+	assert.False(t, list[0].affects("vault", "0.9.0"))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// flagStaleRelease marks releases that were not deployed for longer than the release age policy allows
+const flagStaleRelease = "StaleRelease"
+
+// maxReleaseAgeDays returns the release age policy in days, 0 when disabled
+func maxReleaseAgeDays() int {
+	if maxReleaseAge > 0 {
+		return maxReleaseAge
+	}
+	return cfg.MaxReleaseAgeDays
+}
+
+// checkReleaseAge records when every result was last deployed and flags the
+// releases deployed more than maxDays ago. This is independent of the version
+// status: a release can be up to date only because upstream stopped publishing.
+func checkReleaseAge(releases []*helmRelease, maxDays int, now time.Time, result []ChartVersionInfo) {
+	deployed := make(map[string]time.Time, len(releases))
+	for _, rel := range releases {
+		if rel.Info != nil {
+			deployed[rel.Namespace+"/"+rel.Name] = rel.Info.LastDeployed.Time
+		}
+	}
+
+	for i := range result {
+		lastDeployed, ok := deployed[findingKey(result[i])]
+		if !ok || lastDeployed.IsZero() {
+			continue
+		}
+		result[i].LastDeployed = &lastDeployed
+		if now.Sub(lastDeployed) > time.Duration(maxDays)*24*time.Hour {
+			result[i].Flags = append(result[i].Flags, flagStaleRelease)
+		}
+	}
+}
+
+// releaseAgeHint returns the hint shown for releases violating the release age policy
+func releaseAgeHint(versionInfo ChartVersionInfo, now time.Time) string {
+	return fmt.Sprintf("Release %s was last deployed %d days ago. Check that chart %s is still maintained upstream.",
+		versionInfo.ReleaseName,
+		int(now.Sub(*versionInfo.LastDeployed).Hours()/24),
+		versionInfo.ChartName)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+// Test that releases not deployed within the release age policy are flagged regardless of status
+func TestCheckReleaseAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	releases := []*release.Release{
+		{Name: "web", Namespace: "apps", Info: &release.Info{LastDeployed: helmtime.Time{Time: now.AddDate(0, 0, -200)}}},
+		{Name: "api", Namespace: "apps", Info: &release.Info{LastDeployed: helmtime.Time{Time: now.AddDate(0, 0, -10)}}},
+	}
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "apps", ChartName: "nginx", Status: statusUptodate},
+		{ReleaseName: "api", Namespace: "apps", Status: statusOutdated},
+		{ReleaseName: "db", Namespace: "apps", Status: statusUnknown},
+	}
+
+	checkReleaseAge(releases, 90, now, result)
+
+	assert.True(t, hasFlag(result[0], flagStaleRelease))
+	require.NotNil(t, result[0].LastDeployed)
+	assert.Equal(t, "Release web was last deployed 200 days ago. Check that chart nginx is still maintained upstream.", releaseAgeHint(result[0], now))
+
+	assert.False(t, hasFlag(result[1], flagStaleRelease))
+	assert.NotNil(t, result[1].LastDeployed)
+
+	assert.Nil(t, result[2].LastDeployed)
+	assert.Empty(t, result[2].Flags)
+}
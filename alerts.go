@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats of gen-alerts
+const (
+	// alertsFormatPrometheusRule is a PrometheusRule resource of the Prometheus Operator
+	alertsFormatPrometheusRule = "prometheusrule"
+	// alertsFormatRules is a plain Prometheus rule file
+	alertsFormatRules = "rules"
+)
+
+// alertOptions are the thresholds of the generated alerts
+type alertOptions struct {
+	name       string
+	namespace  string
+	majorFor   time.Duration
+	outdated   time.Duration
+	staleAfter time.Duration
+}
+
+// alertRule is an alerting rule of a Prometheus rule group
+type alertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// ruleGroup is a group of a Prometheus rule file
+type ruleGroup struct {
+	Name  string      `yaml:"name"`
+	Rules []alertRule `yaml:"rules"`
+}
+
+// ruleFile is a plain Prometheus rule file
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+// prometheusRule is a PrometheusRule resource of the Prometheus Operator
+type prometheusRule struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace,omitempty"`
+	} `yaml:"metadata"`
+	Spec ruleFile `yaml:"spec"`
+}
+
+// promDuration renders a duration in the largest Prometheus unit dividing it
+func promDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
+// alertRules returns the alerts on the metrics of -o prometheus and serve.
+// Critical releases behind a major version page, other releases behind a
+// major version and long outdated releases warn.
+func alertRules(opts alertOptions) []alertRule {
+	// The metrics use the names of the vocabulary
+	critical := cfg.Vocabulary.priority(priorityCritical)
+	major := cfg.Vocabulary.severity("MAJOR")
+	return []alertRule{
+		{
+			Alert:  "HelmReleaseCriticalMajorOutdated",
+			Expr:   fmt.Sprintf(`helm_whatup_release_outdated{priority=%q,update_severity=%q} == 1`, critical, major),
+			For:    promDuration(opts.majorFor),
+			Labels: map[string]string{"severity": "critical"},
+			Annotations: map[string]string{
+				"summary":     "Critical Helm release {{ $labels.namespace }}/{{ $labels.release }} is a major version behind",
+				"description": fmt.Sprintf("A new major version of chart {{ $labels.chart }} has been available for more than %s.", promDuration(opts.majorFor)),
+			},
+		},
+		{
+			Alert:  "HelmReleaseMajorOutdated",
+			Expr:   fmt.Sprintf(`helm_whatup_release_outdated{priority!=%q,update_severity=%q} == 1`, critical, major),
+			For:    promDuration(opts.majorFor),
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "Helm release {{ $labels.namespace }}/{{ $labels.release }} is a major version behind",
+				"description": fmt.Sprintf("A new major version of chart {{ $labels.chart }} has been available for more than %s.", promDuration(opts.majorFor)),
+			},
+		},
+		{
+			Alert:  "HelmReleaseOutdated",
+			Expr:   fmt.Sprintf(`helm_whatup_release_outdated{update_severity!=%q} == 1`, major),
+			For:    promDuration(opts.outdated),
+			Labels: map[string]string{"severity": "info"},
+			Annotations: map[string]string{
+				"summary":     "Helm release {{ $labels.namespace }}/{{ $labels.release }} is outdated",
+				"description": fmt.Sprintf("A new version of chart {{ $labels.chart }} has been available for more than %s.", promDuration(opts.outdated)),
+			},
+		},
+		{
+			Alert:  "HelmWhatupStale",
+			Expr:   fmt.Sprintf("time() - helm_whatup_last_run_timestamp_seconds > %d", int64(opts.staleAfter/time.Second)),
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary":     "helm-whatup has not checked the releases recently",
+				"description": fmt.Sprintf("The releases were last checked more than %s ago, the chart update alerts may be out of date.", promDuration(opts.staleAfter)),
+			},
+		},
+	}
+}
+
+// writeAlerts writes the alerts as a PrometheusRule resource or a plain rule file
+func writeAlerts(w io.Writer, output string, opts alertOptions) error {
+	rules := ruleFile{Groups: []ruleGroup{{Name: "helm-whatup", Rules: alertRules(opts)}}}
+
+	var document interface{}
+	switch output {
+	case alertsFormatPrometheusRule:
+		resource := prometheusRule{APIVersion: "monitoring.coreos.com/v1", Kind: "PrometheusRule", Spec: rules}
+		resource.Metadata.Name = opts.name
+		resource.Metadata.Namespace = opts.namespace
+		document = resource
+	case alertsFormatRules:
+		document = rules
+	default:
+		return fmt.Errorf("invalid formatter: %s", output)
+	}
+
+	outputBytes, err := yaml.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	_, err = w.Write(outputBytes)
+	return err
+}
+
+// newGenAlertsCmd creates the gen-alerts subcommand
+func newGenAlertsCmd() *cobra.Command {
+	var output string
+	opts := alertOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "gen-alerts",
+		Short: "print Prometheus alerting rules for the metrics of -o prometheus and serve",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			for flag, d := range map[string]time.Duration{"--major-for": opts.majorFor, "--outdated-for": opts.outdated, "--stale-after": opts.staleAfter} {
+				if d < time.Second {
+					return fmt.Errorf("invalid %s %s, it must be at least a second", flag, d)
+				}
+			}
+			return writeAlerts(os.Stdout, output, opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&output, "output", "o", alertsFormatPrometheusRule, "output format. Accepted formats: prometheusrule (for the Prometheus Operator), rules (a plain Prometheus rule file)")
+	f.StringVar(&opts.name, "name", "helm-whatup", "name of the PrometheusRule")
+	f.StringVarP(&opts.namespace, "namespace", "n", "", "namespace of the PrometheusRule")
+	f.DurationVar(&opts.majorFor, "major-for", 7*24*time.Hour, "alert when a release has been a major version behind for this long, as critical for critical releases")
+	f.DurationVar(&opts.outdated, "outdated-for", 30*24*time.Hour, "alert when a release has been behind a minor or patch version for this long")
+	f.DurationVar(&opts.staleAfter, "stale-after", 3*time.Hour, "alert when the releases were not checked for this long")
+
+	return cmd
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+// Test that the alerts are generated as a PrometheusRule or a plain rule file
+func TestWriteAlerts(t *testing.T) {
+	opts := alertOptions{name: "whatup", namespace: "monitoring", majorFor: 7 * 24 * time.Hour, outdated: 30 * 24 * time.Hour, staleAfter: 3 * time.Hour}
+
+	var out bytes.Buffer
+	require.NoError(t, writeAlerts(&out, alertsFormatPrometheusRule, opts))
+
+	var resource prometheusRule
+	require.NoError(t, yaml.UnmarshalStrict(out.Bytes(), &resource))
+	assert.Equal(t, "PrometheusRule", resource.Kind)
+	assert.Equal(t, "monitoring", resource.Metadata.Namespace)
+	require.Len(t, resource.Spec.Groups, 1)
+	rules := resource.Spec.Groups[0].Rules
+	require.Len(t, rules, 4)
+	assert.Equal(t, "HelmReleaseCriticalMajorOutdated", rules[0].Alert)
+	assert.Equal(t, "7d", rules[0].For)
+	assert.Equal(t, "critical", rules[0].Labels["severity"])
+	assert.Equal(t, "time() - helm_whatup_last_run_timestamp_seconds > 10800", rules[3].Expr)
+
+	out.Reset()
+	require.NoError(t, writeAlerts(&out, alertsFormatRules, opts))
+	assert.Contains(t, out.String(), "groups:\n- name: helm-whatup\n")
+	assert.NotContains(t, out.String(), "PrometheusRule")
+
+	assert.Error(t, writeAlerts(&out, "json", opts))
+
+	assert.Equal(t, "90m", promDuration(90*time.Minute))
+	assert.Equal(t, "36h", promDuration(36*time.Hour))
+	assert.Equal(t, "45s", promDuration(45*time.Second))
+}
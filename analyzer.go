@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severities of the findings of the analyzers, from the most to the least severe
+const (
+	findingError   = "error"
+	findingWarning = "warning"
+	findingInfo    = "info"
+)
+
+// findingSeverities lists the severities of findings from the most to the least severe
+var findingSeverities = []string{findingError, findingWarning, findingInfo}
+
+// Analyzer checks the releases of a scan for a class of problems. Analyzers
+// are registered with RegisterAnalyzer and run by the check subcommand, so
+// new checks don't need to touch the scan itself.
+type Analyzer interface {
+	// Name identifies the analyzer in findings and in --analyzers
+	Name() string
+	// Run returns the findings for a single release
+	Run(ctx context.Context, rc ReleaseContext) []Finding
+}
+
+// analyzerDescriber is implemented by analyzers describing what they find,
+// for the help of the check subcommand
+type analyzerDescriber interface {
+	Description() string
+}
+
+// ReleaseContext is what an analyzer gets to know about a release: the result
+// of the scan and the installed release. Release is nil when it is not known,
+// e.g. for canaries collapsed into their stable release.
+type ReleaseContext struct {
+	Result  ChartVersionInfo
+	Release *helmRelease
+}
+
+// Finding returns a finding about the release with the given severity
+func (rc ReleaseContext) Finding(severity, format string, v ...interface{}) Finding {
+	return Finding{
+		Severity:    severity,
+		Cluster:     rc.Result.Cluster,
+		Namespace:   rc.Result.Namespace,
+		ReleaseName: rc.Result.ReleaseName,
+		ChartName:   rc.Result.ChartName,
+		Message:     fmt.Sprintf(format, v...),
+	}
+}
+
+// Finding is a problem an analyzer found with a release. Analyzer is set to
+// the name of the analyzer that returned it.
+type Finding struct {
+	Analyzer    string `json:"analyzer"`
+	Severity    string `json:"severity"`
+	Cluster     string `json:"cluster,omitempty"`
+	Namespace   string `json:"namespace"`
+	ReleaseName string `json:"releaseName"`
+	ChartName   string `json:"chartName"`
+	Message     string `json:"message"`
+}
+
+// analyzerFunc adapts a function to the Analyzer interface
+type analyzerFunc struct {
+	name        string
+	description string
+	run         func(ctx context.Context, rc ReleaseContext) []Finding
+}
+
+func (a analyzerFunc) Name() string        { return a.name }
+func (a analyzerFunc) Description() string { return a.description }
+
+func (a analyzerFunc) Run(ctx context.Context, rc ReleaseContext) []Finding {
+	return a.run(ctx, rc)
+}
+
+// registeredAnalyzers are the analyzers of the check subcommand, in the order
+// they run
+var registeredAnalyzers = append([]Analyzer(nil), builtinAnalyzers...)
+
+// RegisterAnalyzer adds an analyzer to the check subcommand. It is meant to be
+// called from an init function and panics when the name is already taken.
+func RegisterAnalyzer(a Analyzer) {
+	for _, registered := range registeredAnalyzers {
+		if registered.Name() == a.Name() {
+			panic(fmt.Sprintf("analyzer %s is already registered", a.Name()))
+		}
+	}
+	registeredAnalyzers = append(registeredAnalyzers, a)
+}
+
+// analyzerNames returns the names of the registered analyzers
+func analyzerNames() []string {
+	names := make([]string, 0, len(registeredAnalyzers))
+	for _, a := range registeredAnalyzers {
+		names = append(names, a.Name())
+	}
+	return names
+}
+
+// selectAnalyzers returns the registered analyzers with the given names, all
+// of them when no name is given
+func selectAnalyzers(names []string) ([]Analyzer, error) {
+	if len(names) == 0 {
+		return registeredAnalyzers, nil
+	}
+	var selected []Analyzer
+	for _, name := range names {
+		found := false
+		for _, a := range registeredAnalyzers {
+			if a.Name() == strings.TrimSpace(name) {
+				selected = append(selected, a)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown analyzer %q, expected one of %s", name, strings.Join(analyzerNames(), ", "))
+		}
+	}
+	return selected, nil
+}
+
+// runAnalyzers runs the analyzers against every result of the report and
+// returns their findings, the most severe first
+func runAnalyzers(ctx context.Context, selected []Analyzer, report scanReport) []Finding {
+	var findings []Finding
+	for _, versionInfo := range report.results {
+		rc := ReleaseContext{Result: versionInfo, Release: report.installed[reportKey(versionInfo)]}
+		for _, a := range selected {
+			for _, f := range a.Run(ctx, rc) {
+				f.Analyzer = a.Name()
+				findings = append(findings, f)
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findingRank(findings[i].Severity) < findingRank(findings[j].Severity)
+	})
+	return findings
+}
+
+// findingRank returns the position of a severity in findingSeverities, lower
+// being more severe. Unknown severities rank last.
+func findingRank(severity string) int {
+	for i, s := range findingSeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(findingSeverities)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that registered analyzers run with the built-in ones and that names are unique
+func TestRegisterAnalyzer(t *testing.T) {
+	defer func(analyzers []Analyzer) { registeredAnalyzers = analyzers }(registeredAnalyzers)
+
+	RegisterAnalyzer(analyzerFunc{
+		name: "no-owner",
+		run: func(_ context.Context, rc ReleaseContext) []Finding {
+			if rc.Result.Owner != "" {
+				return nil
+			}
+			return []Finding{rc.Finding(findingInfo, "release %s has no owner", rc.Result.ReleaseName)}
+		},
+	})
+	assert.Panics(t, func() { RegisterAnalyzer(analyzerFunc{name: "version-drift"}) })
+	assert.Contains(t, analyzersHelp(), "  no-owner")
+
+	selected, err := selectAnalyzers([]string{"no-owner"})
+	require.NoError(t, err)
+
+	report := scanReport{results: []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", Cluster: "eu-1"},
+		{ReleaseName: "db", Namespace: "prod", ChartName: "postgresql", Owner: "team-data"},
+	}}
+	assert.Equal(t, []Finding{{
+		Analyzer:    "no-owner",
+		Severity:    findingInfo,
+		Cluster:     "eu-1",
+		Namespace:   "prod",
+		ReleaseName: "web",
+		ChartName:   "nginx",
+		Message:     "release web has no owner",
+	}}, runAnalyzers(context.Background(), selected, report))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strconv"
+)
+
+// Chart annotations and release labels app teams can set to opt a release out
+// of update checks or pin it to a version range, without touching the central
+// config. Release labels win over chart annotations.
+const (
+	ignoreAnnotation = "whatup.helm.sh/ignore"
+	pinAnnotation    = "whatup.helm.sh/pin"
+)
+
+// releaseAnnotationRules returns the version rules declared on the release by
+// the ignore and pin chart annotations or release labels
+func releaseAnnotationRules(rel *helmRelease) ([]versionRule, error) {
+	lookup := func(key string) (string, string, bool) {
+		if value, ok := rel.Labels[key]; ok {
+			return value, "release label " + key, true
+		}
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			if value, ok := rel.Chart.Metadata.Annotations[key]; ok {
+				return value, "chart annotation " + key, true
+			}
+		}
+		return "", "", false
+	}
+
+	var rules []versionRule
+	if value, source, ok := lookup(ignoreAnnotation); ok {
+		if ignore, err := strconv.ParseBool(value); err == nil && ignore {
+			rules = append(rules, versionRule{Source: source, Ignore: true})
+		}
+	}
+	if value, source, ok := lookup(pinAnnotation); ok && value != "" {
+		rule := versionRule{Source: source, Constraint: value}
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that releases can be ignored or pinned with release labels and chart annotations
+func TestReleaseAnnotationRules(t *testing.T) {
+	ignored := newTestRelease("legacy", "default", "legacy-app", "1.0.0")
+	ignored.Labels = map[string]string{ignoreAnnotation: "true"}
+	pinned := newTestRelease("ingress", "default", "ingress-nginx", "4.10.0")
+	pinned.Chart.Metadata.Annotations = map[string]string{pinAnnotation: "4.10.x"}
+	overridden := newTestRelease("edge", "default", "ingress-nginx", "4.10.0")
+	overridden.Chart.Metadata.Annotations = map[string]string{pinAnnotation: "4.10.x"}
+	overridden.Labels = map[string]string{pinAnnotation: "4.x"}
+	broken := newTestRelease("lab", "default", "ingress-nginx", "4.10.0")
+	broken.Labels = map[string]string{pinAnnotation: "four"}
+
+	releases := []*release.Release{ignored, pinned, overridden, broken}
+	repositories := []repoIndex{
+		newTestIndex("legacy-app", "2.0.0"),
+		newTestIndex("ingress-nginx", "5.0.0", "4.11.0", "4.10.1"),
+	}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+	require.Len(t, result, 4)
+	assert.Equal(t, statusIgnored, result[0].Status)
+	assert.Equal(t, "4.10.1", result[1].LatestVersion)
+	assert.Equal(t, "4.11.0", result[2].LatestVersion)
+	assert.Equal(t, "5.0.0", result[3].LatestVersion)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, warnInvalidPin, warnings[0].Code)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Constants for the ArtifactHub integration
+const (
+	defaultArtifactHubURL = "https://artifacthub.io"
+	artifactHubTimeout    = 10 * time.Second
+	artifactHubHelmKind   = 0
+	artifactHubSearchSize = 20
+)
+
+// errArtifactHubNotFound is returned when ArtifactHub does not know a package
+var errArtifactHubNotFound = errors.New("package not found on ArtifactHub")
+
+// artifactHubConfig configures the ArtifactHub integration
+type artifactHubConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	URL     string `yaml:"url"`
+}
+
+// ArtifactHubInfo describes the trust and popularity signals of a package on ArtifactHub
+type ArtifactHubInfo struct {
+	Official          bool   `json:"official"`
+	VerifiedPublisher bool   `json:"verifiedPublisher"`
+	Stars             int    `json:"stars"`
+	Repository        string `json:"repository"`
+	URL               string `json:"url"`
+}
+
+// artifactHubPackage is the subset of the ArtifactHub package API used by whatup
+type artifactHubPackage struct {
+	Name       string                `json:"name"`
+	Version    string                `json:"version"`
+	Official   bool                  `json:"official"`
+	Stars      int                   `json:"stars"`
+	Category   int                   `json:"category"`
+	Repository artifactHubRepository `json:"repository"`
+}
+
+// artifactHubRepository is the repository a package was published in
+type artifactHubRepository struct {
+	Name              string `json:"name"`
+	URL               string `json:"url"`
+	Official          bool   `json:"official"`
+	VerifiedPublisher bool   `json:"verified_publisher"`
+}
+
+// info converts the package into the metadata attached to results
+func (p *artifactHubPackage) info(baseURL string) *ArtifactHubInfo {
+	return &ArtifactHubInfo{
+		Official:          p.Official || p.Repository.Official,
+		VerifiedPublisher: p.Repository.VerifiedPublisher,
+		Stars:             p.Stars,
+		Repository:        p.Repository.Name,
+		URL:               fmt.Sprintf("%s/packages/helm/%s/%s", baseURL, p.Repository.Name, p.Name),
+	}
+}
+
+// artifactHubClient queries the ArtifactHub API. Lookups are cached for the
+// duration of a run since many releases often share the same chart.
+type artifactHubClient struct {
+	baseURL string
+	client  *http.Client
+	cache   map[string]*artifactHubPackage
+}
+
+// newArtifactHubClient creates a client for the configured ArtifactHub instance
+func newArtifactHubClient(config artifactHubConfig) *artifactHubClient {
+	baseURL := config.URL
+	if baseURL == "" {
+		baseURL = defaultArtifactHubURL
+	}
+	return &artifactHubClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: artifactHubTimeout},
+		cache:   map[string]*artifactHubPackage{},
+	}
+}
+
+// getJSON fetches the API path and decodes the JSON response into v
+func (c *artifactHubClient) getJSON(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create ArtifactHub request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query ArtifactHub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errArtifactHubNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("ArtifactHub returned status %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode ArtifactHub response: %w", err)
+	}
+	return nil
+}
+
+// search returns the Helm packages matching the chart name
+func (c *artifactHubClient) search(ctx context.Context, chartName string) ([]artifactHubPackage, error) {
+	query := url.Values{}
+	query.Set("ts_query_web", chartName)
+	query.Set("kind", strconv.Itoa(artifactHubHelmKind))
+	query.Set("limit", strconv.Itoa(artifactHubSearchSize))
+
+	var response struct {
+		Packages []artifactHubPackage `json:"packages"`
+	}
+	if err := c.getJSON(ctx, "/api/v1/packages/search?"+query.Encode(), &response); err != nil {
+		return nil, err
+	}
+
+	var matches []artifactHubPackage
+	for _, pkg := range response.Packages {
+		if pkg.Name == chartName {
+			matches = append(matches, pkg)
+		}
+	}
+	return matches, nil
+}
+
+// lookup finds the package of a chart. The local repository name is tried
+// first since it usually matches the ArtifactHub repository name; otherwise
+// the search results are matched on the repository URL.
+func (c *artifactHubClient) lookup(ctx context.Context, chartName, repoName, repoURL string) (*artifactHubPackage, error) {
+	key := repoName + "/" + chartName + "@" + repoURL
+	if pkg, ok := c.cache[key]; ok {
+		return pkg, nil
+	}
+
+	var pkg *artifactHubPackage
+	if repoName != "" {
+		var found artifactHubPackage
+		path := fmt.Sprintf("/api/v1/packages/helm/%s/%s", url.PathEscape(repoName), url.PathEscape(chartName))
+		err := c.getJSON(ctx, path, &found)
+		switch {
+		case err == nil:
+			pkg = &found
+		case !errors.Is(err, errArtifactHubNotFound):
+			return nil, err
+		}
+	}
+
+	if pkg == nil && repoURL != "" {
+		matches, err := c.search(ctx, chartName)
+		if err != nil {
+			return nil, err
+		}
+		for i := range matches {
+			if strings.TrimSuffix(matches[i].Repository.URL, "/") == strings.TrimSuffix(repoURL, "/") {
+				pkg = &matches[i]
+				break
+			}
+		}
+	}
+
+	c.cache[key] = pkg
+	return pkg, nil
+}
+
+// bestArtifactHubMatch picks the most trustworthy package among search results,
+// preferring official packages, then verified publishers, then stars
+func bestArtifactHubMatch(packages []artifactHubPackage) *artifactHubPackage {
+	var best *artifactHubPackage
+	score := func(p *artifactHubPackage) [3]int {
+		official, verified := 0, 0
+		if p.Official || p.Repository.Official {
+			official = 1
+		}
+		if p.Repository.VerifiedPublisher {
+			verified = 1
+		}
+		return [3]int{official, verified, p.Stars}
+	}
+	for i := range packages {
+		if best == nil {
+			best = &packages[i]
+			continue
+		}
+		a, b := score(&packages[i]), score(best)
+		if a[0] > b[0] || (a[0] == b[0] && (a[1] > b[1] || (a[1] == b[1] && a[2] > b[2]))) {
+			best = &packages[i]
+		}
+	}
+	return best
+}
+
+// discoverRepository searches ArtifactHub for the chart of an UNKNOWN result
+// and suggests the repository publishing it
+func (c *artifactHubClient) discoverRepository(ctx context.Context, versionInfo *ChartVersionInfo) error {
+	matches, err := c.search(ctx, versionInfo.ChartName)
+	if err != nil {
+		return err
+	}
+	pkg := bestArtifactHubMatch(matches)
+	if pkg == nil {
+		return nil
+	}
+
+	versionInfo.ArtifactHub = pkg.info(c.baseURL)
+	if versionInfo.Category == "" {
+		versionInfo.Category = artifactHubCategory(pkg)
+	}
+	versionInfo.SuggestedRepository = &wellKnownRepository{Name: pkg.Repository.Name, URL: pkg.Repository.URL}
+	versionInfo.LatestVersion = pkg.Version
+	return nil
+}
+
+// addArtifactHubInfo attaches the ArtifactHub metadata of the charts to the
+// results. Charts of UNKNOWN results are searched by name to discover the
+// repository they are published in.
+func addArtifactHubInfo(ctx context.Context, client *artifactHubClient, repoURLs map[string]string, result []ChartVersionInfo, warnings *[]warning) {
+	for i := range result {
+		if result[i].Status == statusIgnored {
+			continue
+		}
+
+		if result[i].Status == statusUnknown {
+			if result[i].SuggestedRepository != nil {
+				continue
+			}
+			if err := client.discoverRepository(ctx, &result[i]); err != nil {
+				addWarning(warnings, warnArtifactHubFailed, "Failed to search '%s' on ArtifactHub: %v", result[i].ChartName, err)
+			}
+			continue
+		}
+
+		pkg, err := client.lookup(ctx, result[i].ChartName, result[i].RepoName, repoURLs[result[i].RepoName])
+		if err != nil {
+			addWarning(warnings, warnArtifactHubFailed, "Failed to look up '%s' on ArtifactHub: %v", result[i].ChartName, err)
+			continue
+		}
+		if pkg != nil {
+			result[i].ArtifactHub = pkg.info(client.baseURL)
+			// The chart annotation takes precedence over the category on ArtifactHub
+			if result[i].Category == "" {
+				result[i].Category = artifactHubCategory(pkg)
+			}
+		}
+	}
+}
+
+// artifactHubColumn renders the ArtifactHub trust signals for the table output
+func artifactHubColumn(versionInfo ChartVersionInfo) string {
+	info := versionInfo.ArtifactHub
+	if info == nil {
+		return ""
+	}
+
+	var signals []string
+	if info.Official {
+		signals = append(signals, "official")
+	}
+	if info.VerifiedPublisher {
+		signals = append(signals, "verified")
+	}
+	signals = append(signals, fmt.Sprintf("%d★", info.Stars))
+	return strings.Join(signals, " ")
+}
+
+// artifactHubEnabled reports whether the ArtifactHub integration is enabled by flag or config
+func artifactHubEnabled() bool {
+	return useArtifactHub || cfg.ArtifactHub.Enabled
+}
+
+// repositoryURLs maps the names of the configured repositories to their URLs
+func repositoryURLs(repoFileData *helmRepoFile) map[string]string {
+	urls := make(map[string]string)
+	if repoFileData == nil {
+		return urls
+	}
+	for _, entry := range repoFileData.Repositories {
+		urls[entry.Name] = entry.URL
+	}
+	return urls
+}
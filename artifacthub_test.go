@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestArtifactHub serves a fake ArtifactHub API with a bitnami/redis package
+// and a search result for nginx published under a different repository name
+func newTestArtifactHub(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/packages/helm/bitnami/redis", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"redis","version":"18.0.0","stars":42,"category":2,"repository":{"name":"bitnami","url":"https://charts.bitnami.com/bitnami","verified_publisher":true}}`))
+	})
+	mux.HandleFunc("/api/v1/packages/search", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ingress-nginx", r.URL.Query().Get("ts_query_web"))
+		_, _ = w.Write([]byte(`{"packages":[` +
+			`{"name":"ingress-nginx","stars":5,"repository":{"name":"someone","url":"https://example.com/charts"}},` +
+			`{"name":"ingress-nginx","official":true,"stars":900,"category":5,"repository":{"name":"ingress-nginx","url":"https://kubernetes.github.io/ingress-nginx/"}}]}`))
+	})
+	mux.HandleFunc("/", http.NotFound)
+
+	return httptest.NewServer(mux)
+}
+
+// Test that ArtifactHub metadata is attached by repository name or URL
+func TestAddArtifactHubInfo(t *testing.T) {
+	server := newTestArtifactHub(t)
+	defer server.Close()
+
+	client := newArtifactHubClient(artifactHubConfig{URL: server.URL})
+	repoURLs := map[string]string{"nginx": "https://kubernetes.github.io/ingress-nginx"}
+	result := []ChartVersionInfo{
+		{ReleaseName: "cache", ChartName: "redis", RepoName: "bitnami"},
+		{ReleaseName: "ingress", ChartName: "ingress-nginx", RepoName: "nginx", Category: "security"},
+		{ReleaseName: "unknown", ChartName: "private", RepoName: "internal"},
+	}
+
+	var warnings []warning
+	addArtifactHubInfo(context.Background(), client, repoURLs, result, &warnings)
+	assert.Empty(t, warnings)
+
+	require.NotNil(t, result[0].ArtifactHub)
+	assert.True(t, result[0].ArtifactHub.VerifiedPublisher)
+	assert.Equal(t, 42, result[0].ArtifactHub.Stars)
+	assert.Equal(t, "verified 42★", artifactHubColumn(result[0]))
+	assert.Equal(t, "database", result[0].Category)
+
+	require.NotNil(t, result[1].ArtifactHub)
+	assert.True(t, result[1].ArtifactHub.Official)
+	assert.Equal(t, "ingress-nginx", result[1].ArtifactHub.Repository)
+	// The category annotation of the chart wins
+	assert.Equal(t, "security", result[1].Category)
+
+	assert.Nil(t, result[2].ArtifactHub)
+}
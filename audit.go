@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// Audit event types
+const (
+	auditEventScan    = "scan"
+	auditEventUpgrade = "upgrade"
+)
+
+// Results of audited upgrades
+const (
+	auditResultUpgraded    = "upgraded"
+	auditResultFailed      = "failed"
+	auditResultTestsFailed = "tests-failed"
+	auditResultRolledBack  = "rolled-back"
+)
+
+// auditEntry is a single record of the append-only audit log. One entry is
+// written per line as JSON so the log can be shipped to compliance tooling.
+type auditEntry struct {
+	Timestamp    time.Time     `json:"timestamp"`
+	Event        string        `json:"event"`
+	User         string        `json:"user"`
+	Host         string        `json:"host,omitempty"`
+	KubeContexts []string      `json:"kubeContexts,omitempty"`
+	Args         []string      `json:"args,omitempty"`
+	Policies     []string      `json:"policies,omitempty"`
+	Releases     int           `json:"releases"`
+	Outdated     int           `json:"outdated"`
+	Actions      []auditAction `json:"actions,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// auditAction records a single action performed against a release, such as an upgrade.
+type auditAction struct {
+	Action      string `json:"action"`
+	ReleaseName string `json:"releaseName"`
+	Namespace   string `json:"namespace"`
+	FromVersion string `json:"fromVersion,omitempty"`
+	ToVersion   string `json:"toVersion,omitempty"`
+	Result      string `json:"result"`
+	Error       string `json:"error,omitempty"`
+}
+
+// newAuditEntry creates an audit entry for the given event, filled in with
+// information about who triggered the run.
+func newAuditEntry(event string) auditEntry {
+	entry := auditEntry{
+		Timestamp: time.Now().UTC(),
+		Event:     event,
+		User:      auditUser(),
+		Args:      os.Args[1:],
+	}
+	if host, err := os.Hostname(); err == nil {
+		entry.Host = host
+	}
+	return entry
+}
+
+// auditKubeContext returns the kube context of the settings: the one given
+// with --kube-context, --contexts or a cluster config, otherwise the current
+// context of the kubeconfig
+func auditKubeContext(settings *helmSettings) string {
+	if settings.KubeContext != "" {
+		return settings.KubeContext
+	}
+	raw, err := settings.RESTClientGetter().ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return ""
+	}
+	return raw.CurrentContext
+}
+
+// scanPolicies describes the policies applied by a scan: the sources of the
+// version rules, the repository policy, the minimum severity and the
+// conditions failing the run
+func scanPolicies(rules []versionRule, minimumSeverity string) []string {
+	var policies []string
+	seen := map[string]bool{}
+	add := func(policy string) {
+		if !seen[policy] {
+			seen[policy] = true
+			policies = append(policies, policy)
+		}
+	}
+
+	for _, rule := range rules {
+		add("rules:" + rule.Source)
+	}
+	if cfg.RepositoryPolicy.enabled() {
+		add("repository-policy")
+	}
+	if minimumSeverity != "" {
+		add("min-severity:" + strings.ToLower(minimumSeverity))
+	}
+	if severityHook != "" {
+		add("severity-hook:" + severityHook)
+	}
+	if failOnOutdated || quiet {
+		add("exit:fail-on-outdated")
+	}
+	if cfg.RepositoryPolicy.enabled() && cfg.RepositoryPolicy.Enforce {
+		add("exit:repository-policy")
+	}
+	return policies
+}
+
+// auditUser returns the name of the user that triggered the run
+func auditUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// writeAuditEntry appends the entry to the audit log at path. The file is
+// only ever opened in append mode so existing records are never rewritten.
+func writeAuditEntry(path string, entry auditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// recordScan writes a scan entry to the audit log if one is configured.
+// Failures are reported as warnings so they never mask the scan result.
+func recordScan(report scanReport, scanErr error) {
+	if auditLog == "" {
+		return
+	}
+
+	entry := newAuditEntry(auditEventScan)
+	entry.KubeContexts = report.kubeContexts
+	entry.Policies = report.policies
+	entry.Releases = report.releases
+	for _, versionInfo := range report.results {
+		if versionInfo.Status == statusOutdated {
+			entry.Outdated++
+		}
+	}
+	if scanErr != nil {
+		entry.Error = scanErr.Error()
+	}
+
+	if dryRun {
+		dryRunf("append %s record to audit log %s", entry.Event, auditLog)
+		return
+	}
+
+	if err := writeAuditEntry(auditLog, entry); err != nil {
+		logger.Warn("failed to record scan in audit log", "error", err)
+	}
+}
+
+// recordUpgrades writes an upgrade entry with an action per attempted upgrade
+// to the audit log if one is configured
+func recordUpgrades(results []upgradeResult) {
+	if auditLog == "" || len(results) == 0 {
+		return
+	}
+
+	entry := newAuditEntry(auditEventUpgrade)
+	if name := auditKubeContext(newSettings()); name != "" {
+		entry.KubeContexts = []string{name}
+	}
+	entry.Releases = len(results)
+	for _, result := range results {
+		action := auditAction{
+			Action:      auditEventUpgrade,
+			ReleaseName: result.ReleaseName,
+			Namespace:   result.Namespace,
+			FromVersion: result.FromVersion,
+			ToVersion:   result.ToVersion,
+			Result:      auditResultUpgraded,
+		}
+		switch {
+		case result.RolledBack:
+			action.Result = auditResultRolledBack
+		case result.Error != "":
+			action.Result = auditResultFailed
+			action.Error = result.Error
+		case result.Tests == testsFailed:
+			action.Result = auditResultTestsFailed
+		}
+		entry.Actions = append(entry.Actions, action)
+	}
+
+	if dryRun {
+		dryRunf("append %s record to audit log %s", entry.Event, auditLog)
+		return
+	}
+
+	if err := writeAuditEntry(auditLog, entry); err != nil {
+		logger.Warn("failed to record upgrades in audit log", "error", err)
+	}
+}
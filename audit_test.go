@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that audit entries are appended one JSON record per line
+func TestWriteAuditEntryAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	first := newAuditEntry(auditEventScan)
+	first.Releases = 3
+	first.Outdated = 1
+	require.NoError(t, writeAuditEntry(path, first))
+
+	second := newAuditEntry(auditEventScan)
+	second.Actions = []auditAction{{Action: "upgrade", ReleaseName: "test-release", Namespace: "default", Result: "success"}}
+	require.NoError(t, writeAuditEntry(path, second))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+
+	var decoded auditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &decoded))
+	assert.Equal(t, auditEventScan, decoded.Event)
+	assert.Equal(t, 3, decoded.Releases)
+	assert.Equal(t, 1, decoded.Outdated)
+	assert.NotEmpty(t, decoded.User)
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &decoded))
+	assert.Equal(t, "test-release", decoded.Actions[0].ReleaseName)
+}
+
+// Test that scan records hold the kube contexts of the targets and the policies applied
+func TestRecordScanPolicies(t *testing.T) {
+	auditLog = filepath.Join(t.TempDir(), "audit.log")
+	failOnOutdated = true
+	cfg.RepositoryPolicy = repositoryPolicy{Allow: []string{"bitnami"}, Enforce: true}
+	defer func() {
+		auditLog = ""
+		failOnOutdated = false
+		cfg.RepositoryPolicy = repositoryPolicy{}
+	}()
+
+	rules := []versionRule{
+		{Source: "--ignore", Ignore: true},
+		{Source: "pins.yaml", Constraint: "~1.2"},
+		{Source: "pins.yaml", Constraint: "~2.0"},
+	}
+	report := scanReport{
+		results:      []ChartVersionInfo{{ReleaseName: "web", Status: statusOutdated}},
+		releases:     1,
+		kubeContexts: []string{"prod-eu", "prod-us"},
+		policies:     scanPolicies(rules, "MINOR"),
+	}
+	recordScan(report, nil)
+
+	data, err := os.ReadFile(auditLog)
+	require.NoError(t, err)
+	var entry auditEntry
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.Equal(t, []string{"prod-eu", "prod-us"}, entry.KubeContexts)
+	assert.Equal(t, []string{
+		"rules:--ignore",
+		"rules:pins.yaml",
+		"repository-policy",
+		"min-severity:minor",
+		"exit:fail-on-outdated",
+		"exit:repository-policy",
+	}, entry.Policies)
+	assert.Equal(t, 1, entry.Outdated)
+}
+
+// Test that the audited kube context is the one of the target, not of the environment
+func TestAuditKubeContext(t *testing.T) {
+	t.Setenv("HELM_KUBECONTEXT", "from-env")
+	settings := newSettings()
+	settings.KubeContext = "prod-eu"
+	assert.Equal(t, "prod-eu", auditKubeContext(settings))
+}
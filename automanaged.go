@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// flagAutoManaged marks outdated releases that a GitOps controller upgrades
+// automatically, so nobody needs to upgrade them by hand
+const flagAutoManaged = "AutoManaged"
+
+// GitOps controllers reconciling Helm releases
+const (
+	autoManagedByFlux   = "flux"
+	autoManagedByArgoCD = "argocd"
+)
+
+// Labels and annotations GitOps controllers put on the objects they
+// reconcile: the Flux helm-controller labels every object of a HelmRelease
+// with its name and namespace, and Argo CD tracks the objects of an
+// Application with its name.
+const (
+	fluxNameLabel       = "helm.toolkit.fluxcd.io/name"
+	fluxNamespaceLabel  = "helm.toolkit.fluxcd.io/namespace"
+	argoInstanceLabel   = "argocd.argoproj.io/instance"
+	argoTrackingIDLabel = "argocd.argoproj.io/tracking-id"
+)
+
+// gitOpsOwner is the object of a GitOps controller deploying a release: a
+// Flux HelmRelease or an Argo CD Application. The namespace of an Argo CD
+// Application is only known when it lives outside the Argo CD namespace.
+type gitOpsOwner struct {
+	tool      string
+	namespace string
+	name      string
+}
+
+// releaseGitOpsOwner returns the object of the GitOps controller reconciling
+// the release, and false when there is none. Release labels are checked
+// first, then the labels and annotations of the objects of the manifest.
+func releaseGitOpsOwner(rel *helmRelease) (gitOpsOwner, bool) {
+	if owner, ok := gitOpsOwnerOf(rel.Labels); ok {
+		return owner, true
+	}
+
+	var owner gitOpsOwner
+	found := false
+	_ = decodeDocuments([]byte(rel.Manifest), func(decoder *yaml.Decoder) error {
+		var object manifestObject
+		if err := decoder.Decode(&object); err != nil {
+			return err
+		}
+		if !found {
+			owner, found = gitOpsOwnerOf(object.Metadata.Labels)
+		}
+		if !found {
+			owner, found = gitOpsOwnerOf(object.Metadata.Annotations)
+		}
+		return nil
+	})
+	return owner, found
+}
+
+// gitOpsOwnerOf returns the GitOps object named by the given labels or annotations
+func gitOpsOwnerOf(metadata map[string]string) (gitOpsOwner, bool) {
+	if name, ok := metadata[fluxNameLabel]; ok {
+		return gitOpsOwner{tool: autoManagedByFlux, namespace: metadata[fluxNamespaceLabel], name: name}, true
+	}
+	// Tracking IDs look like APP:GROUP/KIND:NAMESPACE/NAME, where APP is
+	// NAMESPACE_NAME for Applications outside the Argo CD namespace
+	app, ok := metadata[argoInstanceLabel]
+	if id, tracked := metadata[argoTrackingIDLabel]; tracked {
+		app, _, _ = strings.Cut(id, ":")
+		ok = true
+	}
+	if !ok {
+		return gitOpsOwner{}, false
+	}
+	if namespace, name, found := strings.Cut(app, "_"); found {
+		return gitOpsOwner{tool: autoManagedByArgoCD, namespace: namespace, name: name}, true
+	}
+	return gitOpsOwner{tool: autoManagedByArgoCD, name: app}, true
+}
+
+// releaseAutoManagedBy returns the GitOps controller reconciling the release,
+// or an empty string
+func releaseAutoManagedBy(rel *helmRelease) string {
+	owner, _ := releaseGitOpsOwner(rel)
+	return owner.tool
+}
+
+// checkAutoManaged flags the outdated results whose release is reconciled by
+// a GitOps controller. Up to date releases are left alone so they don't show
+// up in the table just for being managed.
+func checkAutoManaged(releases []*helmRelease, result []ChartVersionInfo) {
+	managed := make(map[string]bool, len(releases))
+	for _, rel := range releases {
+		if releaseAutoManagedBy(rel) != "" {
+			managed[rel.Namespace+"/"+rel.Name] = true
+		}
+	}
+
+	for i := range result {
+		if managed[findingKey(result[i])] && (result[i].Status == statusOutdated || result[i].Status == statusVulnerable) {
+			result[i].Flags = append(result[i].Flags, flagAutoManaged)
+		}
+	}
+}
+
+// filterAutoManagedReleases drops the releases reconciled by GitOps controllers
+func filterAutoManagedReleases(releases []*helmRelease) []*helmRelease {
+	var filtered []*helmRelease
+	for _, rel := range releases {
+		if releaseAutoManagedBy(rel) == "" {
+			filtered = append(filtered, rel)
+		}
+	}
+	return filtered
+}
+
+// autoManagedHint returns the hint shown for outdated releases upgraded by a GitOps controller
+func autoManagedHint(versionInfo ChartVersionInfo) string {
+	return fmt.Sprintf("Release %s is managed by a GitOps controller, which reverts upgrades made with Helm. Let the controller upgrade it.", versionInfo.ReleaseName)
+}
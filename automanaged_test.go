@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Test that releases reconciled by Flux or Argo CD are recognized by the markers on their objects
+func TestReleaseAutoManagedBy(t *testing.T) {
+	flux := newTestRelease("podinfo", "apps", "podinfo", "6.0.0")
+	flux.Manifest = `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: podinfo
+  labels:
+    helm.toolkit.fluxcd.io/name: podinfo
+    helm.toolkit.fluxcd.io/namespace: flux-system
+`
+	argo := newTestRelease("redis", "apps", "redis", "17.0.0")
+	argo.Manifest = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: redis
+  annotations:
+    argocd.argoproj.io/tracking-id: redis:apps/StatefulSet:apps/redis
+`
+	labeled := newTestRelease("web", "apps", "nginx", "1.0.0")
+	labeled.Labels = map[string]string{"helm.toolkit.fluxcd.io/name": "web"}
+	cli := newTestRelease("db", "apps", "postgresql", "12.0.0")
+	cli.Manifest = "apiVersion: v1\nkind: Service\nmetadata:\n  name: db\n"
+
+	assert.Equal(t, autoManagedByFlux, releaseAutoManagedBy(flux))
+	assert.Equal(t, autoManagedByArgoCD, releaseAutoManagedBy(argo))
+	assert.Equal(t, autoManagedByFlux, releaseAutoManagedBy(labeled))
+	assert.Empty(t, releaseAutoManagedBy(cli))
+
+	filtered := filterAutoManagedReleases([]*release.Release{flux, argo, labeled, cli})
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "db", filtered[0].Name)
+
+	// Only outdated releases are flagged
+	result := []ChartVersionInfo{
+		{ReleaseName: "podinfo", Namespace: "apps", Status: statusOutdated},
+		{ReleaseName: "redis", Namespace: "apps", Status: statusUptodate},
+		{ReleaseName: "db", Namespace: "apps", Status: statusOutdated},
+	}
+	checkAutoManaged([]*release.Release{flux, argo, cli}, result)
+	assert.Equal(t, []string{flagAutoManaged}, result[0].Flags)
+	assert.Empty(t, result[1].Flags)
+	assert.Empty(t, result[2].Flags)
+}
+
+// Test that releases upgraded by a GitOps controller are not upgraded
+func TestUpgradeCandidatesAutoManaged(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "default", RepoName: "bitnami", RepoConfidence: confidenceExact, Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "1.1.0"},
+		{ReleaseName: "podinfo", Namespace: "default", Status: statusOutdated, Flags: []string{flagAutoManaged}},
+	}
+
+	candidates, err := upgradeCandidates(result, nil)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "web", candidates[0].ReleaseName)
+
+	_, err = upgradeCandidates(result, []string{"podinfo"})
+	assert.ErrorContains(t, err, "managed by a GitOps controller")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+)
+
+// progressiveDeliverySuffixes are the release name suffixes used by Flagger
+// (primary/canary) and Argo Rollouts (stable/canary/preview) when the same
+// chart is deployed several times for a single logical application. They are
+// listed in order of preference for the entry that represents the group.
+var progressiveDeliverySuffixes = []string{"-primary", "-stable", "-canary", "-preview"}
+
+// canaryBaseName returns the logical name of a release and the rank of its
+// suffix. Releases without a progressive delivery suffix rank first.
+func canaryBaseName(name string) (string, int) {
+	for rank, suffix := range progressiveDeliverySuffixes {
+		if base := strings.TrimSuffix(name, suffix); base != name && base != "" {
+			return base, rank + 1
+		}
+	}
+	return name, 0
+}
+
+// collapseCanaries merges releases of the same chart in the same namespace that
+// only differ by a progressive delivery suffix (e.g. app, app-primary and
+// app-canary) into a single entry, so transient canary releases are not
+// double-counted or suggested for upgrades. The names of the merged releases
+// are listed in Variants of the entry that is kept.
+func collapseCanaries(result []ChartVersionInfo) []ChartVersionInfo {
+	type group struct {
+		index int
+		rank  int
+		size  int
+	}
+
+	groups := make(map[string]*group)
+	var collapsed []ChartVersionInfo
+	var variants [][]string
+
+	for _, versionInfo := range result {
+		base, rank := canaryBaseName(versionInfo.ReleaseName)
+		key := versionInfo.Namespace + "/" + versionInfo.ChartName + "/" + base
+
+		g, exists := groups[key]
+		if !exists {
+			groups[key] = &group{index: len(collapsed), rank: rank, size: 1}
+			collapsed = append(collapsed, versionInfo)
+			variants = append(variants, []string{versionInfo.ReleaseName})
+			continue
+		}
+
+		g.size++
+		variants[g.index] = append(variants[g.index], versionInfo.ReleaseName)
+		if rank < g.rank {
+			g.rank = rank
+			collapsed[g.index] = versionInfo
+		}
+	}
+
+	for _, g := range groups {
+		if g.size > 1 {
+			collapsed[g.index].Variants = variants[g.index]
+		}
+	}
+
+	return collapsed
+}
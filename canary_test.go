@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that primary/canary releases are collapsed into one logical entry
+func TestCollapseCanaries(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "podinfo-canary", Namespace: "apps", ChartName: "podinfo", InstalledVersion: "6.1.0"},
+		{ReleaseName: "podinfo-primary", Namespace: "apps", ChartName: "podinfo", InstalledVersion: "6.0.0"},
+		{ReleaseName: "api", Namespace: "apps", ChartName: "api"},
+		{ReleaseName: "api-canary", Namespace: "apps", ChartName: "api"},
+		{ReleaseName: "cache-canary", Namespace: "apps", ChartName: "redis"},
+		{ReleaseName: "podinfo-primary", Namespace: "other", ChartName: "podinfo"},
+	}
+
+	collapsed := collapseCanaries(result)
+	assert.Len(t, collapsed, 4)
+
+	assert.Equal(t, "podinfo-primary", collapsed[0].ReleaseName)
+	assert.Equal(t, "6.0.0", collapsed[0].InstalledVersion)
+	assert.Equal(t, []string{"podinfo-canary", "podinfo-primary"}, collapsed[0].Variants)
+
+	assert.Equal(t, "api", collapsed[1].ReleaseName)
+	assert.Equal(t, []string{"api", "api-canary"}, collapsed[1].Variants)
+
+	assert.Equal(t, "cache-canary", collapsed[2].ReleaseName)
+	assert.Empty(t, collapsed[2].Variants)
+	assert.Empty(t, collapsed[3].Variants)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// outputFormats are the formats accepted by -o, custom-columns and jsonpath
+// taking their spec after an equals sign
+var outputFormats = []string{
+	outputFormatTable,
+	outputFormatWide,
+	outputFormatPlain,
+	outputFormatShort,
+	outputFormatJSON,
+	outputFormatYAML,
+	outputFormatDot,
+	outputFormatMarkdown,
+	outputFormatHTML,
+	outputFormatCodeQuality,
+	outputFormatPrometheus,
+	strings.TrimSuffix(customColumnsPrefix, "="),
+	strings.TrimSuffix(jsonPathPrefix, "="),
+}
+
+// policyFeatures are the ways to control which versions are reported
+var policyFeatures = []string{
+	"ignore",
+	"release-annotations",
+	"pin-file",
+	"renovate-config",
+	"policy-file",
+	"catalog",
+	"min-version-age",
+	"min-severity",
+	"trivial-updates",
+	"repository-policy",
+	"severity-hook",
+}
+
+// capabilityWarning is a warning code the binary can report
+type capabilityWarning struct {
+	Code warningCode `json:"code"`
+	Name string      `json:"name"`
+}
+
+// capabilities describes the features of the installed binary, so wrapper
+// tooling can adapt its invocation to the plugin version it finds
+type capabilities struct {
+	Version          string              `json:"version"`
+	Subcommands      []string            `json:"subcommands"`
+	OutputFormats    []string            `json:"outputFormats"`
+	Resolvers        []string            `json:"resolvers"`
+	Notifiers        []string            `json:"notifiers"`
+	PolicyFeatures   []string            `json:"policyFeatures"`
+	UpdateSeverities []string            `json:"updateSeverities"`
+	Analyzers        []string            `json:"analyzers"`
+	Warnings         []capabilityWarning `json:"warnings"`
+}
+
+// newCapabilitiesCmd creates the capabilities subcommand
+func newCapabilitiesCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "capabilities",
+		Short: "list the output formats, repository resolvers, notifiers and policy features of this binary",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return printCapabilities(os.Stdout, output, binaryCapabilities(cmd.Root()))
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputFormatTable, "output format. Accepted formats: table, json, yaml")
+
+	return cmd
+}
+
+// binaryCapabilities collects the capabilities of the binary with the
+// subcommands of root
+func binaryCapabilities(root *cobra.Command) capabilities {
+	var subcommands []string
+	for _, sub := range root.Commands() {
+		if sub.IsAvailableCommand() {
+			subcommands = append(subcommands, sub.Name())
+		}
+	}
+
+	warnings := make([]capabilityWarning, 0, len(warningNames))
+	for code, name := range warningNames {
+		warnings = append(warnings, capabilityWarning{Code: code, Name: name})
+	}
+	sort.Slice(warnings, func(i, j int) bool { return warnings[i].Code < warnings[j].Code })
+
+	return capabilities{
+		Version:          version,
+		Subcommands:      subcommands,
+		OutputFormats:    outputFormats,
+		Resolvers:        confidenceLevels,
+		Notifiers:        notifierTypes,
+		PolicyFeatures:   policyFeatures,
+		UpdateSeverities: updateSeverities,
+		Analyzers:        analyzerNames(),
+		Warnings:         warnings,
+	}
+}
+
+// printCapabilities writes the capabilities in the requested format
+func printCapabilities(w io.Writer, output string, caps capabilities) error {
+	switch output {
+	case outputFormatJSON:
+		outputBytes, err := json.MarshalIndent(caps, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatYML, outputFormatYAML:
+		outputBytes, err := yaml.Marshal(caps)
+		if err != nil {
+			return fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		fmt.Fprint(w, string(outputBytes))
+	case outputFormatTable:
+		warnings := make([]string, 0, len(caps.Warnings))
+		for _, warning := range caps.Warnings {
+			warnings = append(warnings, string(warning.Code)+" "+warning.Name)
+		}
+		table := uitable.New()
+		table.Wrap = true
+		table.MaxColWidth = 80
+		table.AddRow("VERSION", caps.Version)
+		table.AddRow("SUBCOMMANDS", strings.Join(caps.Subcommands, ", "))
+		table.AddRow("OUTPUT FORMATS", strings.Join(caps.OutputFormats, ", "))
+		table.AddRow("RESOLVERS", strings.Join(caps.Resolvers, ", "))
+		table.AddRow("NOTIFIERS", strings.Join(caps.Notifiers, ", "))
+		table.AddRow("POLICY FEATURES", strings.Join(caps.PolicyFeatures, ", "))
+		table.AddRow("UPDATE SEVERITIES", strings.Join(caps.UpdateSeverities, ", "))
+		table.AddRow("ANALYZERS", strings.Join(caps.Analyzers, ", "))
+		table.AddRow("WARNINGS", strings.Join(warnings, ", "))
+		fmt.Fprintln(w, table)
+	default:
+		return fmt.Errorf("invalid formatter: %s", output)
+	}
+	return nil
+}
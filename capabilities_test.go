@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the capabilities list the subcommands and features of the binary
+func TestCapabilities(t *testing.T) {
+	root := &cobra.Command{Use: "whatup"}
+	root.AddCommand(newResolveCmd(), newCapabilitiesCmd())
+
+	var out bytes.Buffer
+	require.NoError(t, printCapabilities(&out, outputFormatJSON, binaryCapabilities(root)))
+
+	var caps capabilities
+	require.NoError(t, json.Unmarshal(out.Bytes(), &caps))
+	assert.Equal(t, []string{"capabilities", "resolve"}, caps.Subcommands)
+	assert.Contains(t, caps.OutputFormats, "jsonpath")
+	assert.Contains(t, caps.Resolvers, confidenceExact)
+	assert.Equal(t, []string{"webhook", "slack"}, caps.Notifiers)
+	require.NotEmpty(t, caps.Warnings)
+	assert.Equal(t, capabilityWarning{Code: warnRepoUnresolved, Name: "RepoUnresolved"}, caps.Warnings[0])
+
+	assert.Error(t, printCapabilities(&out, "xml", caps))
+}
+
+// Test that every advertised output format is accepted
+func TestOutputFormatsAccepted(t *testing.T) {
+	defer func() { outputFormat = outputFormatTable }()
+
+	report := scanReport{results: []ChartVersionInfo{{ReleaseName: "web", Status: statusOutdated}}}
+	for _, format := range outputFormats {
+		switch format {
+		case "custom-columns":
+			format = customColumnsPrefix + "NAME:.releaseName"
+		case "jsonpath":
+			format = jsonPathPrefix + "{.results[0].releaseName}"
+		}
+		outputFormat = format
+		assert.NoError(t, formatAndPrintResults(io.Discard, report), format)
+	}
+}
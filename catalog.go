@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// catalogTimeout limits the download of a catalog from an endpoint
+const catalogTimeout = 30 * time.Second
+
+// approvedCatalog is the catalog of chart versions approved by the
+// organization, e.g.
+//
+//	charts:
+//	  ingress-nginx: [4.10.1, 4.11.0]
+//	  cert-manager: [v1.14.5]
+type approvedCatalog struct {
+	Charts map[string][]string `json:"charts"`
+}
+
+// catalogLocation returns the catalog file or URL given with --catalog or in the config file
+func catalogLocation() string {
+	if catalogPath != "" {
+		return catalogPath
+	}
+	return cfg.Catalog
+}
+
+// loadCatalogRules reads the approved versions catalog from a file or an
+// HTTP(S) endpoint and turns every chart into a version rule, so the latest
+// version reported for a chart is its latest approved version. Charts missing
+// from the catalog are compared against upstream as usual.
+func loadCatalogRules(ctx context.Context, location string) ([]versionRule, error) {
+	data, err := readFileOrURL(ctx, &http.Client{Timeout: catalogTimeout}, location, "catalog")
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog approvedCatalog
+	if err := yaml.UnmarshalStrict(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog %s: %w", location, err)
+	}
+
+	charts := make([]string, 0, len(catalog.Charts))
+	for chart := range catalog.Charts {
+		charts = append(charts, chart)
+	}
+	sort.Strings(charts)
+
+	rules := make([]versionRule, 0, len(charts))
+	for _, chart := range charts {
+		rules = append(rules, versionRule{
+			Source:           location,
+			Charts:           []string{chart},
+			ApprovedVersions: catalog.Charts[chart],
+		})
+	}
+	return rules, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that the latest version of a chart in the catalog is its latest approved version
+func TestCatalogRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("charts:\n  ingress-nginx: [4.10.0, 4.10.1]\n"), 0o600))
+
+	rules, err := loadCatalogRules(context.Background(), path)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	releases := []*release.Release{
+		newTestRelease("ingress", "default", "ingress-nginx", "4.10.0"),
+		newTestRelease("edge", "default", "ingress-nginx", "4.11.0"),
+		newTestRelease("web", "default", "nginx", "1.0.0"),
+	}
+	repositories := []repoIndex{
+		newTestIndex("ingress-nginx", "4.11.0", "4.10.1", "4.10.0"),
+		newTestIndex("nginx", "1.1.0", "1.0.0"),
+	}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, rules, nil, &warnings)
+	require.Len(t, result, 3)
+
+	assert.Equal(t, "4.10.1", result[0].LatestVersion)
+	assert.Equal(t, "4.11.0", result[0].UpstreamVersion)
+	assert.Equal(t, statusOutdated, result[0].Status)
+
+	// Installed versions that are not approved are flagged
+	assert.Equal(t, statusUptodate, result[1].Status)
+	assert.True(t, hasFlag(result[1], flagOutsideConstraint))
+
+	assert.Equal(t, "1.1.0", result[2].LatestVersion)
+	assert.Empty(t, result[2].UpstreamVersion)
+}
+
+// Test that the catalog can be served by an endpoint
+func TestCatalogRulesEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"charts": {"cert-manager": ["v1.14.5"]}}`))
+	}))
+	defer server.Close()
+
+	rules, err := loadCatalogRules(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, []string{"cert-manager"}, rules[0].Charts)
+	assert.Equal(t, []string{"v1.14.5"}, rules[0].ApprovedVersions)
+
+	server.Close()
+	_, err = loadCatalogRules(context.Background(), server.URL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// statusChange is a record of the change log: a release whose status differs
+// from the one it had in the previous run. One change is written per line as
+// JSON, so the log is an event history auditors can replay without a database.
+type statusChange struct {
+	Timestamp        time.Time `json:"timestamp"`
+	Cluster          string    `json:"cluster,omitempty"`
+	Namespace        string    `json:"namespace"`
+	ReleaseName      string    `json:"releaseName"`
+	ChartName        string    `json:"chartName"`
+	FromStatus       string    `json:"fromStatus,omitempty"`
+	ToStatus         string    `json:"toStatus"`
+	InstalledVersion string    `json:"installedVersion,omitempty"`
+	LatestVersion    string    `json:"latestVersion,omitempty"`
+}
+
+// loadLastStatuses replays the change log at path and returns the last
+// status of every release. A missing log holds no statuses.
+func loadLastStatuses(path string) (map[string]string, error) {
+	statuses := make(map[string]string)
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return statuses, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open change log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var change statusChange
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d of %s: %w", line, path, err)
+		}
+		statuses[reportKey(ChartVersionInfo{Cluster: change.Cluster, Namespace: change.Namespace, ReleaseName: change.ReleaseName})] = change.ToStatus
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read change log: %w", err)
+	}
+	return statuses, nil
+}
+
+// statusChanges returns a change for every release whose status differs from
+// its last one. Releases seen for the first time have no previous status.
+func statusChanges(last map[string]string, result []ChartVersionInfo, now time.Time) []statusChange {
+	var changes []statusChange
+	for _, versionInfo := range result {
+		previous := last[reportKey(versionInfo)]
+		if previous == versionInfo.Status {
+			continue
+		}
+		changes = append(changes, statusChange{
+			Timestamp:        now.UTC(),
+			Cluster:          versionInfo.Cluster,
+			Namespace:        versionInfo.Namespace,
+			ReleaseName:      versionInfo.ReleaseName,
+			ChartName:        versionInfo.ChartName,
+			FromStatus:       previous,
+			ToStatus:         versionInfo.Status,
+			InstalledVersion: versionInfo.InstalledVersion,
+			LatestVersion:    versionInfo.LatestVersion,
+		})
+	}
+	return changes
+}
+
+// appendStatusChanges appends the changes to the change log at path. The file
+// is only ever opened in append mode so existing records are never rewritten.
+func appendStatusChanges(path string, changes []statusChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open change log: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, change := range changes {
+		line, err := json.Marshal(change)
+		if err != nil {
+			return fmt.Errorf("failed to marshal status change: %w", err)
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write change log: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write change log: %w", err)
+	}
+	return nil
+}
+
+// recordStatusChanges appends the status changes of a scan to the change log
+// if one is configured. Failed scans are not recorded since their results are
+// incomplete, and failures are printed so they never mask the scan result.
+func recordStatusChanges(result []ChartVersionInfo, scanErr error) {
+	if changeLog == "" || scanErr != nil {
+		return
+	}
+
+	last, err := loadLastStatuses(changeLog)
+	if err != nil {
+		logger.Warn("failed to read change log", "error", err)
+		return
+	}
+	changes := statusChanges(last, result, time.Now())
+
+	if dryRun {
+		dryRunf("append %d status changes to change log %s", len(changes), changeLog)
+		return
+	}
+
+	if err := appendStatusChanges(changeLog, changes); err != nil {
+		logger.Warn("failed to record status changes", "error", err)
+	}
+}
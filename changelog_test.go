@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that only status changes since the previous run are appended to the change log
+func TestStatusChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "changes.jsonl")
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	last, err := loadLastStatuses(path)
+	require.NoError(t, err)
+	assert.Empty(t, last)
+
+	first := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "1.0.0", Status: statusUptodate},
+		{ReleaseName: "db", Namespace: "prod", ChartName: "postgresql", InstalledVersion: "12.0.0", LatestVersion: "13.0.0", Status: statusOutdated},
+	}
+	changes := statusChanges(last, first, now)
+	require.Len(t, changes, 2)
+	assert.Empty(t, changes[0].FromStatus)
+	require.NoError(t, appendStatusChanges(path, changes))
+
+	second := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "1.1.0", Status: statusOutdated},
+		{ReleaseName: "db", Namespace: "prod", ChartName: "postgresql", InstalledVersion: "12.0.0", LatestVersion: "13.1.0", Status: statusOutdated},
+	}
+	last, err = loadLastStatuses(path)
+	require.NoError(t, err)
+	changes = statusChanges(last, second, now.Add(time.Hour))
+	require.Len(t, changes, 1)
+	assert.Equal(t, statusChange{
+		Timestamp:        now.Add(time.Hour),
+		Namespace:        "prod",
+		ReleaseName:      "web",
+		ChartName:        "nginx",
+		FromStatus:       statusUptodate,
+		ToStatus:         statusOutdated,
+		InstalledVersion: "1.0.0",
+		LatestVersion:    "1.1.0",
+	}, changes[0])
+	require.NoError(t, appendStatusChanges(path, changes))
+	require.NoError(t, appendStatusChanges(path, nil))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3)
+
+	last, err = loadLastStatuses(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"prod/web": statusOutdated, "prod/db": statusOutdated}, last)
+}
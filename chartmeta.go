@@ -0,0 +1,43 @@
+package main
+
+// categoryAnnotation is the chart annotation holding the ArtifactHub category
+// of a chart, e.g. database or monitoring-logging
+const categoryAnnotation = "artifacthub.io/category"
+
+// artifactHubCategories maps the package categories of the ArtifactHub API to
+// the names used by the category annotation
+var artifactHubCategories = map[int]string{
+	1: "ai-machine-learning",
+	2: "database",
+	3: "integration-delivery",
+	4: "monitoring-logging",
+	5: "networking",
+	6: "security",
+	7: "storage",
+	8: "streaming-messaging",
+}
+
+// addChartMetadata records the icon and category of the installed chart of
+// every result, so visual reports can show the application and group releases
+// by category
+func addChartMetadata(releases []*helmRelease, result []ChartVersionInfo) {
+	byKey := make(map[string]*helmRelease, len(releases))
+	for _, rel := range releases {
+		byKey[rel.Namespace+"/"+rel.Name] = rel
+	}
+
+	for i := range result {
+		rel, ok := byKey[findingKey(result[i])]
+		if !ok || rel.Chart == nil || rel.Chart.Metadata == nil {
+			continue
+		}
+		result[i].ChartIcon = rel.Chart.Metadata.Icon
+		result[i].Category = rel.Chart.Metadata.Annotations[categoryAnnotation]
+	}
+}
+
+// artifactHubCategory returns the category of an ArtifactHub package, or an
+// empty string when ArtifactHub did not categorize it
+func artifactHubCategory(pkg *artifactHubPackage) string {
+	return artifactHubCategories[pkg.Category]
+}
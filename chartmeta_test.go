@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Test that the icon and category annotation of the installed chart are recorded
+func TestAddChartMetadata(t *testing.T) {
+	db := newTestRelease("db", "default", "postgresql", "12.0.0")
+	db.Chart.Metadata.Icon = "https://example.com/postgresql.svg"
+	db.Chart.Metadata.Annotations = map[string]string{categoryAnnotation: "database"}
+	web := newTestRelease("web", "default", "nginx", "1.0.0")
+
+	result := []ChartVersionInfo{
+		{ReleaseName: "db", Namespace: "default"},
+		{ReleaseName: "web", Namespace: "default"},
+	}
+	addChartMetadata([]*release.Release{db, web}, result)
+
+	assert.Equal(t, "https://example.com/postgresql.svg", result[0].ChartIcon)
+	assert.Equal(t, "database", result[0].Category)
+	assert.Empty(t, result[1].ChartIcon)
+	assert.Empty(t, result[1].Category)
+}
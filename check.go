@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultCheckAbandonedMonths is the abandoned chart threshold of the
+// abandoned analyzer when none is configured
+const defaultCheckAbandonedMonths = 12
+
+// builtinAnalyzers are the analyzers shipped with whatup
+var builtinAnalyzers = []Analyzer{
+	analyzerFunc{
+		name:        "version-drift",
+		description: "newer chart versions are available, or the installed one is affected by an advisory",
+		run:         versionDriftFindings,
+	},
+	analyzerFunc{
+		name:        "deprecated-chart",
+		description: "the installed chart is marked as deprecated",
+		run:         deprecatedChartFindings,
+	},
+	analyzerFunc{
+		name:        "deprecated-api",
+		description: "the release manifest uses Kubernetes APIs that were removed",
+		run:         deprecatedAPIFindings,
+	},
+	analyzerFunc{
+		name:        "policy",
+		description: "the release violates the repository policy or its pinned version range",
+		run:         policyFindings,
+	},
+	analyzerFunc{
+		name:        "abandoned",
+		description: "the chart has not published a new version for a long time",
+		run:         abandonedFindings,
+	},
+}
+
+// versionDriftFindings reports releases with a newer chart version or
+// affected by an advisory. Major updates are warnings, other updates info.
+func versionDriftFindings(_ context.Context, rc ReleaseContext) []Finding {
+	versionInfo := rc.Result
+	switch versionInfo.Status {
+	case statusOutdated:
+		severity := findingInfo
+		if versionInfo.UpdateSeverity == "MAJOR" {
+			severity = findingWarning
+		}
+		message := fmt.Sprintf("%s %s can be updated to %s", versionInfo.ChartName, versionInfo.InstalledVersion, versionInfo.LatestVersion)
+		if versionInfo.UpdateSeverity != "" {
+			message += fmt.Sprintf(" (%s update)", versionInfo.UpdateSeverity)
+		}
+		return []Finding{rc.Finding(severity, "%s", message)}
+	case statusVulnerable:
+		return []Finding{rc.Finding(findingError, "%s %s is affected by %s", versionInfo.ChartName, versionInfo.InstalledVersion, advisoriesColumn(versionInfo))}
+	}
+	return nil
+}
+
+// deprecatedChartFindings reports releases of charts marked as deprecated
+func deprecatedChartFindings(_ context.Context, rc ReleaseContext) []Finding {
+	rel := rc.Release
+	if rel == nil || rel.Chart == nil || rel.Chart.Metadata == nil || !rel.Chart.Metadata.Deprecated {
+		return nil
+	}
+	return []Finding{rc.Finding(findingWarning, "chart %s %s is deprecated", rc.Result.ChartName, rc.Result.InstalledVersion)}
+}
+
+// removedAPI is a Kubernetes API version that was removed
+type removedAPI struct {
+	removedIn   string
+	replacement string
+}
+
+// removedAPIs are the removed Kubernetes APIs by "apiVersion kind"
+var removedAPIs = map[string]removedAPI{
+	"extensions/v1beta1 Deployment":        {"1.16", "apps/v1"},
+	"extensions/v1beta1 DaemonSet":         {"1.16", "apps/v1"},
+	"extensions/v1beta1 ReplicaSet":        {"1.16", "apps/v1"},
+	"extensions/v1beta1 NetworkPolicy":     {"1.16", "networking.k8s.io/v1"},
+	"extensions/v1beta1 PodSecurityPolicy": {"1.16", "policy/v1beta1"},
+	"extensions/v1beta1 Ingress":           {"1.22", "networking.k8s.io/v1"},
+	"apps/v1beta1 Deployment":              {"1.16", "apps/v1"},
+	"apps/v1beta1 StatefulSet":             {"1.16", "apps/v1"},
+	"apps/v1beta2 Deployment":              {"1.16", "apps/v1"},
+	"apps/v1beta2 StatefulSet":             {"1.16", "apps/v1"},
+	"apps/v1beta2 DaemonSet":               {"1.16", "apps/v1"},
+	"apps/v1beta2 ReplicaSet":              {"1.16", "apps/v1"},
+
+	"networking.k8s.io/v1beta1 Ingress":                                   {"1.22", "networking.k8s.io/v1"},
+	"networking.k8s.io/v1beta1 IngressClass":                              {"1.22", "networking.k8s.io/v1"},
+	"rbac.authorization.k8s.io/v1beta1 ClusterRole":                       {"1.22", "rbac.authorization.k8s.io/v1"},
+	"rbac.authorization.k8s.io/v1beta1 ClusterRoleBinding":                {"1.22", "rbac.authorization.k8s.io/v1"},
+	"rbac.authorization.k8s.io/v1beta1 Role":                              {"1.22", "rbac.authorization.k8s.io/v1"},
+	"rbac.authorization.k8s.io/v1beta1 RoleBinding":                       {"1.22", "rbac.authorization.k8s.io/v1"},
+	"apiextensions.k8s.io/v1beta1 CustomResourceDefinition":               {"1.22", "apiextensions.k8s.io/v1"},
+	"admissionregistration.k8s.io/v1beta1 MutatingWebhookConfiguration":   {"1.22", "admissionregistration.k8s.io/v1"},
+	"admissionregistration.k8s.io/v1beta1 ValidatingWebhookConfiguration": {"1.22", "admissionregistration.k8s.io/v1"},
+	"scheduling.k8s.io/v1beta1 PriorityClass":                             {"1.22", "scheduling.k8s.io/v1"},
+
+	"batch/v1beta1 CronJob":                           {"1.25", "batch/v1"},
+	"policy/v1beta1 PodDisruptionBudget":              {"1.25", "policy/v1"},
+	"policy/v1beta1 PodSecurityPolicy":                {"1.25", ""},
+	"autoscaling/v2beta1 HorizontalPodAutoscaler":     {"1.25", "autoscaling/v2"},
+	"autoscaling/v2beta2 HorizontalPodAutoscaler":     {"1.26", "autoscaling/v2"},
+	"flowcontrol.apiserver.k8s.io/v1beta1 FlowSchema": {"1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	"flowcontrol.apiserver.k8s.io/v1beta2 FlowSchema": {"1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	"storage.k8s.io/v1beta1 CSIStorageCapacity":       {"1.27", "storage.k8s.io/v1"},
+}
+
+// manifestObject is the part of a rendered manifest the analyzers look at
+type manifestObject struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name        string            `yaml:"name"`
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+}
+
+// deprecatedAPIFindings reports the objects of the release manifest using
+// removed Kubernetes APIs. Manifests that can't be parsed are skipped.
+func deprecatedAPIFindings(_ context.Context, rc ReleaseContext) []Finding {
+	if rc.Release == nil {
+		return nil
+	}
+	var findings []Finding
+	_ = decodeDocuments([]byte(rc.Release.Manifest), func(decoder *yaml.Decoder) error {
+		var object manifestObject
+		if err := decoder.Decode(&object); err != nil {
+			return err
+		}
+		api, removed := removedAPIs[object.APIVersion+" "+object.Kind]
+		if !removed {
+			return nil
+		}
+		message := fmt.Sprintf("%s %s uses %s, removed in Kubernetes %s", object.Kind, object.Metadata.Name, object.APIVersion, api.removedIn)
+		if api.replacement != "" {
+			message += fmt.Sprintf(", use %s", api.replacement)
+		}
+		findings = append(findings, rc.Finding(findingError, "%s", message))
+		return nil
+	})
+	return findings
+}
+
+// policyFindings reports releases from unapproved repositories, an error, or
+// installed outside their pinned version range
+func policyFindings(_ context.Context, rc ReleaseContext) []Finding {
+	var findings []Finding
+	if hasFlag(rc.Result, flagUnapprovedRepo) {
+		findings = append(findings, rc.Finding(findingError, "installed from repository %s, which is not approved by the repository policy", rc.Result.RepoName))
+	}
+	if hasFlag(rc.Result, flagOutsideConstraint) {
+		findings = append(findings, rc.Finding(findingWarning, "installed version %s is outside the pinned version range", rc.Result.InstalledVersion))
+	}
+	return findings
+}
+
+// abandonedFindings reports releases of charts that stopped publishing versions
+func abandonedFindings(_ context.Context, rc ReleaseContext) []Finding {
+	if !hasFlag(rc.Result, flagAbandoned) {
+		return nil
+	}
+	if rc.Result.LatestReleaseDate != nil {
+		return []Finding{rc.Finding(findingWarning, "chart %s has not published a new version since %s", rc.Result.ChartName, rc.Result.LatestReleaseDate.Format("2006-01-02"))}
+	}
+	return []Finding{rc.Finding(findingWarning, "chart %s has not published a new version for a long time", rc.Result.ChartName)}
+}
+
+// findingsError returns the error failing the run because of findings at
+// least as severe as failOn, or nil if there are none
+func findingsError(findings []Finding, failOn string) error {
+	if failOn == "" {
+		return nil
+	}
+	count := 0
+	for _, f := range findings {
+		if findingRank(f.Severity) <= findingRank(failOn) {
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return &exitError{code: exitCodeFindings, err: fmt.Errorf("%d finding(s) of severity %s or higher", count, failOn)}
+}
+
+// newCheckCmd creates the check subcommand
+func newCheckCmd() *cobra.Command {
+	var (
+		output   string
+		selected []string
+		failOn   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "run health checks against the installed releases and list their findings",
+		Long: "Run analyzers against the installed releases and list their findings.\n\nAnalyzers:\n" +
+			analyzersHelp(),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if failOn != "" && !containsString(findingSeverities, failOn) {
+				return fmt.Errorf("invalid --fail-on %q, expected one of %s", failOn, strings.Join(findingSeverities, ", "))
+			}
+			checks, err := selectAnalyzers(selected)
+			if err != nil {
+				return err
+			}
+			if abandonedAfterMonths() == 0 {
+				abandonedAfter = defaultCheckAbandonedMonths
+			}
+
+			report, err := scan(cmd.Context())
+			if err != nil {
+				return err
+			}
+			logWarnings(report.warnings)
+
+			findings := runAnalyzers(cmd.Context(), checks, report)
+			if err := printFindings(os.Stdout, output, findings); err != nil {
+				return err
+			}
+			if err := findingsError(findings, failOn); err != nil {
+				// Findings are a result, not a usage error
+				cmd.SilenceUsage = true
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputFormatTable, "output format. Accepted formats: table, json")
+	cmd.Flags().StringSliceVar(&selected, "analyzers", nil, "run only these analyzers (comma-separated). Defaults to all: "+strings.Join(analyzerNames(), ", "))
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "exit with code 4 when findings of this severity or higher are found: error, warning or info")
+
+	return cmd
+}
+
+// analyzersHelp lists the registered analyzers with their description
+func analyzersHelp() string {
+	var b strings.Builder
+	for _, a := range registeredAnalyzers {
+		description := ""
+		if describer, ok := a.(analyzerDescriber); ok {
+			description = describer.Description()
+		}
+		fmt.Fprintf(&b, "  %-17s %s\n", a.Name(), description)
+	}
+	return b.String()
+}
+
+// printFindings writes the findings in the requested format
+func printFindings(w io.Writer, output string, findings []Finding) error {
+	switch output {
+	case outputFormatJSON:
+		if findings == nil {
+			findings = []Finding{}
+		}
+		outputBytes, err := json.MarshalIndent(findings, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatTable:
+		if len(findings) == 0 {
+			fmt.Fprintln(w, "No findings. All checks passed!")
+			return nil
+		}
+		table := uitable.New()
+		table.MaxColWidth = 80
+		table.Wrap = true
+		table.AddRow("SEVERITY", "ANALYZER", "NAMESPACE", "RELEASE", "MESSAGE")
+		for _, f := range findings {
+			name := f.ReleaseName
+			if f.Cluster != "" {
+				name = f.Cluster + "/" + name
+			}
+			table.AddRow(f.Severity, f.Analyzer, f.Namespace, name, f.Message)
+		}
+		fmt.Fprintln(w, table)
+	default:
+		return fmt.Errorf("invalid formatter: %s", output)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the analyzers report a unified list of findings, the most severe first
+func TestRunAnalyzers(t *testing.T) {
+	web := newTestRelease("web", "prod", "nginx", "1.0.0")
+	web.Manifest = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: web
+`
+	legacy := newTestRelease("legacy", "prod", "legacy", "0.1.0")
+	legacy.Chart.Metadata.Deprecated = true
+
+	report := scanReport{
+		results: []ChartVersionInfo{
+			{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "1.1.0", UpdateSeverity: "MINOR", Status: statusOutdated},
+			{ReleaseName: "legacy", Namespace: "prod", ChartName: "legacy", InstalledVersion: "0.1.0", LatestVersion: "0.1.0", Status: statusUptodate,
+				Flags: []string{flagAbandoned, flagUnapprovedRepo}, RepoName: "random"},
+		},
+		installed: map[string]*helmRelease{"prod/web": web, "prod/legacy": legacy},
+	}
+
+	all, err := selectAnalyzers(nil)
+	require.NoError(t, err)
+	findings := runAnalyzers(context.Background(), all, report)
+	require.Len(t, findings, 5)
+
+	assert.Equal(t, Finding{
+		Analyzer:    "deprecated-api",
+		Severity:    findingError,
+		Namespace:   "prod",
+		ReleaseName: "web",
+		ChartName:   "nginx",
+		Message:     "Ingress web uses networking.k8s.io/v1beta1, removed in Kubernetes 1.22, use networking.k8s.io/v1",
+	}, findings[0])
+	assert.Equal(t, "policy", findings[1].Analyzer)
+	assert.Equal(t, findingError, findings[1].Severity)
+	assert.Equal(t, "deprecated-chart", findings[2].Analyzer)
+	assert.Equal(t, "abandoned", findings[3].Analyzer)
+	assert.Equal(t, "version-drift", findings[4].Analyzer)
+	assert.Equal(t, findingInfo, findings[4].Severity)
+	assert.Equal(t, "nginx 1.0.0 can be updated to 1.1.0 (MINOR update)", findings[4].Message)
+
+	drift, err := selectAnalyzers([]string{"version-drift"})
+	require.NoError(t, err)
+	assert.Len(t, runAnalyzers(context.Background(), drift, report), 1)
+
+	_, err = selectAnalyzers([]string{"spelling"})
+	assert.ErrorContains(t, err, "expected one of version-drift")
+
+	assert.NoError(t, findingsError(findings, ""))
+	assert.Equal(t, exitCodeFindings, exitCode(findingsError(findings, findingError)))
+	assert.NoError(t, findingsError(findings[4:], findingWarning))
+
+	var out bytes.Buffer
+	require.NoError(t, printFindings(&out, outputFormatTable, nil))
+	assert.Equal(t, "No findings. All checks passed!\n", out.String())
+	out.Reset()
+	require.NoError(t, printFindings(&out, outputFormatTable, findings))
+	assert.Contains(t, out.String(), "SEVERITY")
+	assert.Error(t, printFindings(&out, "xml", findings))
+}
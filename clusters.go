@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// clusterConfig describes a cluster of the fleet scanned in a single run
+type clusterConfig struct {
+	Name         string             `yaml:"name"`
+	KubeConfig   string             `yaml:"kubeconfig"`
+	KubeContext  string             `yaml:"context"`
+	Labels       map[string]string  `yaml:"labels"`
+	Enabled      *bool              `yaml:"enabled"`
+	Integrations integrationsConfig `yaml:"integrations"`
+}
+
+// integrationsConfig enables or disables integrations for a single cluster.
+// Integrations that are not set keep the value of the command line flags.
+type integrationsConfig struct {
+	Owners      *bool `yaml:"owners"`
+	Events      *bool `yaml:"events"`
+	Workloads   *bool `yaml:"workloads"`
+	History     *bool `yaml:"history"`
+	ArtifactHub *bool `yaml:"artifactHub"`
+	ProbeRepos  *bool `yaml:"probeRepos"`
+	GitSources  *bool `yaml:"gitSources"`
+}
+
+// scanOptions are the integrations enabled for the scan of a cluster
+type scanOptions struct {
+	ownerAnnotation    string
+	emitEvents         bool
+	correlateWorkloads bool
+	checkHistory       bool
+	artifactHub        bool
+	probeRepos         bool
+	gitSources         bool
+}
+
+// globalScanOptions returns the integrations enabled by the flags and the config file
+func globalScanOptions() scanOptions {
+	annotation := ownerAnnotation
+	if annotation == "" {
+		annotation = cfg.OwnerAnnotation
+	}
+	return scanOptions{
+		ownerAnnotation:    annotation,
+		emitEvents:         emitEvents,
+		correlateWorkloads: correlateWorkloads,
+		checkHistory:       checkHistory,
+		artifactHub:        artifactHubEnabled(),
+		probeRepos:         probeRepos,
+		gitSources:         gitSources,
+	}
+}
+
+// apply overrides the options with the integrations set for a cluster
+func (c integrationsConfig) apply(opts scanOptions) scanOptions {
+	override := func(value *bool, target *bool) {
+		if value != nil {
+			*target = *value
+		}
+	}
+	override(c.Events, &opts.emitEvents)
+	override(c.Workloads, &opts.correlateWorkloads)
+	override(c.History, &opts.checkHistory)
+	override(c.ArtifactHub, &opts.artifactHub)
+	override(c.ProbeRepos, &opts.probeRepos)
+	override(c.GitSources, &opts.gitSources)
+	if c.Owners != nil && !*c.Owners {
+		opts.ownerAnnotation = ""
+	}
+	return opts
+}
+
+// selectedClusters returns the clusters to scan: the kubeconfig contexts
+// selected with --contexts or --all-contexts, or else the clusters of the
+// config file
+func selectedClusters() ([]clusterConfig, error) {
+	if len(kubeContexts) > 0 && allContexts {
+		return nil, fmt.Errorf("--contexts and --all-contexts cannot be used together")
+	}
+
+	names := kubeContexts
+	if allContexts {
+		rawConfig, err := newSettings().RESTClientGetter().ToRawKubeConfigLoader().RawConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+		names = make([]string, 0, len(rawConfig.Contexts))
+		for name := range rawConfig.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no contexts found in kubeconfig")
+		}
+	}
+	if len(names) == 0 {
+		return cfg.Clusters, nil
+	}
+
+	clusters := make([]clusterConfig, 0, len(names))
+	for _, name := range names {
+		clusters = append(clusters, clusterConfig{Name: name, KubeContext: name})
+	}
+	return clusters, nil
+}
+
+// scanTarget is a cluster to scan together with its client settings
+type scanTarget struct {
+	cluster  clusterConfig
+	settings *helmSettings
+	options  scanOptions
+}
+
+// scanTargets returns the clusters to scan. Without a clusters section the
+// current Kubernetes context is the only target.
+func scanTargets(clusters []clusterConfig) ([]scanTarget, error) {
+	if len(clusters) == 0 {
+		return []scanTarget{{settings: newSettings(), options: globalScanOptions()}}, nil
+	}
+
+	seen := make(map[string]bool, len(clusters))
+	var targets []scanTarget
+	for _, cluster := range clusters {
+		if cluster.Name == "" {
+			return nil, fmt.Errorf("cluster in config has no name")
+		}
+		if seen[cluster.Name] {
+			return nil, fmt.Errorf("cluster %q is defined more than once in config", cluster.Name)
+		}
+		seen[cluster.Name] = true
+
+		if cluster.Enabled != nil && !*cluster.Enabled {
+			continue
+		}
+
+		settings := newSettings()
+		if cluster.KubeConfig != "" {
+			settings.KubeConfig = cluster.KubeConfig
+		}
+		if cluster.KubeContext != "" {
+			settings.KubeContext = cluster.KubeContext
+		}
+
+		targets = append(targets, scanTarget{
+			cluster:  cluster,
+			settings: settings,
+			options:  cluster.Integrations.apply(globalScanOptions()),
+		})
+	}
+
+	return targets, nil
+}
+
+// scanInputs holds the data shared by the scans of all clusters
+type scanInputs struct {
+	repositories []repoIndex
+	repoFileData *helmRepoFile
+	chartRepoMap map[string]string
+	rules        []versionRule
+	advisories   []chartAdvisory
+	artifactHub  *artifactHubClient
+	resolver     *repoResolver
+}
+
+// clusterScan is the scan of a single target
+type clusterScan struct {
+	target   scanTarget
+	clients  []*helmConfiguration
+	releases []*helmRelease
+}
+
+// newClusterScan connects to the target and lists its releases
+func newClusterScan(target scanTarget) (*clusterScan, error) {
+	clients, err := newClients(target.settings)
+	if err != nil {
+		return nil, clusterError(target.cluster, err)
+	}
+
+	releases, err := fetchReleases(clients)
+	if err != nil {
+		return nil, clusterError(target.cluster, err)
+	}
+
+	return &clusterScan{target: target, clients: clients, releases: releases}, nil
+}
+
+// clusterError adds the cluster name to an error of a fleet scan
+func clusterError(cluster clusterConfig, err error) error {
+	if cluster.Name == "" {
+		return err
+	}
+	return fmt.Errorf("cluster %s: %w", cluster.Name, err)
+}
+
+// run checks the releases of the cluster against the repositories and
+// applies the integrations enabled for the cluster.
+func (s *clusterScan) run(ctx context.Context, inputs scanInputs, warnings *[]warning) ([]ChartVersionInfo, error) {
+	var clusterWarnings []warning
+	defer func() {
+		for _, w := range clusterWarnings {
+			if s.target.cluster.Name != "" {
+				w.Message = fmt.Sprintf("[%s] %s", s.target.cluster.Name, w.Message)
+			}
+			*warnings = append(*warnings, w)
+		}
+	}()
+
+	opts := s.target.options
+
+	result := processReleases(
+		s.releases,
+		inputs.repositories,
+		inputs.repoFileData,
+		inputs.chartRepoMap,
+		inputs.rules,
+		inputs.resolver,
+		&clusterWarnings,
+	)
+
+	addReleaseInfo(s.releases, result)
+	addChartMetadata(s.releases, result)
+
+	if minRepoConfidence != "" {
+		dropUnconfidentRepos(minRepoConfidence, result, &clusterWarnings)
+	}
+
+	// Label the results first so routing and priority rules can match on the cluster
+	for i := range result {
+		result[i].Cluster = s.target.cluster.Name
+		result[i].ClusterLabels = s.target.cluster.Labels
+	}
+
+	if trivialUpdates {
+		checkTrivialUpdates(ctx, inputs.repositories, inputs.repoFileData, s.releases, result, &clusterWarnings)
+	}
+
+	checkAdvisories(inputs.advisories, result)
+
+	checkRepositoryPolicy(cfg.RepositoryPolicy, repositoryURLs(inputs.repoFileData), result)
+
+	if opts.checkHistory {
+		counts, err := countRevisions(s.clients)
+		if err != nil {
+			return nil, clusterError(s.target.cluster, err)
+		}
+		checkRevisionHistory(counts, maxRevisions, result)
+	}
+
+	if days := maxReleaseAgeDays(); days > 0 {
+		checkReleaseAge(s.releases, days, time.Now(), result)
+	}
+
+	checkAutoManaged(s.releases, result)
+
+	if opts.gitSources {
+		client, err := newKubeDynamicClient(s.target.settings)
+		if err != nil {
+			return nil, clusterError(s.target.cluster, err)
+		}
+		addGitSources(ctx, client, s.releases, result, &clusterWarnings)
+	}
+
+	if valuesSummary {
+		addValuesInfo(s.releases, result)
+	}
+
+	if months := abandonedAfterMonths(); months > 0 {
+		checkAbandoned(inputs.repositories, months, time.Now(), result)
+	}
+
+	if opts.probeRepos {
+		probeWellKnownRepositories(ctx, wellKnownRepositories(), inputs.repoFileData, result, &clusterWarnings)
+	}
+
+	if opts.artifactHub {
+		addArtifactHubInfo(ctx, inputs.artifactHub, repositoryURLs(inputs.repoFileData), result, &clusterWarnings)
+	}
+
+	if collapseCanary {
+		result = collapseCanaries(result)
+	}
+
+	if err := enrichResults(ctx, s.target, result, &clusterWarnings); err != nil {
+		return nil, clusterError(s.target.cluster, err)
+	}
+
+	if severityHook != "" {
+		runSeverityHook(ctx, severityHook, result, &clusterWarnings)
+	}
+
+	if err := assignPriorities(cfg.Priorities, releaseLabelsByKey(s.releases), result); err != nil {
+		return nil, err
+	}
+
+	annotateDependencies(releaseDependencies(cfg.Dependencies, s.releases), result, &clusterWarnings)
+
+	return result, nil
+}
+
+// reportKey identifies a release across the clusters of a fleet
+func reportKey(versionInfo ChartVersionInfo) string {
+	if versionInfo.Cluster == "" {
+		return findingKey(versionInfo)
+	}
+	return versionInfo.Cluster + "/" + findingKey(versionInfo)
+}
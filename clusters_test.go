@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+// Test that clusters from the config become scan targets with their own client settings and integrations
+func TestScanTargets(t *testing.T) {
+	emitEvents, correlateWorkloads = true, false
+	defer func() { emitEvents, correlateWorkloads = false, false }()
+
+	var config Config
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`
+clusters:
+  - name: prod-eu
+    kubeconfig: /etc/kube/prod-eu
+    context: admin@prod-eu
+    labels:
+      env: prod
+    integrations:
+      events: false
+      workloads: true
+  - name: staging
+    enabled: false
+  - name: dev
+`), &config))
+
+	targets, err := scanTargets(config.Clusters)
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+
+	assert.Equal(t, "prod-eu", targets[0].cluster.Name)
+	assert.Equal(t, "/etc/kube/prod-eu", targets[0].settings.KubeConfig)
+	assert.Equal(t, "admin@prod-eu", targets[0].settings.KubeContext)
+	assert.False(t, targets[0].options.emitEvents)
+	assert.True(t, targets[0].options.correlateWorkloads)
+
+	assert.Equal(t, "dev", targets[1].cluster.Name)
+	assert.True(t, targets[1].options.emitEvents)
+	assert.False(t, targets[1].options.correlateWorkloads)
+
+	_, err = scanTargets([]clusterConfig{{Name: "dev"}, {Name: "dev"}})
+	assert.Error(t, err)
+
+	_, err = scanTargets([]clusterConfig{{KubeContext: "dev"}})
+	assert.Error(t, err)
+
+	targets, err = scanTargets(nil)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Empty(t, targets[0].cluster.Name)
+}
+
+// Test that findings of a fleet scan can be routed on the cluster and its labels
+func TestFindingLabelsCluster(t *testing.T) {
+	finding := ChartVersionInfo{
+		ReleaseName:   "web",
+		Namespace:     "apps",
+		Cluster:       "prod-eu",
+		ClusterLabels: map[string]string{"env": "prod", "release": "spoofed"},
+	}
+
+	labels := findingLabels(finding)
+	assert.Equal(t, "prod-eu", labels["cluster"])
+	assert.Equal(t, "prod", labels["env"])
+	assert.Equal(t, "web", labels["release"])
+
+	assert.Equal(t, "prod-eu/apps/web", reportKey(finding))
+	finding.Cluster = ""
+	assert.Equal(t, "apps/web", reportKey(finding))
+}
+
+// Test that --contexts and --all-contexts select the clusters to scan
+func TestSelectedClusters(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+  - name: eu
+    cluster: {server: "https://eu.example.com"}
+  - name: us
+    cluster: {server: "https://us.example.com"}
+users:
+  - name: admin
+    user: {token: secret}
+contexts:
+  - name: prod-us
+    context: {cluster: us, user: admin}
+  - name: prod-eu
+    context: {cluster: eu, user: admin}
+current-context: prod-eu
+`), 0o600))
+	kubeConfig = kubeconfig
+	cfg.Clusters = []clusterConfig{{Name: "from-config"}}
+	defer func() {
+		kubeConfig, kubeContexts, allContexts = "", nil, false
+		cfg.Clusters = nil
+	}()
+
+	clusters, err := selectedClusters()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Clusters, clusters)
+
+	kubeContexts = []string{"prod-us"}
+	clusters, err = selectedClusters()
+	require.NoError(t, err)
+	assert.Equal(t, []clusterConfig{{Name: "prod-us", KubeContext: "prod-us"}}, clusters)
+
+	allContexts = true
+	_, err = selectedClusters()
+	assert.Error(t, err)
+
+	kubeContexts = nil
+	clusters, err = selectedClusters()
+	require.NoError(t, err)
+	require.Len(t, clusters, 2)
+	assert.Equal(t, "prod-eu", clusters[0].Name)
+	assert.Equal(t, "prod-us", clusters[1].KubeContext)
+
+	targets, err := scanTargets(clusters)
+	require.NoError(t, err)
+	assert.Equal(t, kubeconfig, targets[0].settings.KubeConfig)
+	assert.Equal(t, "prod-eu", targets[0].settings.KubeContext)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// outputFormatCodeQuality renders the outdated releases as a GitLab code
+// quality report
+const outputFormatCodeQuality = "codequality"
+
+// codeQualityIssue is an issue of a GitLab code quality report
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+// codeQualityLocation is the file an issue is reported for
+type codeQualityLocation struct {
+	Path  string `json:"path"`
+	Lines struct {
+		Begin int `json:"begin"`
+	} `json:"lines"`
+}
+
+// codeQualitySeverities maps update severities to code quality severities.
+// These are the severities GitLab accepts, so the vocabulary doesn't apply.
+var codeQualitySeverities = map[string]string{
+	"MAJOR":   "major",
+	"MINOR":   "minor",
+	"PATCH":   "info",
+	"TRIVIAL": "info",
+}
+
+// codeQualityIssues converts the outdated and vulnerable releases into code
+// quality issues. Results of scan-dir are reported for the file declaring
+// them, others for their namespace/name. The fingerprint changes with the
+// latest version, so a new version shows up as a new issue in merge requests.
+func codeQualityIssues(result []ChartVersionInfo) []codeQualityIssue {
+	issues := []codeQualityIssue{}
+	for _, versionInfo := range result {
+		var issue codeQualityIssue
+		switch versionInfo.Status {
+		case statusOutdated:
+			issue.CheckName = "helm-whatup-outdated"
+			issue.Severity = codeQualitySeverities[versionInfo.UpdateSeverity]
+			if issue.Severity == "" {
+				issue.Severity = "minor"
+			}
+			issue.Description = fmt.Sprintf("Release %s (%s) can be updated from %s to %s",
+				versionInfo.ReleaseName, versionInfo.ChartName, versionInfo.InstalledVersion, versionInfo.LatestVersion)
+		case statusVulnerable:
+			issue.CheckName = "helm-whatup-vulnerable"
+			issue.Severity = "critical"
+			issue.Description = fmt.Sprintf("Release %s (%s) version %s is affected by %s",
+				versionInfo.ReleaseName, versionInfo.ChartName, versionInfo.InstalledVersion, advisoriesColumn(versionInfo))
+		default:
+			continue
+		}
+
+		issue.Location.Path = versionInfo.Source
+		if issue.Location.Path == "" {
+			issue.Location.Path = reportKey(versionInfo)
+		}
+		issue.Location.Lines.Begin = 1
+
+		sum := sha256.Sum256([]byte(issue.CheckName + "\x00" + reportKey(versionInfo) + "\x00" + versionInfo.ChartName + "\x00" + versionInfo.LatestVersion))
+		issue.Fingerprint = hex.EncodeToString(sum[:])
+
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// formatCodeQuality writes the GitLab code quality report of the results
+func formatCodeQuality(w io.Writer, result []ChartVersionInfo) error {
+	outputBytes, err := json.MarshalIndent(codeQualityIssues(result), "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal code quality report: %w", err)
+	}
+	fmt.Fprintln(w, string(outputBytes))
+	return nil
+}
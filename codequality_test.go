@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that outdated and vulnerable releases become code quality issues
+func TestFormatCodeQuality(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "2.0.0", UpdateSeverity: "MAJOR", Status: statusOutdated},
+		{ReleaseName: "db", Namespace: "prod", ChartName: "postgresql", Status: statusUptodate},
+		{ReleaseName: "cache", ChartName: "redis", InstalledVersion: "18.0.0", LatestVersion: "18.0.1", UpdateSeverity: "PATCH", Status: statusOutdated, Source: "deploy/helmfile.yaml"},
+		{ReleaseName: "api", Namespace: "prod", ChartName: "api", InstalledVersion: "1.0.0", Status: statusVulnerable,
+			Advisories: []Advisory{{ID: "CVE-2024-1234"}}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, formatCodeQuality(&out, result))
+
+	var issues []codeQualityIssue
+	require.NoError(t, json.Unmarshal(out.Bytes(), &issues))
+	require.Len(t, issues, 3)
+
+	assert.Equal(t, "helm-whatup-outdated", issues[0].CheckName)
+	assert.Equal(t, "major", issues[0].Severity)
+	assert.Equal(t, "Release web (nginx) can be updated from 1.0.0 to 2.0.0", issues[0].Description)
+	assert.Equal(t, reportKey(result[0]), issues[0].Location.Path)
+	assert.Equal(t, 1, issues[0].Location.Lines.Begin)
+
+	assert.Equal(t, "info", issues[1].Severity)
+	assert.Equal(t, "deploy/helmfile.yaml", issues[1].Location.Path)
+
+	assert.Equal(t, "helm-whatup-vulnerable", issues[2].CheckName)
+	assert.Equal(t, "critical", issues[2].Severity)
+
+	assert.NotEqual(t, issues[0].Fingerprint, issues[1].Fingerprint)
+	assert.Equal(t, issues[0].Fingerprint, codeQualityIssues(result[:1])[0].Fingerprint)
+
+	out.Reset()
+	require.NoError(t, formatCodeQuality(&out, nil))
+	assert.Equal(t, "[]\n", out.String())
+}
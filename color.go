@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ANSI escape sequences of the colors used in the table and plain output
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+// statusColors are the colors of the release statuses
+var statusColors = map[string]string{
+	statusOutdated:   colorRed,
+	statusVulnerable: colorBoldRed,
+	statusUptodate:   colorGreen,
+	statusUnknown:    colorYellow,
+}
+
+// severityColors are the colors of the update severities, i.e. of the delta
+// between the installed and the latest version
+var severityColors = map[string]string{
+	"MAJOR": colorRed,
+	"MINOR": colorYellow,
+	"PATCH": colorGreen,
+}
+
+// colorEnabled reports whether output is colored: only when stdout is a
+// terminal, unless --no-color or the NO_COLOR environment variable
+// (https://no-color.org) disable it
+func colorEnabled() bool {
+	if noColor || outputFile != "" || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// colorize wraps s in the given color, leaving it unchanged when there is no
+// color or nothing to color
+func colorize(s, color string) string {
+	if s == "" || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// tableColors returns the colors of the cells of a row: the latest version
+// is colored by status and the severity by how far behind the release is
+func tableColors(columns []tableColumn, versionInfo ChartVersionInfo) []string {
+	colors := make([]string, len(columns))
+	for i, column := range columns {
+		switch column.header {
+		case "LATEST VERSION":
+			colors[i] = statusColors[versionInfo.Status]
+		case "SEVERITY":
+			colors[i] = severityColors[versionInfo.UpdateSeverity]
+		}
+	}
+	return colors
+}
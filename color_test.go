@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that colors are disabled by --no-color, NO_COLOR and when stdout is not a terminal
+func TestColorEnabled(t *testing.T) {
+	// Test output is never written to a terminal
+	assert.False(t, colorEnabled())
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, colorEnabled())
+
+	defer func(previous bool) { noColor = previous }(noColor)
+	noColor = true
+	assert.False(t, colorEnabled())
+}
+
+// Test that the latest version is colored by status and the severity by update severity
+func TestTableColors(t *testing.T) {
+	columns := []tableColumn{{header: "NAME"}, {header: "LATEST VERSION"}, {header: "SEVERITY"}}
+	assert.Equal(t, []string{"", colorRed, colorYellow}, tableColors(columns, ChartVersionInfo{Status: statusOutdated, UpdateSeverity: "MINOR"}))
+	assert.Equal(t, []string{"", colorBoldRed, ""}, tableColors(columns, ChartVersionInfo{Status: statusVulnerable}))
+	assert.Equal(t, "", colorize("", colorRed))
+
+	// Escape sequences don't count towards the width of a column
+	var out bytes.Buffer
+	header := tableHeader(columns)
+	rows := [][]string{{"web", "2.0.0", "MAJOR"}}
+	renderTable(&out, columns, header, rows, tableOptions{colors: [][]string{tableColors(columns, ChartVersionInfo{Status: statusOutdated, UpdateSeverity: "MAJOR"})}})
+	assert.Equal(t, ""+
+		"NAME  LATEST VERSION  SEVERITY\n"+
+		"web   \x1b[31m2.0.0\x1b[0m           \x1b[31mMAJOR\x1b[0m\n", out.String())
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"gopkg.in/yaml.v2"
+)
+
+// compatLegacy reproduces the output and exit codes of the original plugin
+const compatLegacy = "legacy"
+
+// legacyCompat reports whether --compat legacy was requested
+func legacyCompat() bool {
+	return compatMode == compatLegacy
+}
+
+// validateCompat checks the value of --compat
+func validateCompat() error {
+	if compatMode != "" && compatMode != compatLegacy {
+		return fmt.Errorf("invalid compatibility mode: %s. Accepted modes: legacy", compatMode)
+	}
+	return nil
+}
+
+// legacyLatestIndex returns the index of the latest version like the original
+// plugin did: the first allowed entry, relying on Helm sorting indexes newest
+// first. Its pre-release check never matched, so pre-releases are included.
+func legacyLatestIndex(entries helmChartVersions, allowed func(version string, created time.Time) bool) int {
+	for i, entry := range entries {
+		if allowed(entry.Version, entry.Created) {
+			return i
+		}
+	}
+	return -1
+}
+
+// legacyChartVersionInfo holds the fields of a result reported by the original plugin
+type legacyChartVersionInfo struct {
+	ReleaseName      string `json:"releaseName"`
+	Namespace        string `json:"namespace"`
+	ChartName        string `json:"chartName"`
+	InstalledVersion string `json:"installedVersion"`
+	LatestVersion    string `json:"latestVersion"`
+	RepoName         string `json:"repoName"`
+	Status           string `json:"status"`
+}
+
+// legacyResults converts the results to the original format. Releases the
+// original plugin didn't report, such as unresolved or ignored ones, are dropped.
+func legacyResults(result []ChartVersionInfo) []legacyChartVersionInfo {
+	legacy := []legacyChartVersionInfo{}
+	for _, versionInfo := range result {
+		if versionInfo.Status != statusOutdated && versionInfo.Status != statusUptodate {
+			continue
+		}
+		legacy = append(legacy, legacyChartVersionInfo{
+			ReleaseName:      versionInfo.ReleaseName,
+			Namespace:        versionInfo.Namespace,
+			ChartName:        versionInfo.ChartName,
+			InstalledVersion: versionInfo.InstalledVersion,
+			LatestVersion:    versionInfo.LatestVersion,
+			RepoName:         versionInfo.RepoName,
+			Status:           versionInfo.Status,
+		})
+	}
+	return legacy
+}
+
+// formatLegacyResults prints the results exactly like the original plugin did
+func formatLegacyResults(w io.Writer, report scanReport) error {
+	result := legacyResults(report.results)
+
+	if outputFormat == outputFormatPlain && len(report.warnings) > 0 {
+		fmt.Fprintln(w)
+		for _, warning := range report.warnings {
+			fmt.Fprintf(w, "WARNING: %s\n", warning.Message)
+		}
+	}
+
+	hasOutdated := false
+	for _, versionInfo := range result {
+		if versionInfo.Status == statusOutdated {
+			hasOutdated = true
+			break
+		}
+	}
+
+	if !hasOutdated && outputFormat == outputFormatPlain {
+		fmt.Fprintln(w, "No charts need updates. All up to date!")
+		return nil
+	}
+
+	switch outputFormat {
+	case outputFormatPlain:
+		fmt.Fprintln(w, "\nWARNING: Charts marked as deprecated will not be shown in the results.")
+		fmt.Fprintln(w)
+		for _, versionInfo := range result {
+			if versionInfo.LatestVersion != versionInfo.InstalledVersion {
+				fmt.Fprintf(w, "There is an update available for release %s (%s)!\n"+
+					"Installed version: %s\n"+
+					"Available version: %s\n\n",
+					versionInfo.ReleaseName,
+					versionInfo.ChartName,
+					versionInfo.InstalledVersion,
+					versionInfo.LatestVersion)
+			} else {
+				fmt.Fprintf(w, "Release %s (%s) is up to date.\n", versionInfo.ReleaseName, versionInfo.ChartName)
+			}
+		}
+		fmt.Fprintln(w, "Done.")
+	case outputFormatShort:
+		for _, versionInfo := range result {
+			if versionInfo.LatestVersion != versionInfo.InstalledVersion {
+				fmt.Fprintf(w, "%s (%s): %s --> %s\n", versionInfo.ReleaseName, versionInfo.ChartName, versionInfo.InstalledVersion, versionInfo.LatestVersion)
+			}
+		}
+	case outputFormatJSON:
+		outputBytes, err := json.MarshalIndent(result, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatYML, outputFormatYAML:
+		outputBytes, err := yaml.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatTable:
+		fmt.Fprintln(w, "\nWARNING: Charts marked as deprecated will not be shown in the results.")
+		fmt.Fprintln(w)
+
+		table := uitable.New()
+		table.MaxColWidth = 50
+		table.Wrap = true
+		table.Separator = "  "
+
+		table.AddRow("NAME", "NAMESPACE", "INSTALLED VERSION", "LATEST VERSION", "CHART", "REPOSITORY")
+		for _, versionInfo := range result {
+			if versionInfo.LatestVersion != versionInfo.InstalledVersion {
+				table.AddRow(
+					versionInfo.ReleaseName,
+					versionInfo.Namespace,
+					versionInfo.InstalledVersion,
+					versionInfo.LatestVersion,
+					versionInfo.ChartName,
+					versionInfo.RepoName,
+				)
+			}
+		}
+		fmt.Fprintln(w, table)
+	default:
+		return fmt.Errorf("invalid formatter: %s", outputFormat)
+	}
+
+	return nil
+}
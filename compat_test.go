@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that legacy mode prints the bare list of results the original plugin printed
+func TestFormatLegacyResults(t *testing.T) {
+	defer func() { outputFormat = outputFormatPlain }()
+
+	report := scanReport{
+		results: []ChartVersionInfo{
+			{ReleaseName: "web", Namespace: "apps", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "1.1.0", RepoName: "stable", Status: statusOutdated, Flags: []string{flagStaleRelease}},
+			{ReleaseName: "db", Namespace: "apps", ChartName: "postgresql", InstalledVersion: "2.0.0", LatestVersion: "2.0.0", RepoName: "stable", Status: statusUptodate},
+			{ReleaseName: "cache", Namespace: "apps", ChartName: "redis", InstalledVersion: "3.0.0", Status: statusUnknown},
+		},
+		warnings: []warning{{Code: warnRepoUnresolved, Name: "RepoUnresolved", Message: "chart redis not found"}},
+	}
+
+	outputFormat = outputFormatJSON
+	var out bytes.Buffer
+	require.NoError(t, formatLegacyResults(&out, report))
+	assert.True(t, bytes.HasPrefix(out.Bytes(), []byte("[")))
+	assert.Contains(t, out.String(), `"releaseName": "web"`)
+	assert.NotContains(t, out.String(), "cache")
+	assert.NotContains(t, out.String(), "flags")
+
+	outputFormat = outputFormatPlain
+	out.Reset()
+	require.NoError(t, formatLegacyResults(&out, report))
+	assert.Contains(t, out.String(), "WARNING: chart redis not found\n")
+	assert.NotContains(t, out.String(), "W001")
+	assert.Contains(t, out.String(), "There is an update available for release web (nginx)!")
+	assert.Contains(t, out.String(), "Release db (postgresql) is up to date.")
+	assert.NotContains(t, out.String(), "HINT")
+
+	outputFormat = outputFormatShort
+	out.Reset()
+	require.NoError(t, formatLegacyResults(&out, report))
+	assert.Equal(t, "web (nginx): 1.0.0 --> 1.1.0\n", out.String())
+}
+
+func TestValidateCompat(t *testing.T) {
+	defer func() { compatMode = "" }()
+
+	assert.NoError(t, validateCompat())
+	compatMode = compatLegacy
+	assert.NoError(t, validateCompat())
+	assert.True(t, legacyCompat())
+	compatMode = "v1"
+	assert.Error(t, validateCompat())
+}
+
+// Test that legacy mode scans like the original plugin did
+func TestLegacyCompatScan(t *testing.T) {
+	compatMode = compatLegacy
+	defer func() { compatMode = "" }()
+
+	t.Setenv("HELM_NAMESPACE", "team-a")
+	assert.Empty(t, selectedNamespaces())
+
+	releases := []*release.Release{
+		newTestRelease("ingress", "default", "ingress-nginx", "4.10.0"),
+		newTestRelease("api", "default", "api", "2.0.0"),
+	}
+	repositories := []repoIndex{
+		newTestIndex("ingress-nginx", "4.11.0-rc.1", "4.10.1"),
+		newTestIndex("api", "1.9.0"),
+	}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+	require.Len(t, result, 2)
+	assert.Equal(t, "4.11.0-rc.1", result[0].LatestVersion)
+	assert.Equal(t, statusOutdated, result[0].Status)
+	assert.Equal(t, "1.9.0", result[1].LatestVersion)
+	assert.Equal(t, statusOutdated, result[1].Status)
+
+	t.Setenv("HELM_REPOSITORY_CONFIG", filepath.Join(t.TempDir(), "repositories.yaml"))
+	_, err := fetchIndices()
+	assert.ErrorContains(t, err, "failed to load repository file")
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// completeReleases completes the release names of the current context for
+// arguments such as those of `helm whatup upgrade`, skipping releases that
+// were already given
+func completeReleases(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	releases, err := completionReleases()
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return releaseCompletions(releases, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeReleaseList completes release names for flags taking a
+// comma-separated list of releases, such as --ignore
+func completeReleaseList(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	releases, err := completionReleases()
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveError
+	}
+	given, last := splitCompletionList(toComplete)
+	return prefixCompletions(releaseCompletions(releases, given, last), given), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeNamespaces completes the namespaces of the current context for
+// flags taking a comma-separated list of namespaces
+func completeNamespaces(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	clientset, err := newKubeClientset(newSettings())
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveError
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	list, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		cobra.CompDebugln(fmt.Sprintf("failed to list namespaces: %v", err), true)
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	names := make([]string, 0, len(list.Items))
+	for _, namespace := range list.Items {
+		names = append(names, namespace.Name)
+	}
+	given, last := splitCompletionList(toComplete)
+	return prefixCompletions(namespaceCompletions(names, given, last), given), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionReleases lists the releases of the current context, honoring the
+// namespace flags given so far
+func completionReleases() ([]*helmRelease, error) {
+	clients, err := newClients(newSettings())
+	if err != nil {
+		return nil, err
+	}
+	return fetchReleases(clients)
+}
+
+// releaseCompletions returns the release names starting with toComplete that
+// are not in given, described by their namespace and chart. Releases whose
+// name is used in several namespaces are completed as namespace/name.
+func releaseCompletions(releases []*helmRelease, given []string, toComplete string) []string {
+	namespacesOf := map[string]int{}
+	for _, r := range releases {
+		namespacesOf[r.Name]++
+	}
+
+	var completions []string
+	for _, r := range releases {
+		name := r.Name
+		if namespacesOf[r.Name] > 1 {
+			name = r.Namespace + "/" + r.Name
+		}
+		if !strings.HasPrefix(name, toComplete) || containsString(given, name) {
+			continue
+		}
+		description := r.Namespace
+		if r.Chart != nil && r.Chart.Metadata != nil {
+			description = fmt.Sprintf("%s, %s %s", r.Namespace, r.Chart.Metadata.Name, r.Chart.Metadata.Version)
+		}
+		completions = append(completions, name+"\t"+description)
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+// namespaceCompletions returns the namespaces starting with toComplete that
+// are not in given
+func namespaceCompletions(names, given []string, toComplete string) []string {
+	var completions []string
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) && !containsString(given, name) {
+			completions = append(completions, name)
+		}
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+// splitCompletionList splits the value of a comma-separated flag being
+// completed into the values already given and the one being typed
+func splitCompletionList(toComplete string) ([]string, string) {
+	values := strings.Split(toComplete, ",")
+	return values[:len(values)-1], values[len(values)-1]
+}
+
+// prefixCompletions prepends the values already given to every completion of
+// a comma-separated flag, so the shell keeps them
+func prefixCompletions(completions, given []string) []string {
+	if len(given) == 0 {
+		return completions
+	}
+	prefix := strings.Join(given, ",") + ","
+	for i := range completions {
+		completions[i] = prefix + completions[i]
+	}
+	return completions
+}
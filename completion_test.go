@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Test that release names are completed with their namespace and chart, skipping given ones
+func TestReleaseCompletions(t *testing.T) {
+	releases := []*release.Release{
+		newTestRelease("web", "prod", "nginx", "1.0.0"),
+		newTestRelease("worker", "prod", "worker", "2.0.0"),
+		newTestRelease("db", "prod", "postgresql", "12.0.0"),
+		newTestRelease("db", "staging", "postgresql", "13.0.0"),
+	}
+
+	assert.Equal(t, []string{"web\tprod, nginx 1.0.0", "worker\tprod, worker 2.0.0"}, releaseCompletions(releases, nil, "w"))
+	assert.Equal(t, []string{"worker\tprod, worker 2.0.0"}, releaseCompletions(releases, []string{"web"}, "w"))
+	// Names used in several namespaces are qualified
+	assert.Equal(t, []string{"staging/db\tstaging, postgresql 13.0.0"}, releaseCompletions(releases, nil, "st"))
+}
+
+// Test that comma-separated flag values keep the values already given
+func TestNamespaceCompletionsList(t *testing.T) {
+	names := []string{"kube-system", "prod", "staging", "platform"}
+
+	given, last := splitCompletionList("prod,p")
+	assert.Equal(t, []string{"prod"}, given)
+	assert.Equal(t, "p", last)
+	assert.Equal(t, []string{"prod,platform"}, prefixCompletions(namespaceCompletions(names, given, last), given))
+
+	given, last = splitCompletionList("")
+	assert.Equal(t, []string{"kube-system", "platform", "prod", "staging"}, prefixCompletions(namespaceCompletions(names, given, last), given))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultConfigFileName is the name of the configuration file looked up in the Helm configuration directory
+const defaultConfigFileName = "whatup.yaml"
+
+// Config is the content of the whatup.yaml configuration file
+type Config struct {
+	Notifications   notificationConfig `yaml:"notifications"`
+	OwnerAnnotation string             `yaml:"ownerAnnotation"`
+	Priorities      []priorityRule     `yaml:"priorities"`
+	RenovateConfig  string             `yaml:"renovateConfig"`
+	Dependencies    []dependencyRule   `yaml:"dependencies"`
+	ArtifactHub     artifactHubConfig  `yaml:"artifactHub"`
+
+	WellKnownRepositories []wellKnownRepository `yaml:"wellKnownRepositories"`
+	Clusters              []clusterConfig       `yaml:"clusters"`
+	Vocabulary            vocabularyConfig      `yaml:"vocabulary"`
+	MaxReleaseAgeDays     int                   `yaml:"maxReleaseAgeDays"`
+	AbandonedAfterMonths  int                   `yaml:"abandonedAfterMonths"`
+	PostProcessHook       string                `yaml:"postProcessHook"`
+	RepositoryPolicy      repositoryPolicy      `yaml:"repositoryPolicy"`
+	AdvisoryFeeds         []string              `yaml:"advisoryFeeds"`
+	PinFile               string                `yaml:"pinFile"`
+	Ignore                []string              `yaml:"ignore"`
+	PolicyFile            string                `yaml:"policyFile"`
+	Catalog               string                `yaml:"catalog"`
+	Telemetry             telemetryConfig       `yaml:"telemetry"`
+	Statsd                statsdConfig          `yaml:"statsd"`
+}
+
+// cfg holds the configuration loaded for the current invocation
+var cfg Config
+
+// defaultConfigFile returns the default location of the configuration file
+func defaultConfigFile() string {
+	return helmConfigPath(defaultConfigFileName)
+}
+
+// loadConfig reads the configuration file at path. A missing file is only an
+// error when the path was requested explicitly.
+func loadConfig(path string, explicit bool) (Config, error) {
+	var config Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !explicit {
+			return config, nil
+		}
+		return config, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := config.Vocabulary.validate(); err != nil {
+		return config, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// loadConfigFile is run before every command to populate cfg from the --config flag
+func loadConfigFile(cmd *cobra.Command, _ []string) error {
+	config, err := loadConfig(configFile, cmd.Flags().Changed("config"))
+	if err != nil {
+		return err
+	}
+	cfg = config
+	return nil
+}
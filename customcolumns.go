@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// customColumnsPrefix starts the -o custom-columns=HEADER:.path,... output format
+const customColumnsPrefix = "custom-columns="
+
+// customColumnNone is shown for fields a result doesn't have, like kubectl does
+const customColumnNone = "<none>"
+
+// customColumn is a column of the custom-columns output
+type customColumn struct {
+	header string
+	path   []pathSegment
+}
+
+// pathSegment is a field of a JSON path, optionally followed by an index
+// into the list it holds, e.g. advisories[0]
+type pathSegment struct {
+	field string
+	index int
+}
+
+// isCustomColumns reports whether the output format is custom-columns
+func isCustomColumns(format string) bool {
+	return strings.HasPrefix(format, customColumnsPrefix)
+}
+
+// parseCustomColumns parses a HEADER:.path,... specification. Paths select
+// fields of the JSON output, e.g. .releaseName or .workload.ready.
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	spec = strings.TrimPrefix(spec, customColumnsPrefix)
+	if spec == "" {
+		return nil, fmt.Errorf("custom-columns requires at least one HEADER:.path column")
+	}
+
+	var columns []customColumn
+	for _, part := range strings.Split(spec, ",") {
+		header, expr, ok := strings.Cut(part, ":")
+		if !ok || header == "" || expr == "" {
+			return nil, fmt.Errorf("invalid custom column %q, expected HEADER:.path", part)
+		}
+		path, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid custom column %q: %w", part, err)
+		}
+		columns = append(columns, customColumn{header: header, path: path})
+	}
+	return columns, nil
+}
+
+// parseJSONPath parses a simple JSON path such as .workload.ready or
+// .advisories[0].id. Braces as in kubectl's {.releaseName} are accepted.
+func parseJSONPath(expr string) ([]pathSegment, error) {
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+	if !strings.HasPrefix(expr, ".") {
+		return nil, fmt.Errorf("path %q has to start with a dot", expr)
+	}
+
+	var path []pathSegment
+	for _, field := range strings.Split(strings.TrimPrefix(expr, "."), ".") {
+		segment := pathSegment{field: field, index: -1}
+		if open := strings.Index(field, "["); open >= 0 {
+			if !strings.HasSuffix(field, "]") {
+				return nil, fmt.Errorf("unterminated index in %q", field)
+			}
+			index, err := strconv.Atoi(field[open+1 : len(field)-1])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index in %q", field)
+			}
+			segment = pathSegment{field: field[:open], index: index}
+		}
+		if segment.field == "" {
+			return nil, fmt.Errorf("empty field in path %q", expr)
+		}
+		path = append(path, segment)
+	}
+	return path, nil
+}
+
+// lookupPath returns the value at path in a decoded JSON object
+func lookupPath(value interface{}, path []pathSegment) (interface{}, bool) {
+	for _, segment := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[segment.field]; !ok {
+			return nil, false
+		}
+		if segment.index >= 0 {
+			list, ok := value.([]interface{})
+			if !ok || segment.index >= len(list) {
+				return nil, false
+			}
+			value = list[segment.index]
+		}
+	}
+	return value, true
+}
+
+// formatCustomValue renders a JSON value as a table cell
+func formatCustomValue(value interface{}, found bool) string {
+	if !found || value == nil {
+		return customColumnNone
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return customColumnNone
+		}
+		return string(data)
+	}
+}
+
+// formatCustomColumns writes every result as a row of the columns given by
+// the custom-columns specification
+func formatCustomColumns(w io.Writer, spec string, result []ChartVersionInfo) error {
+	columns, err := parseCustomColumns(spec)
+	if err != nil {
+		return err
+	}
+
+	tableCols := make([]tableColumn, 0, len(columns))
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		tableCols = append(tableCols, tableColumn{header: column.header})
+		header = append(header, column.header)
+	}
+
+	rows := make([][]string, 0, len(result))
+	for _, versionInfo := range cfg.Vocabulary.translate(result) {
+		data, err := json.Marshal(versionInfo)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		var object interface{}
+		if err := json.Unmarshal(data, &object); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+
+		row := make([]string, 0, len(columns))
+		for _, column := range columns {
+			row = append(row, formatCustomValue(lookupPath(object, column.path)))
+		}
+		rows = append(rows, row)
+	}
+
+	renderTable(w, tableCols, header, rows, tableOptions{width: terminalWidth(), rowSeparators: rowSeparators, noHeader: noHeaders})
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that custom columns select fields of the JSON output
+func TestFormatCustomColumns(t *testing.T) {
+	t.Setenv("COLUMNS", "")
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "apps", LatestVersion: "1.1.0", Advisories: []Advisory{{ID: "CVE-1"}}},
+		{ReleaseName: "db", Namespace: "data", LatestVersion: "13.0.0", Revision: 4},
+	}
+
+	var out bytes.Buffer
+	err := formatCustomColumns(&out, "custom-columns=NAME:.releaseName,NS:{.namespace},CVE:.advisories[0].id,REV:.revision", result)
+	require.NoError(t, err)
+	assert.Equal(t, "NAME  NS    CVE     REV\n"+
+		"web   apps  CVE-1   <none>\n"+
+		"db    data  <none>  4\n", out.String())
+}
+
+// Test that invalid custom column specifications are rejected
+func TestParseCustomColumnsInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"custom-columns=",
+		"custom-columns=NAME",
+		"custom-columns=NAME:releaseName",
+		"custom-columns=CVE:.advisories[x].id",
+		"custom-columns=NAME:.a..b",
+	} {
+		_, err := parseCustomColumns(spec)
+		assert.Error(t, err, spec)
+	}
+}
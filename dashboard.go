@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// grafanaDatasource points panels at the Prometheus data source chosen in the
+// datasource variable of the dashboard
+var grafanaDatasource = grafanaRef{Type: "prometheus", UID: "${datasource}"}
+
+// grafanaRef references a Grafana data source
+type grafanaRef struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// grafanaDashboard is the subset of the Grafana dashboard model gen-dashboard uses
+type grafanaDashboard struct {
+	UID           string   `json:"uid"`
+	Title         string   `json:"title"`
+	Tags          []string `json:"tags"`
+	SchemaVersion int      `json:"schemaVersion"`
+	Refresh       string   `json:"refresh"`
+	Time          struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"time"`
+	Templating struct {
+		List []grafanaVariable `json:"list"`
+	} `json:"templating"`
+	Panels []grafanaPanel `json:"panels"`
+}
+
+// grafanaVariable is a template variable of a dashboard
+type grafanaVariable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label"`
+	Type       string      `json:"type"`
+	Query      string      `json:"query"`
+	Datasource *grafanaRef `json:"datasource,omitempty"`
+	Multi      bool        `json:"multi,omitempty"`
+	IncludeAll bool        `json:"includeAll,omitempty"`
+	Refresh    int         `json:"refresh,omitempty"`
+}
+
+// grafanaPanel is a panel of a dashboard
+type grafanaPanel struct {
+	ID          int                    `json:"id"`
+	Type        string                 `json:"type"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description,omitempty"`
+	GridPos     grafanaGridPos         `json:"gridPos"`
+	Datasource  grafanaRef             `json:"datasource"`
+	Targets     []grafanaTarget        `json:"targets"`
+	FieldConfig grafanaFieldConfig     `json:"fieldConfig"`
+	Options     map[string]interface{} `json:"options,omitempty"`
+}
+
+// grafanaGridPos places a panel on the 24 columns wide grid
+type grafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// grafanaTarget is a query of a panel
+type grafanaTarget struct {
+	RefID        string     `json:"refId"`
+	Datasource   grafanaRef `json:"datasource"`
+	Expr         string     `json:"expr"`
+	LegendFormat string     `json:"legendFormat,omitempty"`
+	Instant      bool       `json:"instant,omitempty"`
+	Format       string     `json:"format,omitempty"`
+}
+
+// grafanaFieldConfig sets the unit of the values of a panel
+type grafanaFieldConfig struct {
+	Defaults struct {
+		Unit string `json:"unit,omitempty"`
+	} `json:"defaults"`
+	Overrides []interface{} `json:"overrides"`
+}
+
+// newGrafanaPanel returns a panel querying the given expressions
+func newGrafanaPanel(id int, panelType, title, unit string, pos grafanaGridPos, targets ...grafanaTarget) grafanaPanel {
+	panel := grafanaPanel{ID: id, Type: panelType, Title: title, GridPos: pos, Datasource: grafanaDatasource, Targets: targets}
+	for i := range panel.Targets {
+		panel.Targets[i].RefID = string(rune('A' + i))
+		panel.Targets[i].Datasource = grafanaDatasource
+	}
+	panel.FieldConfig.Defaults.Unit = unit
+	panel.FieldConfig.Overrides = []interface{}{}
+	return panel
+}
+
+// newDashboard returns a dashboard of the metrics of -o prometheus and serve:
+// the current drift, its trend and the outdated releases, filtered by the
+// namespace variable
+func newDashboard(uid, title string) grafanaDashboard {
+	dashboard := grafanaDashboard{
+		UID:           uid,
+		Title:         title,
+		Tags:          []string{"helm", "helm-whatup"},
+		SchemaVersion: 39,
+		Refresh:       "5m",
+	}
+	dashboard.Time.From = "now-30d"
+	dashboard.Time.To = "now"
+	dashboard.Templating.List = []grafanaVariable{
+		{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+		{
+			Name:       "namespace",
+			Label:      "Namespace",
+			Type:       "query",
+			Query:      "label_values(helm_whatup_release_outdated, namespace)",
+			Datasource: &grafanaDatasource,
+			Multi:      true,
+			IncludeAll: true,
+			Refresh:    2,
+		},
+	}
+
+	selector := `namespace=~"$namespace"`
+	dashboard.Panels = []grafanaPanel{
+		newGrafanaPanel(1, "stat", "Outdated releases", "none", grafanaGridPos{H: 4, W: 6, X: 0, Y: 0},
+			grafanaTarget{Expr: fmt.Sprintf("sum(helm_whatup_release_outdated{%s})", selector)}),
+		newGrafanaPanel(2, "stat", "Major updates", "none", grafanaGridPos{H: 4, W: 6, X: 6, Y: 0},
+			grafanaTarget{Expr: fmt.Sprintf(`sum(helm_whatup_release_outdated{%s,update_severity=%q})`, selector, cfg.Vocabulary.severity("MAJOR"))}),
+		newGrafanaPanel(3, "stat", "Critical releases outdated", "none", grafanaGridPos{H: 4, W: 6, X: 12, Y: 0},
+			grafanaTarget{Expr: fmt.Sprintf(`sum(helm_whatup_release_outdated{%s,priority=%q})`, selector, cfg.Vocabulary.priority(priorityCritical))}),
+		newGrafanaPanel(4, "stat", "Last checked", "s", grafanaGridPos{H: 4, W: 6, X: 18, Y: 0},
+			grafanaTarget{Expr: "time() - max(helm_whatup_last_run_timestamp_seconds)"}),
+		newGrafanaPanel(5, "timeseries", "Outdated releases per namespace", "none", grafanaGridPos{H: 8, W: 12, X: 0, Y: 4},
+			grafanaTarget{Expr: fmt.Sprintf("sum by (namespace) (helm_whatup_release_outdated{%s})", selector), LegendFormat: "{{namespace}}"}),
+		newGrafanaPanel(6, "timeseries", "Versions behind per namespace", "none", grafanaGridPos{H: 8, W: 12, X: 12, Y: 4},
+			grafanaTarget{Expr: fmt.Sprintf("sum by (namespace) (helm_whatup_versions_behind{%s})", selector), LegendFormat: "{{namespace}}"}),
+		newGrafanaPanel(7, "table", "Outdated releases", "none", grafanaGridPos{H: 10, W: 24, X: 0, Y: 12},
+			grafanaTarget{Expr: fmt.Sprintf("helm_whatup_release_outdated{%s} == 1", selector), Instant: true, Format: "table"}),
+	}
+	return dashboard
+}
+
+// writeDashboard writes the dashboard as JSON, ready to import into Grafana
+func writeDashboard(w io.Writer, dashboard grafanaDashboard) error {
+	outputBytes, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dashboard: %w", err)
+	}
+	fmt.Fprintln(w, string(outputBytes))
+	return nil
+}
+
+// newGenDashboardCmd creates the gen-dashboard subcommand
+func newGenDashboardCmd() *cobra.Command {
+	var uid, title string
+
+	cmd := &cobra.Command{
+		Use:   "gen-dashboard",
+		Short: "print a Grafana dashboard for the metrics of -o prometheus and serve",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return writeDashboard(os.Stdout, newDashboard(uid, title))
+		},
+	}
+
+	cmd.Flags().StringVar(&uid, "uid", "helm-whatup", "UID of the dashboard")
+	cmd.Flags().StringVar(&title, "title", "Helm chart updates", "title of the dashboard")
+
+	return cmd
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the dashboard queries the exporter metrics filtered by namespace
+func TestWriteDashboard(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeDashboard(&out, newDashboard("whatup", "Charts")))
+
+	var dashboard grafanaDashboard
+	require.NoError(t, json.Unmarshal(out.Bytes(), &dashboard))
+	assert.Equal(t, "whatup", dashboard.UID)
+	assert.Equal(t, "Charts", dashboard.Title)
+	require.Len(t, dashboard.Templating.List, 2)
+	assert.Equal(t, "namespace", dashboard.Templating.List[1].Name)
+
+	ids := map[int]bool{}
+	for _, panel := range dashboard.Panels {
+		assert.False(t, ids[panel.ID], "duplicate panel ID %d", panel.ID)
+		ids[panel.ID] = true
+		require.NotEmpty(t, panel.Targets)
+		assert.Equal(t, "A", panel.Targets[0].RefID)
+		assert.Contains(t, panel.Targets[0].Expr, "helm_whatup_")
+	}
+	assert.Equal(t, `sum by (namespace) (helm_whatup_release_outdated{namespace=~"$namespace"})`, dashboard.Panels[4].Targets[0].Expr)
+}
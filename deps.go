@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Annotation and label declaring the releases a release depends on. The chart
+// annotation takes a comma-separated list; since label values cannot contain
+// commas, the release label separates the names with underscores.
+const (
+	dependsOnAnnotation = "whatup.helm.sh/depends-on"
+	dependsOnLabel      = "whatup.helm.sh/depends-on"
+)
+
+// outputFormatDot renders the dependency graph in Graphviz format
+const outputFormatDot = "dot"
+
+// dependencyRule declares the dependencies of a release in the config file.
+// Release names may be given as name or namespace/name; names without a
+// namespace refer to the namespace of the dependent release.
+type dependencyRule struct {
+	Release   string   `yaml:"release"`
+	DependsOn []string `yaml:"dependsOn"`
+}
+
+// qualifyRelease returns the namespace/name key of a release reference
+func qualifyRelease(name, namespace string) string {
+	if strings.Contains(name, "/") {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// releaseDependencies collects the declared dependencies of every release,
+// keyed by namespace/name
+func releaseDependencies(rules []dependencyRule, releases []*helmRelease) map[string][]string {
+	deps := make(map[string][]string)
+	add := func(key, namespace string, names []string) {
+		for _, name := range names {
+			if name = strings.TrimSpace(name); name != "" {
+				deps[key] = append(deps[key], qualifyRelease(name, namespace))
+			}
+		}
+	}
+
+	for _, rel := range releases {
+		key := rel.Namespace + "/" + rel.Name
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			if value, ok := rel.Chart.Metadata.Annotations[dependsOnAnnotation]; ok {
+				add(key, rel.Namespace, strings.Split(value, ","))
+			}
+		}
+		if value, ok := rel.Labels[dependsOnLabel]; ok {
+			add(key, rel.Namespace, strings.Split(value, "_"))
+		}
+		for _, rule := range rules {
+			if qualifyRelease(rule.Release, rel.Namespace) == key {
+				add(key, rel.Namespace, rule.DependsOn)
+			}
+		}
+	}
+
+	return deps
+}
+
+// annotateDependencies records the dependencies of every result and computes
+// an upgrade order in which dependencies come before their dependents.
+// Releases that are part of a dependency cycle are reported as a warning and
+// left without an order.
+func annotateDependencies(deps map[string][]string, result []ChartVersionInfo, warnings *[]warning) {
+	if len(deps) == 0 {
+		return
+	}
+
+	index := make(map[string]int, len(result))
+	for i := range result {
+		key := findingKey(result[i])
+		index[key] = i
+		result[i].DependsOn = deps[key]
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	order := 0
+	var cyclic []string
+
+	var visit func(key string) bool
+	visit = func(key string) bool {
+		switch state[key] {
+		case visiting:
+			return false
+		case visited:
+			return true
+		}
+		state[key] = visiting
+		ok := true
+		for _, dep := range deps[key] {
+			if !visit(dep) {
+				ok = false
+			}
+		}
+		state[key] = visited
+		if !ok {
+			cyclic = append(cyclic, key)
+			return false
+		}
+		if i, exists := index[key]; exists {
+			order++
+			result[i].UpgradeOrder = order
+		}
+		return true
+	}
+
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		visit(key)
+	}
+
+	if len(cyclic) > 0 {
+		sort.Strings(cyclic)
+		addWarning(warnings, warnDependencyCycle, "Dependency cycle detected, no upgrade order for: %s", strings.Join(cyclic, ", "))
+	}
+}
+
+// dotQuote quotes a string for use as a Graphviz identifier or label
+func dotQuote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// formatDot renders the releases and their dependencies as a Graphviz digraph.
+// Outdated releases are highlighted; edges point from a release to the
+// releases it depends on. Nodes are prefixed with their cluster when several
+// clusters were scanned, as dependencies never cross clusters.
+func formatDot(result []ChartVersionInfo) string {
+	var b strings.Builder
+	b.WriteString("digraph whatup {\n")
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [shape=box, style=rounded];\n")
+
+	for _, versionInfo := range result {
+		key := reportKey(versionInfo)
+		label := fmt.Sprintf("%s\\n%s", key, versionInfo.InstalledVersion)
+		attrs := ""
+		if versionInfo.Status == statusOutdated {
+			label = fmt.Sprintf("%s\\n%s -> %s", key, versionInfo.InstalledVersion, versionInfo.LatestVersion)
+			attrs = `, style="rounded,filled", fillcolor="#f8d7da", color="#dc3545"`
+		}
+		fmt.Fprintf(&b, "    %s [label=%s%s];\n", dotQuote(key), dotQuote(label), attrs)
+	}
+
+	for _, versionInfo := range result {
+		for _, dep := range versionInfo.DependsOn {
+			if versionInfo.Cluster != "" {
+				dep = versionInfo.Cluster + "/" + dep
+			}
+			fmt.Fprintf(&b, "    %s -> %s;\n", dotQuote(reportKey(versionInfo)), dotQuote(dep))
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
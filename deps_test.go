@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Test that dependencies are collected from config, annotations and labels and ordered
+func TestAnnotateDependencies(t *testing.T) {
+	app := newTestRelease("app", "shop", "app", "1.0.0")
+	app.Chart.Metadata.Annotations = map[string]string{dependsOnAnnotation: "postgres"}
+	worker := newTestRelease("worker", "shop", "worker", "1.0.0")
+	worker.Labels = map[string]string{dependsOnLabel: "app_redis"}
+	releases := []*release.Release{
+		app,
+		worker,
+		newTestRelease("postgres", "shop", "postgresql", "12.0.0"),
+		newTestRelease("redis", "shop", "redis", "17.0.0"),
+	}
+	rules := []dependencyRule{{Release: "shop/redis", DependsOn: []string{"infra/vault"}}}
+
+	deps := releaseDependencies(rules, releases)
+	result := []ChartVersionInfo{
+		{ReleaseName: "app", Namespace: "shop", Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "1.1.0"},
+		{ReleaseName: "worker", Namespace: "shop"},
+		{ReleaseName: "postgres", Namespace: "shop"},
+		{ReleaseName: "redis", Namespace: "shop"},
+	}
+
+	var warnings []warning
+	annotateDependencies(deps, result, &warnings)
+	assert.Empty(t, warnings)
+
+	assert.Equal(t, []string{"shop/postgres"}, result[0].DependsOn)
+	assert.Equal(t, []string{"shop/app", "shop/redis"}, result[1].DependsOn)
+	assert.Equal(t, []string{"infra/vault"}, result[3].DependsOn)
+	assert.Less(t, result[2].UpgradeOrder, result[0].UpgradeOrder)
+	assert.Less(t, result[0].UpgradeOrder, result[1].UpgradeOrder)
+	assert.Less(t, result[3].UpgradeOrder, result[1].UpgradeOrder)
+
+	dot := formatDot(result)
+	assert.Contains(t, dot, `"shop/worker" -> "shop/app";`)
+	assert.Contains(t, dot, `"shop/app" [label="shop/app\n1.0.0 -> 1.1.0", style="rounded,filled"`)
+}
+
+// Test that releases with the same name in different clusters are separate nodes
+func TestFormatDotClusters(t *testing.T) {
+	result := []ChartVersionInfo{
+		{Cluster: "prod", ReleaseName: "app", Namespace: "shop", InstalledVersion: "1.0.0", DependsOn: []string{"shop/postgres"}},
+		{Cluster: "prod", ReleaseName: "postgres", Namespace: "shop", InstalledVersion: "12.0.0"},
+		{Cluster: "staging", ReleaseName: "app", Namespace: "shop", InstalledVersion: "1.1.0", DependsOn: []string{"shop/postgres"}},
+		{Cluster: "staging", ReleaseName: "postgres", Namespace: "shop", InstalledVersion: "12.0.0"},
+	}
+
+	dot := formatDot(result)
+	assert.Contains(t, dot, `"prod/shop/app" [label="prod/shop/app\n1.0.0"];`)
+	assert.Contains(t, dot, `"staging/shop/app" [label="staging/shop/app\n1.1.0"];`)
+	assert.Contains(t, dot, `"prod/shop/app" -> "prod/shop/postgres";`)
+	assert.Contains(t, dot, `"staging/shop/app" -> "staging/shop/postgres";`)
+}
+
+// Test that dependency cycles are reported instead of ordered
+func TestAnnotateDependenciesCycle(t *testing.T) {
+	deps := map[string][]string{"ns/a": {"ns/b"}, "ns/b": {"ns/a"}}
+	result := []ChartVersionInfo{{ReleaseName: "a", Namespace: "ns"}, {ReleaseName: "b", Namespace: "ns"}}
+
+	var warnings []warning
+	annotateDependencies(deps, result, &warnings)
+
+	assert.Len(t, warnings, 1)
+	assert.Zero(t, result[0].UpgradeOrder)
+	assert.Zero(t, result[1].UpgradeOrder)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Digest periods accepted by --digest
+const (
+	digestDaily  = "daily"
+	digestWeekly = "weekly"
+)
+
+// digestPeriods maps the accepted digest periods to their duration
+var digestPeriods = map[string]time.Duration{
+	digestDaily:  24 * time.Hour,
+	digestWeekly: 7 * 24 * time.Hour,
+}
+
+// digestState is persisted between runs to accumulate findings per receiver
+type digestState struct {
+	Receivers map[string]*receiverDigest `json:"receivers"`
+}
+
+// receiverDigest tracks the findings accumulated for a single receiver
+type receiverDigest struct {
+	LastSent time.Time                   `json:"lastSent"`
+	Pending  map[string]ChartVersionInfo `json:"pending"`
+	Sent     map[string]ChartVersionInfo `json:"sent"`
+}
+
+// digestDelta describes what changed since the previous digest of a receiver
+type digestDelta struct {
+	Period   string             `json:"period"`
+	Since    time.Time          `json:"since,omitempty"`
+	New      []ChartVersionInfo `json:"new,omitempty"`
+	Resolved []ChartVersionInfo `json:"resolved,omitempty"`
+}
+
+// isNew reports whether the finding was not part of the previous digest
+func (d *digestDelta) isNew(finding ChartVersionInfo) bool {
+	for _, n := range d.New {
+		if reportKey(n) == reportKey(finding) {
+			return true
+		}
+	}
+	return false
+}
+
+// digestStateFile returns the location of the persisted digest state
+func digestStateFile() string {
+	return helmCachePath("whatup", "digest.json")
+}
+
+// findingKey identifies a release of a cluster across runs
+func findingKey(versionInfo ChartVersionInfo) string {
+	return versionInfo.Namespace + "/" + versionInfo.ReleaseName
+}
+
+// loadDigestState reads the digest state, returning an empty state if none exists yet
+func loadDigestState(path string) (*digestState, error) {
+	state := &digestState{Receivers: map[string]*receiverDigest{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read digest state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse digest state %s: %w", path, err)
+	}
+	if state.Receivers == nil {
+		state.Receivers = map[string]*receiverDigest{}
+	}
+
+	return state, nil
+}
+
+// saveDigestState writes the digest state to disk
+func saveDigestState(path string, state *digestState) error {
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create digest state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write digest state: %w", err)
+	}
+	return nil
+}
+
+// collectDigests merges the routed findings of this run into the persisted
+// digest state and returns a consolidated notification for every receiver
+// whose period has elapsed. Releases that were scanned in this run but are no
+// longer routed to a receiver are dropped from its pending findings.
+func collectDigests(
+	path string,
+	period string,
+	config notificationConfig,
+	result []ChartVersionInfo,
+	routed map[string][]ChartVersionInfo,
+	now time.Time,
+) ([]notification, error) {
+	duration, ok := digestPeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("invalid digest period: %s", period)
+	}
+
+	state, err := loadDigestState(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifications []notification
+	for _, receiver := range config.Receivers {
+		rd := state.Receivers[receiver.Name]
+		if rd == nil {
+			rd = &receiverDigest{}
+			state.Receivers[receiver.Name] = rd
+		}
+		if rd.Pending == nil {
+			rd.Pending = map[string]ChartVersionInfo{}
+		}
+
+		for _, versionInfo := range result {
+			delete(rd.Pending, reportKey(versionInfo))
+		}
+		for _, finding := range routed[receiver.Name] {
+			rd.Pending[reportKey(finding)] = finding
+		}
+
+		if !rd.LastSent.IsZero() && now.Sub(rd.LastSent) < duration {
+			continue
+		}
+
+		n := notification{Receiver: receiver.Name, Digest: &digestDelta{Period: period, Since: rd.LastSent}}
+		for _, key := range sortedFindingKeys(rd.Pending) {
+			finding := rd.Pending[key]
+			n.Findings = append(n.Findings, finding)
+			if previous, ok := rd.Sent[key]; !ok || previous.LatestVersion != finding.LatestVersion {
+				n.Digest.New = append(n.Digest.New, finding)
+			}
+		}
+		for _, key := range sortedFindingKeys(rd.Sent) {
+			if _, ok := rd.Pending[key]; !ok {
+				n.Digest.Resolved = append(n.Digest.Resolved, rd.Sent[key])
+			}
+		}
+
+		if len(n.Findings) > 0 || len(n.Digest.Resolved) > 0 {
+			notifications = append(notifications, n)
+		}
+
+		rd.LastSent = now
+		rd.Sent = rd.Pending
+		rd.Pending = map[string]ChartVersionInfo{}
+		for key, finding := range rd.Sent {
+			rd.Pending[key] = finding
+		}
+	}
+
+	if dryRun {
+		dryRunf("write digest state to %s", path)
+	} else if err := saveDigestState(path, state); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// sortedFindingKeys returns the keys of the findings in a stable order
+func sortedFindingKeys(findings map[string]ChartVersionInfo) []string {
+	keys := make([]string, 0, len(findings))
+	for key := range findings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that digests are sent once per period and highlight the delta
+func TestCollectDigests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "digest.json")
+	config := notificationConfig{
+		Route:     notificationRoute{Receiver: "weekly"},
+		Receivers: []receiverConfig{{Name: "weekly"}},
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	api := ChartVersionInfo{ReleaseName: "api", Namespace: "prod", LatestVersion: "2.0.0", Status: statusOutdated}
+	web := ChartVersionInfo{ReleaseName: "web", Namespace: "prod", LatestVersion: "1.1.0", Status: statusOutdated}
+	webFixed := ChartVersionInfo{ReleaseName: "web", Namespace: "prod", LatestVersion: "1.1.0", Status: statusUptodate}
+
+	// First run sends the initial digest right away
+	routed := map[string][]ChartVersionInfo{"weekly": {web}}
+	notifications, err := collectDigests(path, digestWeekly, config, []ChartVersionInfo{web}, routed, start)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Len(t, notifications[0].Digest.New, 1)
+
+	// Runs within the period only accumulate
+	routed = map[string][]ChartVersionInfo{"weekly": {api}}
+	notifications, err = collectDigests(path, digestWeekly, config, []ChartVersionInfo{api, webFixed}, routed, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, notifications)
+
+	// Once the period elapsed, a consolidated digest with the delta is sent
+	notifications, err = collectDigests(path, digestWeekly, config, nil, nil, start.Add(8*24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+
+	n := notifications[0]
+	require.Len(t, n.Findings, 1)
+	assert.Equal(t, "api", n.Findings[0].ReleaseName)
+	assert.True(t, n.Digest.isNew(api))
+	require.Len(t, n.Digest.Resolved, 1)
+	assert.Equal(t, "web", n.Digest.Resolved[0].ReleaseName)
+	assert.Contains(t, formatNotificationText(n), "Weekly digest")
+}
+
+// Test that an unknown digest period is rejected
+func TestCollectDigestsInvalidPeriod(t *testing.T) {
+	_, err := collectDigests(filepath.Join(t.TempDir(), "digest.json"), "hourly", notificationConfig{}, nil, nil, time.Now())
+	assert.Error(t, err)
+}
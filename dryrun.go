@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// dryRunOut receives the actions skipped because of --dry-run. It is kept
+// separate from stdout so machine-readable output stays parseable.
+var dryRunOut io.Writer = os.Stderr
+
+// dryRunf reports an action that would have been performed without --dry-run
+func dryRunf(format string, v ...interface{}) {
+	fmt.Fprintf(dryRunOut, "DRY-RUN: "+format+"\n", v...)
+}
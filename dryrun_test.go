@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Test that --dry-run prints the actions instead of performing them
+func TestDryRun(t *testing.T) {
+	var out bytes.Buffer
+	dryRun, dryRunOut = true, &out
+	defer func() { dryRun, dryRunOut = false, os.Stderr }()
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	result := []ChartVersionInfo{{ReleaseName: "web", Namespace: "apps", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "1.1.0", Status: statusOutdated}}
+	ctx := context.Background()
+
+	require.NoError(t, postJSON(ctx, server.Client(), server.URL, nil, result))
+	assert.False(t, called)
+	assert.Contains(t, out.String(), "DRY-RUN: POST "+server.URL+" [{")
+
+	clientset := fake.NewSimpleClientset()
+	var warnings []warning
+	emitOutdatedEvents(ctx, clientset, result, &warnings)
+	events, err := clientset.CoreV1().Events("apps").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, events.Items)
+	assert.Contains(t, out.String(), "DRY-RUN: create event apps/web.whatup.1.1.0 for release web\n")
+
+	t.Setenv("HELM_BIN", "helm")
+	addRepositories(ctx, []wellKnownRepository{{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"}}, &warnings)
+	assert.Empty(t, warnings)
+	assert.Contains(t, out.String(), "DRY-RUN: helm repo add bitnami https://charts.bitnami.com/bitnami\n")
+
+	auditLog = filepath.Join(t.TempDir(), "audit.log")
+	defer func() { auditLog = "" }()
+	recordScan(scanReport{results: result, releases: 1}, nil)
+	assert.NoFileExists(t, auditLog)
+	assert.Contains(t, out.String(), "DRY-RUN: append scan record to audit log "+auditLog+"\n")
+}
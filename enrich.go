@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+// enrichResults adds information from the cluster to the results and emits
+// the configured cluster-side signals. The Kubernetes client is only created
+// when at least one of these features is enabled.
+func enrichResults(ctx context.Context, target scanTarget, result []ChartVersionInfo, warnings *[]warning) error {
+	opts := target.options
+	annotation := opts.ownerAnnotation
+
+	if !opts.emitEvents && annotation == "" && !opts.correlateWorkloads {
+		return nil
+	}
+
+	clientset, err := newKubeClientset(target.settings)
+	if err != nil {
+		return err
+	}
+
+	if annotation != "" {
+		resolveOwners(ctx, clientset, annotation, result, warnings)
+		// Group the results by owner so each team finds its releases together
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Owner < result[j].Owner
+		})
+	}
+
+	if opts.correlateWorkloads {
+		correlateWorkloadStatus(ctx, clientset, result, warnings)
+	}
+
+	if opts.emitEvents {
+		emitOutdatedEvents(ctx, clientset, result, warnings)
+	}
+
+	return nil
+}
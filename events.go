@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Constants for Kubernetes events emitted for outdated releases
+const (
+	eventReasonOutdated = "ChartOutdated"
+	eventComponent      = "helm-whatup"
+	eventObjectKind     = "Release"
+	eventObjectAPI      = "helm.sh/v3"
+	maxEventNameLength  = 253
+)
+
+// invalidEventNameChars matches characters that are not allowed in an event name
+var invalidEventNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// eventName builds a deterministic event name for a release and the version
+// it is outdated against. Because the name only changes when a new version
+// becomes available, an event is emitted once per newly available version
+// rather than on every run.
+func eventName(versionInfo ChartVersionInfo) string {
+	name := fmt.Sprintf("%s.whatup.%s", versionInfo.ReleaseName, strings.ToLower(versionInfo.LatestVersion))
+	name = invalidEventNameChars.ReplaceAllString(name, "-")
+	if len(name) > maxEventNameLength {
+		name = name[:maxEventNameLength]
+	}
+	// Names have to start and end with an alphanumeric character, also when
+	// they were cut
+	return strings.Trim(name, "-.")
+}
+
+// newOutdatedEvent creates the Kubernetes Event describing an outdated release
+func newOutdatedEvent(versionInfo ChartVersionInfo, now time.Time) *corev1.Event {
+	host, _ := os.Hostname()
+	timestamp := metav1.NewTime(now)
+
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      eventName(versionInfo),
+			Namespace: versionInfo.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": eventComponent,
+			},
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: eventObjectAPI,
+			Kind:       eventObjectKind,
+			Name:       versionInfo.ReleaseName,
+			Namespace:  versionInfo.Namespace,
+		},
+		Reason: eventReasonOutdated,
+		Message: fmt.Sprintf("Release %s uses chart %s %s, but %s is available",
+			versionInfo.ReleaseName,
+			versionInfo.ChartName,
+			versionInfo.InstalledVersion,
+			versionInfo.LatestVersion),
+		Type:                corev1.EventTypeWarning,
+		Source:              corev1.EventSource{Component: eventComponent, Host: host},
+		ReportingController: eventComponent,
+		ReportingInstance:   host,
+		FirstTimestamp:      timestamp,
+		LastTimestamp:       timestamp,
+		Count:               1,
+	}
+}
+
+// emitOutdatedEvents emits a Kubernetes Event in the namespace of every outdated
+// release. Events that already exist for the same release and version are left
+// untouched. Failures are collected as warnings instead of aborting the run.
+func emitOutdatedEvents(ctx context.Context, clientset kubernetes.Interface, result []ChartVersionInfo, warnings *[]warning) {
+	now := time.Now()
+
+	for _, versionInfo := range result {
+		if versionInfo.Status != statusOutdated {
+			continue
+		}
+
+		event := newOutdatedEvent(versionInfo, now)
+		if dryRun {
+			dryRunf("create event %s/%s for release %s", event.Namespace, event.Name, versionInfo.ReleaseName)
+			continue
+		}
+		_, err := clientset.CoreV1().Events(versionInfo.Namespace).Create(ctx, event, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			addWarning(warnings, warnEventFailed, "Failed to emit event for '%s': %v", versionInfo.ReleaseName, err)
+		}
+	}
+}
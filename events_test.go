@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Test that events are emitted once per outdated release and version
+func TestEmitOutdatedEvents(t *testing.T) {
+	clientset := fake.NewClientset()
+	result := []ChartVersionInfo{
+		{ReleaseName: "outdated", Namespace: "apps", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "1.1.0+build.1", Status: statusOutdated},
+		{ReleaseName: "current", Namespace: "apps", ChartName: "redis", InstalledVersion: "2.0.0", LatestVersion: "2.0.0", Status: statusUptodate},
+	}
+
+	var warnings []warning
+	emitOutdatedEvents(context.Background(), clientset, result, &warnings)
+	emitOutdatedEvents(context.Background(), clientset, result, &warnings)
+	assert.Empty(t, warnings)
+
+	events, err := clientset.CoreV1().Events("apps").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+
+	event := events.Items[0]
+	assert.Equal(t, "outdated.whatup.1.1.0-build.1", event.Name)
+	assert.Equal(t, eventReasonOutdated, event.Reason)
+	assert.Equal(t, "outdated", event.InvolvedObject.Name)
+	assert.Contains(t, event.Message, "1.1.0+build.1")
+}
+
+// Test that event names of long releases are cut to a valid name
+func TestEventNameLongRelease(t *testing.T) {
+	name := eventName(ChartVersionInfo{ReleaseName: strings.Repeat("a", 246), LatestVersion: "1.1.0"})
+	assert.Equal(t, strings.Repeat("a", 246)+".whatup", name)
+	assert.LessOrEqual(t, len(name), maxEventNameLength)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes other than the generic failure code 1
+const (
+	// exitCodeOutdated is returned by --fail-on-outdated when outdated or vulnerable releases were found
+	exitCodeOutdated = 2
+	// exitCodePolicy is returned when the enforced repository policy is violated
+	exitCodePolicy = 3
+	// exitCodeFindings is returned by check --fail-on when findings of the given severity were found
+	exitCodeFindings = 4
+	// exitCodeLocked is returned when another run holds the lock (EX_TEMPFAIL)
+	exitCodeLocked = 75
+)
+
+// exitError is an error that terminates whatup with a specific exit code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// outdatedError returns the error failing the run because of outdated or
+// vulnerable releases, or nil if there are none
+func outdatedError(result []ChartVersionInfo) error {
+	outdated, vulnerable := 0, 0
+	for _, versionInfo := range result {
+		switch versionInfo.Status {
+		case statusOutdated:
+			outdated++
+		case statusVulnerable:
+			vulnerable++
+		}
+	}
+	switch {
+	case outdated == 0 && vulnerable == 0:
+		return nil
+	case vulnerable == 0:
+		return &exitError{code: exitCodeOutdated, err: fmt.Errorf("%d release(s) are outdated", outdated)}
+	default:
+		return &exitError{code: exitCodeOutdated, err: fmt.Errorf("%d release(s) are outdated, %d are vulnerable", outdated, vulnerable)}
+	}
+}
+
+// repoPolicyError returns the error failing the run because of releases from
+// unapproved repositories, or nil if there are none
+func repoPolicyError(result []ChartVersionInfo) error {
+	unapproved := 0
+	for _, versionInfo := range result {
+		if hasFlag(versionInfo, flagUnapprovedRepo) {
+			unapproved++
+		}
+	}
+	if unapproved == 0 {
+		return nil
+	}
+	return &exitError{code: exitCodePolicy, err: fmt.Errorf("%d release(s) are installed from unapproved repositories", unapproved)}
+}
+
+// exitCode returns the exit code for an error returned by a command
+func exitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return 1
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test the exit codes pipelines can gate on
+func TestExitCodes(t *testing.T) {
+	assert.NoError(t, outdatedError([]ChartVersionInfo{{Status: statusUptodate}, {Status: statusUnknown}}))
+
+	err := outdatedError([]ChartVersionInfo{{Status: statusOutdated}, {Status: statusUptodate}, {Status: statusOutdated}})
+	assert.EqualError(t, err, "2 release(s) are outdated")
+	assert.Equal(t, exitCodeOutdated, exitCode(err))
+	assert.Equal(t, exitCodeOutdated, exitCode(fmt.Errorf("wrapped: %w", err)))
+
+	assert.Equal(t, 1, exitCode(errors.New("failed to list releases")))
+}
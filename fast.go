@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// changedFiles returns the files of dir that differ from the last commit,
+// staged or not, and the untracked ones, relative to dir. This is what a
+// pre-commit hook is about to commit.
+func changedFiles(ctx context.Context, dir string) (map[string]bool, error) {
+	changed := make(map[string]bool)
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", "--cached"},
+		{"diff", "--name-only", "--relative"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		// #nosec G204 -- the arguments are fixed, only the directory is user-supplied
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the changed files of %s with git: %w: %s", dir, err, strings.TrimSpace(stderr.String()))
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				changed[filepath.FromSlash(line)] = true
+			}
+		}
+	}
+	return changed, nil
+}
+
+// changedDeclarations returns the declarations made in the changed files
+func changedDeclarations(declarations []chartDeclaration, changed map[string]bool) []chartDeclaration {
+	var selected []chartDeclaration
+	for _, declaration := range declarations {
+		if changed[declaration.Source] {
+			selected = append(selected, declaration)
+		}
+	}
+	return selected
+}
+
+// checkFastMode fails when a version rule source of the config needs the
+// network, since --fast only uses cached data
+func checkFastMode() error {
+	if location := catalogLocation(); isRepositoryURL(location) {
+		return fmt.Errorf("--fast only uses cached data, use a local copy of the catalog %s", location)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that only the files changed since the last commit are checked
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	writeTestFiles(t, dir, map[string]string{
+		"deploy/helmfile.yaml":  "releases: [{name: web, chart: repo/web, version: 1.0.0}]",
+		"deploy/committed.yaml": "releases: []",
+	})
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	writeTestFiles(t, dir, map[string]string{
+		"deploy/helmfile.yaml": "releases: [{name: web, chart: repo/web, version: 1.1.0}]",
+		"deploy/new.yaml":      "releases: []",
+		"other/staged.yaml":    "releases: []",
+	})
+	git("add", "other/staged.yaml")
+
+	changed, err := changedFiles(context.Background(), filepath.Join(dir, "deploy"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"helmfile.yaml": true, "new.yaml": true}, changed)
+
+	declarations := []chartDeclaration{
+		{Source: "helmfile.yaml", Release: "web"},
+		{Source: "committed.yaml", Release: "api"},
+	}
+	assert.Equal(t, declarations[:1], changedDeclarations(declarations, changed))
+}
+
+// Test that --fast refuses remote catalogs
+func TestCheckFastMode(t *testing.T) {
+	assert.NoError(t, checkFastMode())
+
+	catalogPath = "https://example.com/catalog.yaml"
+	defer func() { catalogPath = "" }()
+	assert.Error(t, checkFastMode())
+}
+
+// Test that --fast resolves the version ranges of the changed charts
+func TestFastModeVersionRange(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"repositories.yaml": `
+repositories:
+  - name: bitnami
+    url: https://charts.bitnami.com/bitnami
+`,
+		"cache/bitnami-index.yaml": `
+apiVersion: v1
+entries:
+  redis:
+    - name: redis
+      version: 18.0.2
+    - name: redis
+      version: 18.0.0
+`,
+	})
+	t.Setenv("HELM_REPOSITORY_CONFIG", filepath.Join(dir, "repositories.yaml"))
+	t.Setenv("HELM_REPOSITORY_CACHE", filepath.Join(dir, "cache"))
+
+	repo := filepath.Join(dir, "repo")
+	writeTestFiles(t, repo, map[string]string{
+		"helmfile.yaml": "releases: [{name: cache, chart: bitnami/redis, version: ~18.0.0}]",
+	})
+	out, err := exec.Command("git", "-C", repo, "init", "-q").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	declarations, warnings, err := discoverDeclarations(repo, false)
+	require.NoError(t, err)
+	changed, err := changedFiles(context.Background(), repo)
+	require.NoError(t, err)
+	declarations = changedDeclarations(declarations, changed)
+	require.Len(t, declarations, 1)
+
+	ociOffline = true
+	defer func() { ociOffline = false }()
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	report, err := scanDeclarations(cmd, declarations, warnings)
+	require.NoError(t, err)
+	require.Len(t, report.results, 1)
+	assert.Equal(t, statusUptodate, report.results[0].Status)
+	assert.Equal(t, "18.0.2", report.results[0].InstalledVersion)
+	assert.Equal(t, "~18.0.0", report.results[0].VersionConstraint)
+}
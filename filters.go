@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// releaseFilter selects releases by release and chart name before they are
+// looked up in the repository indexes
+type releaseFilter struct {
+	release *regexp.Regexp
+	chart   *regexp.Regexp
+}
+
+// newReleaseFilter compiles the values of --release-filter and --chart-filter.
+// Empty expressions match everything.
+func newReleaseFilter(releaseExpr, chartExpr string) (releaseFilter, error) {
+	var filter releaseFilter
+	var err error
+	if releaseExpr != "" {
+		if filter.release, err = regexp.Compile(releaseExpr); err != nil {
+			return filter, fmt.Errorf("invalid release filter: %w", err)
+		}
+	}
+	if chartExpr != "" {
+		if filter.chart, err = regexp.Compile(chartExpr); err != nil {
+			return filter, fmt.Errorf("invalid chart filter: %w", err)
+		}
+	}
+	return filter, nil
+}
+
+// apply drops the releases not matching the filter
+func (f releaseFilter) apply(releases []*helmRelease) []*helmRelease {
+	if f.release == nil && f.chart == nil {
+		return releases
+	}
+
+	var filtered []*helmRelease
+	for _, rel := range releases {
+		if f.release != nil && !f.release.MatchString(rel.Name) {
+			continue
+		}
+		if f.chart != nil && (rel.Chart == nil || rel.Chart.Metadata == nil || !f.chart.MatchString(rel.Chart.Metadata.Name)) {
+			continue
+		}
+		filtered = append(filtered, rel)
+	}
+	return filtered
+}
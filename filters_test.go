@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+)
+
+// Test that releases are filtered on release and chart name while listing them
+func TestFetchReleasesFilters(t *testing.T) {
+	defer func() { releaseFilterExpr, chartFilterExpr = "", "" }()
+
+	clients := []*action.Configuration{
+		newTestClient(t, "default",
+			newTestRelease("prod-api", "default", "api", "1.0.0"),
+			newTestRelease("prod-db", "default", "postgresql", "1.0.0"),
+			newTestRelease("staging-api", "default", "api", "1.0.0"),
+		),
+	}
+
+	releaseFilterExpr = "^prod-"
+	releases, err := fetchReleases(clients)
+	require.NoError(t, err)
+	assert.Len(t, releases, 2)
+
+	chartFilterExpr = "^api$"
+	releases, err = fetchReleases(clients)
+	require.NoError(t, err)
+	require.Len(t, releases, 1)
+	assert.Equal(t, "prod-api", releases[0].Name)
+
+	chartFilterExpr = "("
+	_, err = fetchReleases(clients)
+	assert.Error(t, err)
+}
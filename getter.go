@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// getterScheme is the URL scheme whatup serves as a Helm downloader plugin
+const getterScheme = "whatup"
+
+// parseGetterURL returns the repository and chart of a whatup://latest/REPO/CHART URL
+func parseGetterURL(raw string) (string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Scheme != getterScheme || u.Host != "latest" || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid URL %q, expected %s://latest/REPO/CHART", raw, getterScheme)
+	}
+	return parts[0], parts[1], nil
+}
+
+// latestChartVersion returns the index entry of the latest version of a chart
+// in the cached index of a repository
+func latestChartVersion(repositories []repoIndex, repoFileData *helmRepoFile, repoName, chartName string) (*helmChartVersion, error) {
+	for _, idx := range repositories {
+		if idx.name != repoName {
+			continue
+		}
+		entries := idx.Entries[chartName]
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("chart %s not found in repository %s", chartName, repoName)
+		}
+		latest := findLatestVersion(entries, repoFileData, &repoName, func(string, time.Time) bool { return true })
+		for _, entry := range entries {
+			if entry.Version == latest {
+				return entry, nil
+			}
+		}
+		return nil, fmt.Errorf("chart %s has no stable version in repository %s", chartName, repoName)
+	}
+	return nil, fmt.Errorf("repository %s not found, add it with `helm repo add` and run `helm repo update`", repoName)
+}
+
+// writeChartVersion writes the index entry of a chart version as YAML
+func writeChartVersion(w io.Writer, entry *helmChartVersion) error {
+	outputBytes, err := yaml.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	_, err = w.Write(outputBytes)
+	return err
+}
+
+// newGetterCmd creates the getter subcommand Helm runs for whatup:// URLs.
+// Helm passes the certificate, key and CA files of the request before the
+// URL and reads the response from stdout.
+func newGetterCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "getter CERT_FILE KEY_FILE CA_FILE URL",
+		Short:  "answer whatup://latest/REPO/CHART requests of Helm with the metadata of the latest chart version",
+		Hidden: true,
+		Args:   cobra.ExactArgs(4),
+		RunE: func(_ *cobra.Command, args []string) error {
+			repoName, chartName, err := parseGetterURL(args[3])
+			if err != nil {
+				return err
+			}
+			repositories, err := fetchIndices()
+			if err != nil {
+				return err
+			}
+			repoFileData, err := loadRepoFile(newSettings().RepositoryConfig)
+			if err != nil {
+				return fmt.Errorf("failed to load repository file: %w", err)
+			}
+			entry, err := latestChartVersion(repositories, repoFileData, repoName, chartName)
+			if err != nil {
+				return err
+			}
+			return writeChartVersion(os.Stdout, entry)
+		},
+	}
+}
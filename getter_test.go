@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that whatup:// URLs name a repository and a chart
+func TestParseGetterURL(t *testing.T) {
+	repoName, chartName, err := parseGetterURL("whatup://latest/bitnami/redis")
+	require.NoError(t, err)
+	assert.Equal(t, "bitnami", repoName)
+	assert.Equal(t, "redis", chartName)
+
+	for _, raw := range []string{"https://latest/bitnami/redis", "whatup://oldest/bitnami/redis", "whatup://latest/redis", "whatup://latest/bitnami/redis/extra"} {
+		_, _, err := parseGetterURL(raw)
+		assert.ErrorContains(t, err, "expected whatup://latest/REPO/CHART", raw)
+	}
+}
+
+// Test that the getter answers with the index entry of the latest stable version
+func TestLatestChartVersion(t *testing.T) {
+	idx := newTestIndex("redis", "18.0.0", "18.1.0", "19.0.0-rc.1")
+	idx.name = "bitnami"
+
+	entry, err := latestChartVersion([]repoIndex{idx}, nil, "bitnami", "redis")
+	require.NoError(t, err)
+	assert.Equal(t, "18.1.0", entry.Version)
+
+	var out bytes.Buffer
+	require.NoError(t, writeChartVersion(&out, entry))
+	assert.Contains(t, out.String(), "name: redis\n")
+	assert.Contains(t, out.String(), "version: 18.1.0\n")
+
+	_, err = latestChartVersion([]repoIndex{idx}, nil, "bitnami", "nginx")
+	assert.EqualError(t, err, "chart nginx not found in repository bitnami")
+	_, err = latestChartVersion([]repoIndex{idx}, nil, "stable", "redis")
+	assert.ErrorContains(t, err, "repository stable not found")
+}
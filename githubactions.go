@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// githubStepSummaryEnv names the file GitHub Actions renders as the summary of a step
+const githubStepSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+// writeGitHubAnnotations writes a workflow command per outdated or vulnerable
+// release, shown as annotations of the workflow run. Results of scan-dir are
+// attached to the file declaring them.
+func writeGitHubAnnotations(w io.Writer, result []ChartVersionInfo) {
+	for _, versionInfo := range result {
+		var level, title, message string
+		switch versionInfo.Status {
+		case statusOutdated:
+			level = "warning"
+			title = fmt.Sprintf("%s is outdated", versionInfo.ReleaseName)
+			message = fmt.Sprintf("Release %s (%s) can be updated from %s to %s",
+				versionInfo.ReleaseName, versionInfo.ChartName, versionInfo.InstalledVersion, versionInfo.LatestVersion)
+		case statusVulnerable:
+			level = "error"
+			title = fmt.Sprintf("%s is vulnerable", versionInfo.ReleaseName)
+			message = fmt.Sprintf("Release %s (%s) version %s is affected by %s",
+				versionInfo.ReleaseName, versionInfo.ChartName, versionInfo.InstalledVersion, advisoriesColumn(versionInfo))
+		default:
+			continue
+		}
+		if versionInfo.Namespace != "" {
+			message += fmt.Sprintf(" in namespace %s", versionInfo.Namespace)
+		}
+
+		properties := "title=" + escapeGitHubProperty(title)
+		if versionInfo.Source != "" {
+			properties = "file=" + escapeGitHubProperty(versionInfo.Source) + "," + properties
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", level, properties, escapeGitHubData(message))
+	}
+}
+
+// appendGitHubStepSummary appends the markdown table of the outdated releases
+// to the step summary, when running in GitHub Actions
+func appendGitHubStepSummary(result []ChartVersionInfo) error {
+	path := os.Getenv(githubStepSummaryEnv)
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open the step summary: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "## Helm chart updates\n\n%s\n", formatMarkdown(result)); err != nil {
+		return fmt.Errorf("failed to write the step summary: %w", err)
+	}
+	return nil
+}
+
+// escapeGitHubData escapes the message of a workflow command
+func escapeGitHubData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command
+func escapeGitHubProperty(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
+
+// reportToGitHubActions writes the annotations and the step summary of a scan
+func reportToGitHubActions(w io.Writer, result []ChartVersionInfo) error {
+	writeGitHubAnnotations(w, result)
+	return appendGitHubStepSummary(result)
+}
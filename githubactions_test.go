@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that outdated and vulnerable releases become workflow annotations
+func TestWriteGitHubAnnotations(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "2.0.0", Status: statusOutdated},
+		{ReleaseName: "db", Namespace: "prod", ChartName: "postgresql", Status: statusUptodate},
+		{ReleaseName: "cache", ChartName: "redis", InstalledVersion: "18.0.0", LatestVersion: "18.1.0", Status: statusOutdated, Source: "deploy/helmfile.yaml"},
+		{ReleaseName: "api", Namespace: "prod", ChartName: "api", InstalledVersion: "1.0.0", Status: statusVulnerable,
+			Advisories: []Advisory{{ID: "CVE-2024-1234"}}},
+	}
+
+	var out bytes.Buffer
+	writeGitHubAnnotations(&out, result)
+	assert.Equal(t,
+		"::warning title=web is outdated::Release web (nginx) can be updated from 1.0.0 to 2.0.0 in namespace prod\n"+
+			"::warning file=deploy/helmfile.yaml,title=cache is outdated::Release cache (redis) can be updated from 18.0.0 to 18.1.0\n"+
+			"::error title=api is vulnerable::Release api (api) version 1.0.0 is affected by CVE-2024-1234 in namespace prod\n",
+		out.String())
+
+	assert.Equal(t, "a%3Ab%2Cc%25%0A", escapeGitHubProperty("a:b,c%\n"))
+	assert.Equal(t, "a:b,c%25%0A", escapeGitHubData("a:b,c%\n"))
+}
+
+// Test that the markdown table is appended to the step summary
+func TestAppendGitHubStepSummary(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "2.0.0", Status: statusOutdated},
+	}
+
+	t.Setenv(githubStepSummaryEnv, "")
+	require.NoError(t, appendGitHubStepSummary(result))
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	require.NoError(t, os.WriteFile(path, []byte("previous step\n"), 0o600))
+	t.Setenv(githubStepSummaryEnv, path)
+	require.NoError(t, appendGitHubStepSummary(result))
+
+	summary, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(summary), "previous step\n## Helm chart updates\n\n| Release |")
+	assert.Contains(t, string(summary), "| web | prod | nginx | 1.0.0 | 2.0.0 |")
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Resources of the GitOps controllers read to find where a release is declared
+var (
+	fluxHelmReleases   = schema.GroupVersionResource{Group: "helm.toolkit.fluxcd.io", Version: "v2", Resource: "helmreleases"}
+	fluxKustomizations = schema.GroupVersionResource{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Resource: "kustomizations"}
+	fluxGitRepos       = schema.GroupVersionResource{Group: "source.toolkit.fluxcd.io", Version: "v1", Resource: "gitrepositories"}
+	argoApplications   = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"}
+)
+
+// Labels the Flux kustomize-controller puts on the objects it applies, such
+// as HelmReleases, naming the Kustomization that applied them
+const (
+	fluxKustomizationNameLabel      = "kustomize.toolkit.fluxcd.io/name"
+	fluxKustomizationNamespaceLabel = "kustomize.toolkit.fluxcd.io/namespace"
+)
+
+// defaultArgoCDNamespace is where Argo CD Applications live by default
+const defaultArgoCDNamespace = "argocd"
+
+// GitSource is where a release deployed by a GitOps controller is declared in
+// Git, i.e. where its chart version has to be changed
+type GitSource struct {
+	Controller string `json:"controller"`
+	Repository string `json:"repository"`
+	Path       string `json:"path,omitempty"`
+	Revision   string `json:"revision,omitempty"`
+	// URL links to the path on GitHub or GitLab
+	URL string `json:"url,omitempty"`
+}
+
+// newKubeDynamicClient returns a client of the custom resources of the cluster
+func newKubeDynamicClient(settings *helmSettings) (dynamic.Interface, error) {
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Kubernetes client configuration: %w", err)
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return client, nil
+}
+
+// addGitSources records the Git source of the results whose release is
+// reconciled by Flux or Argo CD. Sources that can't be looked up, e.g. for
+// lack of permissions, are reported as warnings.
+func addGitSources(ctx context.Context, client dynamic.Interface, releases []*helmRelease, result []ChartVersionInfo, warnings *[]warning) {
+	owners := make(map[string]gitOpsOwner, len(releases))
+	for _, rel := range releases {
+		if owner, ok := releaseGitOpsOwner(rel); ok {
+			owners[rel.Namespace+"/"+rel.Name] = owner
+		}
+	}
+
+	// Releases of the same HelmRelease or Application share their source
+	sources := map[gitOpsOwner]*GitSource{}
+	for i := range result {
+		owner, ok := owners[findingKey(result[i])]
+		if !ok {
+			continue
+		}
+		source, seen := sources[owner]
+		if !seen {
+			var err error
+			source, err = lookupGitSource(ctx, client, owner, result[i].Namespace)
+			if err != nil {
+				addWarning(warnings, warnGitSourceFailed, "Failed to look up the Git source of '%s': %v", result[i].ReleaseName, err)
+			}
+			sources[owner] = source
+		}
+		result[i].GitSource = source
+	}
+}
+
+// lookupGitSource returns the Git source of a GitOps object, or nil when it
+// is not deployed from Git, e.g. from a Helm or OCI repository
+func lookupGitSource(ctx context.Context, client dynamic.Interface, owner gitOpsOwner, releaseNamespace string) (*GitSource, error) {
+	switch owner.tool {
+	case autoManagedByFlux:
+		namespace := owner.namespace
+		if namespace == "" {
+			namespace = releaseNamespace
+		}
+		return fluxGitSource(ctx, client, namespace, owner.name)
+	case autoManagedByArgoCD:
+		namespace := owner.namespace
+		if namespace == "" {
+			namespace = defaultArgoCDNamespace
+		}
+		return argoGitSource(ctx, client, namespace, owner.name)
+	}
+	return nil, nil
+}
+
+// fluxGitSource follows a HelmRelease to the Kustomization that applied it
+// and to the GitRepository of the Kustomization
+func fluxGitSource(ctx context.Context, client dynamic.Interface, namespace, name string) (*GitSource, error) {
+	helmRelease, err := client.Resource(fluxHelmReleases).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HelmRelease %s/%s: %w", namespace, name, err)
+	}
+	labels := helmRelease.GetLabels()
+	kustomizationName := labels[fluxKustomizationNameLabel]
+	if kustomizationName == "" {
+		// Applied by hand or by another tool
+		return nil, nil
+	}
+	kustomizationNamespace := labels[fluxKustomizationNamespaceLabel]
+	if kustomizationNamespace == "" {
+		kustomizationNamespace = namespace
+	}
+
+	kustomization, err := client.Resource(fluxKustomizations).Namespace(kustomizationNamespace).Get(ctx, kustomizationName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Kustomization %s/%s: %w", kustomizationNamespace, kustomizationName, err)
+	}
+	path, _, _ := unstructured.NestedString(kustomization.Object, "spec", "path")
+	kind, _, _ := unstructured.NestedString(kustomization.Object, "spec", "sourceRef", "kind")
+	sourceName, _, _ := unstructured.NestedString(kustomization.Object, "spec", "sourceRef", "name")
+	sourceNamespace, _, _ := unstructured.NestedString(kustomization.Object, "spec", "sourceRef", "namespace")
+	if kind != "GitRepository" {
+		return nil, nil
+	}
+	if sourceNamespace == "" {
+		sourceNamespace = kustomizationNamespace
+	}
+
+	gitRepo, err := client.Resource(fluxGitRepos).Namespace(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitRepository %s/%s: %w", sourceNamespace, sourceName, err)
+	}
+	repository, _, _ := unstructured.NestedString(gitRepo.Object, "spec", "url")
+	var revision string
+	for _, field := range []string{"commit", "tag", "branch"} {
+		if revision, _, _ = unstructured.NestedString(gitRepo.Object, "spec", "ref", field); revision != "" {
+			break
+		}
+	}
+	return newGitSource(autoManagedByFlux, repository, path, revision), nil
+}
+
+// argoGitSource returns the Git source of an Application, the first of its
+// sources with a path for Applications with several sources
+func argoGitSource(ctx context.Context, client dynamic.Interface, namespace, name string) (*GitSource, error) {
+	app, err := client.Resource(argoApplications).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Application %s/%s: %w", namespace, name, err)
+	}
+
+	sources, _, _ := unstructured.NestedSlice(app.Object, "spec", "sources")
+	if source, ok, _ := unstructured.NestedMap(app.Object, "spec", "source"); ok {
+		sources = append([]interface{}{source}, sources...)
+	}
+	for _, s := range sources {
+		source, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, _, _ := unstructured.NestedString(source, "path")
+		if path == "" {
+			// Charts installed straight from a chart repository
+			continue
+		}
+		repository, _, _ := unstructured.NestedString(source, "repoURL")
+		revision, _, _ := unstructured.NestedString(source, "targetRevision")
+		return newGitSource(autoManagedByArgoCD, repository, path, revision), nil
+	}
+	return nil, nil
+}
+
+// newGitSource returns a Git source, linking to the path when the repository
+// is hosted on GitHub or GitLab
+func newGitSource(controller, repository, path, revision string) *GitSource {
+	source := &GitSource{
+		Controller: controller,
+		Repository: repository,
+		Path:       strings.TrimPrefix(strings.TrimPrefix(path, "./"), "/"),
+		Revision:   revision,
+	}
+
+	web := gitWebURL(repository)
+	if web != "" {
+		ref := revision
+		if ref == "" || ref == "HEAD" {
+			ref = "HEAD"
+		}
+		source.URL = web + "/tree/" + ref
+		if source.Path != "" && source.Path != "." {
+			source.URL += "/" + source.Path
+		}
+	}
+	return source
+}
+
+// gitWebURL returns the web address of a GitHub or GitLab repository cloned
+// over HTTPS or SSH, or an empty string for other hosts
+func gitWebURL(repository string) string {
+	address := strings.TrimSuffix(strings.TrimSuffix(repository, "/"), ".git")
+	switch {
+	case strings.HasPrefix(address, "https://"):
+		address = strings.TrimPrefix(address, "https://")
+	case strings.HasPrefix(address, "ssh://"):
+		address = strings.TrimPrefix(address, "ssh://")
+		address = address[strings.Index(address, "@")+1:]
+		// Drop the port of ssh://git@host:22/org/repo
+		if host, rest, found := strings.Cut(address, "/"); found {
+			host, _, _ = strings.Cut(host, ":")
+			address = host + "/" + rest
+		}
+	case strings.HasPrefix(address, "git@"):
+		address = strings.Replace(strings.TrimPrefix(address, "git@"), ":", "/", 1)
+	default:
+		return ""
+	}
+
+	host, _, _ := strings.Cut(address, "/")
+	if !strings.Contains(host, "github") && !strings.Contains(host, "gitlab") {
+		return ""
+	}
+	return "https://" + address
+}
+
+// gitSourceColumn renders the link to the Git source of a release, or its
+// repository and path when there is no link
+func gitSourceColumn(versionInfo ChartVersionInfo) string {
+	source := versionInfo.GitSource
+	switch {
+	case source == nil:
+		return ""
+	case source.URL != "":
+		return source.URL
+	case source.Path != "":
+		return source.Repository + "//" + source.Path
+	default:
+		return source.Repository
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+// newTestObject returns a custom resource of a GitOps controller
+func newTestObject(apiVersion, kind, namespace, name string, labels map[string]interface{}, spec map[string]interface{}) *unstructured.Unstructured {
+	metadata := map[string]interface{}{"name": name, "namespace": namespace}
+	if labels != nil {
+		metadata["labels"] = labels
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   metadata,
+		"spec":       spec,
+	}}
+}
+
+// Test that the Git sources of releases deployed by Flux and Argo CD are looked up
+func TestAddGitSources(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newTestObject("helm.toolkit.fluxcd.io/v2", "HelmRelease", "flux-system", "podinfo",
+			map[string]interface{}{fluxKustomizationNameLabel: "apps", fluxKustomizationNamespaceLabel: "flux-system"}, map[string]interface{}{}),
+		newTestObject("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "flux-system", "apps", nil, map[string]interface{}{
+			"path":      "./clusters/production/apps",
+			"sourceRef": map[string]interface{}{"kind": "GitRepository", "name": "fleet"},
+		}),
+		newTestObject("source.toolkit.fluxcd.io/v1", "GitRepository", "flux-system", "fleet", nil, map[string]interface{}{
+			"url": "ssh://git@github.com/example/fleet.git",
+			"ref": map[string]interface{}{"branch": "main"},
+		}),
+		newTestObject("argoproj.io/v1alpha1", "Application", "argocd", "redis", nil, map[string]interface{}{
+			"source": map[string]interface{}{"repoURL": "https://git.example.com/platform.git", "path": "redis", "targetRevision": "v2"},
+		}),
+	)
+
+	flux := newTestRelease("podinfo", "apps", "podinfo", "6.0.0")
+	flux.Labels = map[string]string{fluxNameLabel: "podinfo", fluxNamespaceLabel: "flux-system"}
+	argo := newTestRelease("redis", "apps", "redis", "17.0.0")
+	argo.Labels = map[string]string{argoInstanceLabel: "redis"}
+	missing := newTestRelease("web", "apps", "nginx", "1.0.0")
+	missing.Labels = map[string]string{fluxNameLabel: "web"}
+	cli := newTestRelease("db", "apps", "postgresql", "12.0.0")
+
+	result := []ChartVersionInfo{
+		{ReleaseName: "podinfo", Namespace: "apps"},
+		{ReleaseName: "redis", Namespace: "apps"},
+		{ReleaseName: "web", Namespace: "apps"},
+		{ReleaseName: "db", Namespace: "apps"},
+	}
+	var warnings []warning
+	addGitSources(context.Background(), client, []*release.Release{flux, argo, missing, cli}, result, &warnings)
+
+	assert.Equal(t, &GitSource{
+		Controller: autoManagedByFlux,
+		Repository: "ssh://git@github.com/example/fleet.git",
+		Path:       "clusters/production/apps",
+		Revision:   "main",
+		URL:        "https://github.com/example/fleet/tree/main/clusters/production/apps",
+	}, result[0].GitSource)
+	assert.Equal(t, &GitSource{
+		Controller: autoManagedByArgoCD,
+		Repository: "https://git.example.com/platform.git",
+		Path:       "redis",
+		Revision:   "v2",
+	}, result[1].GitSource)
+	assert.Nil(t, result[2].GitSource)
+	assert.Nil(t, result[3].GitSource)
+
+	require.Len(t, warnings, 1)
+	assert.Equal(t, warnGitSourceFailed, warnings[0].Code)
+	assert.Contains(t, warnings[0].Message, "HelmRelease apps/web")
+
+	assert.Equal(t, "https://git.example.com/platform.git//redis", gitSourceColumn(result[1]))
+}
+
+// Test that GitHub and GitLab repositories are linked whatever the clone URL
+func TestGitWebURL(t *testing.T) {
+	assert.Equal(t, "https://github.com/example/fleet", gitWebURL("https://github.com/example/fleet.git"))
+	assert.Equal(t, "https://gitlab.com/group/sub/fleet", gitWebURL("git@gitlab.com:group/sub/fleet.git"))
+	assert.Equal(t, "https://github.com/example/fleet", gitWebURL("ssh://git@github.com:22/example/fleet"))
+	assert.Empty(t, gitWebURL("https://bitbucket.org/example/fleet.git"))
+	assert.Equal(t, "https://github.com/example/fleet/tree/HEAD", newGitSource(autoManagedByArgoCD, "https://github.com/example/fleet", ".", "").URL)
+}
@@ -0,0 +1,138 @@
+//go:build !helm4
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// The Helm SDK is only used through this file, so whatup can be built against
+// the Helm v4 SDK by swapping it for helm_v4.go with -tags helm4.
+type (
+	helmConfiguration = action.Configuration
+	helmSettings      = cli.EnvSettings
+	helmRelease       = release.Release
+	helmRepoFile      = repo.File
+	helmRepoEntry     = repo.Entry
+	helmIndexFile     = repo.IndexFile
+	helmChartVersions = repo.ChartVersions
+	helmChartVersion  = repo.ChartVersion
+	helmChart         = chart.Chart
+	helmListStates    = action.ListStates
+)
+
+// helmListUnknown is the state mask of an unknown release state name
+const helmListUnknown = action.ListUnknown
+
+// newHelmSettings returns the Helm settings read from the environment
+func newHelmSettings() *helmSettings {
+	return cli.New()
+}
+
+// initHelmConfiguration initializes a Helm client for the namespace
+func initHelmConfiguration(actionConfig *helmConfiguration, settings *helmSettings, namespace, driver string) error {
+	return actionConfig.Init(settings.RESTClientGetter(), namespace, driver, debug)
+}
+
+// listHelmReleases lists the latest revision of every release. A zero state
+// mask lists releases in every state.
+func listHelmReleases(actionConfig *helmConfiguration, allNamespaces bool, stateMask helmListStates) ([]*helmRelease, error) {
+	listAction := action.NewList(actionConfig)
+	listAction.All = true
+	listAction.AllNamespaces = allNamespaces
+	if stateMask != 0 {
+		listAction.StateMask = stateMask
+	} else {
+		listAction.SetStateMask() // Make sure we get all release states
+	}
+	return listAction.Run()
+}
+
+// storedHelmReleases lists every stored revision of every release
+func storedHelmReleases(actionConfig *helmConfiguration) ([]*helmRelease, error) {
+	return actionConfig.Releases.ListReleases()
+}
+
+// loadRepoFile loads the repositories file. A missing file, as on fresh
+// machines and CI images, configures no repositories.
+func loadRepoFile(path string) (*helmRepoFile, error) {
+	repoFile, err := repo.LoadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return repo.NewFile(), nil
+	}
+	return repoFile, err
+}
+
+// loadIndexFile loads a cached repository index
+func loadIndexFile(path string) (*helmIndexFile, error) {
+	return repo.LoadIndexFile(path)
+}
+
+// helmConfigPath returns a path in the Helm configuration directory
+func helmConfigPath(elem ...string) string {
+	return helmpath.ConfigPath(elem...)
+}
+
+// helmCachePath returns a path in the Helm cache directory
+func helmCachePath(elem ...string) string {
+	return helmpath.CachePath(elem...)
+}
+
+// newDeclaredRelease returns a release that only exists as a declaration, e.g.
+// in a helmfile, so it can be checked like an installed one
+func newDeclaredRelease(name, namespace, chartName, chartVersion string, annotations map[string]string) *helmRelease {
+	return &helmRelease{
+		Name:      name,
+		Namespace: namespace,
+		Chart: &chart.Chart{Metadata: &chart.Metadata{
+			Name:        chartName,
+			Version:     chartVersion,
+			Annotations: annotations,
+		}},
+	}
+}
+
+// newOCIIndexFile returns an index listing the given versions of the charts
+// of an OCI repository, as found in its tags
+func newOCIIndexFile(versions map[string][]string) *helmIndexFile {
+	idx := repo.NewIndexFile()
+	for chartName, chartVersions := range versions {
+		for _, version := range chartVersions {
+			idx.Entries[chartName] = append(idx.Entries[chartName], &repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: chartName, Version: version},
+			})
+		}
+	}
+	return idx
+}
+
+// listRegistryTags returns a function listing the chart versions tagged in an
+// OCI registry, authenticated with the credentials of helm registry login
+func listRegistryTags(settings *helmSettings) (func(ref string) ([]string, error), error) {
+	client, err := registry.NewClient(
+		registry.ClientOptCredentialsFile(settings.RegistryConfig),
+		registry.ClientOptWriter(io.Discard),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %w", err)
+	}
+	return client.Tags, nil
+}
+
+// loadChartArchive loads a packaged chart
+func loadChartArchive(data []byte) (*helmChart, error) {
+	return loader.LoadArchive(bytes.NewReader(data))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+)
+
+// flagStorageBloat marks releases that keep an excessive number of revisions
+const flagStorageBloat = "StorageBloat"
+
+// countRevisions returns the number of stored revisions per release, keyed by
+// namespace and release name. All revisions are read in a single storage
+// query per client rather than one history lookup per release.
+func countRevisions(clients []*helmConfiguration) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	for _, actionConfig := range clients {
+		stored, err := storedHelmReleases(actionConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list release revisions: %w", err)
+		}
+
+		for _, rel := range stored {
+			counts[rel.Namespace+"/"+rel.Name]++
+		}
+	}
+
+	return counts, nil
+}
+
+// checkRevisionHistory records the number of stored revisions of every result
+// and flags releases exceeding maxRevisions. Clusters with a lot of release
+// history tend to be the stale ones, and the stored secrets slow down Helm.
+func checkRevisionHistory(counts map[string]int, maxRevisions int, result []ChartVersionInfo) {
+	for i := range result {
+		result[i].Revisions = counts[findingKey(result[i])]
+		if maxRevisions > 0 && result[i].Revisions > maxRevisions {
+			result[i].Flags = append(result[i].Flags, flagStorageBloat)
+		}
+	}
+}
+
+// historyHint returns the cleanup hint shown for releases with excessive history
+func historyHint(versionInfo ChartVersionInfo) string {
+	return fmt.Sprintf("Release %s keeps %d revisions in storage. Review them with `helm history %s -n %s`"+
+		" and limit future history with `helm upgrade --history-max 10`.",
+		versionInfo.ReleaseName,
+		versionInfo.Revisions,
+		versionInfo.ReleaseName,
+		versionInfo.Namespace)
+}
+
+// hasFlag reports whether the result carries the given flag
+func hasFlag(versionInfo ChartVersionInfo, flag string) bool {
+	for _, f := range versionInfo.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that releases with too many stored revisions are flagged
+func TestCheckRevisionHistory(t *testing.T) {
+	counts := map[string]int{"apps/api": 75, "apps/web": 3}
+	result := []ChartVersionInfo{
+		{ReleaseName: "api", Namespace: "apps"},
+		{ReleaseName: "web", Namespace: "apps"},
+	}
+
+	checkRevisionHistory(counts, 50, result)
+
+	assert.Equal(t, 75, result[0].Revisions)
+	assert.True(t, hasFlag(result[0], flagStorageBloat))
+	assert.Contains(t, historyHint(result[0]), "helm history api -n apps")
+	assert.Equal(t, 3, result[1].Revisions)
+	assert.False(t, hasFlag(result[1], flagStorageBloat))
+}
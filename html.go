@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// outputFormatHTML renders the results as a standalone HTML page
+const outputFormatHTML = "html"
+
+// htmlSummary counts the results of every status for the summary of the page
+type htmlSummary struct {
+	Status string
+	Count  int
+}
+
+// htmlReport is the data of the HTML page
+type htmlReport struct {
+	GeneratedAt time.Time
+	Version     string
+	Summary     []htmlSummary
+	Results     []ChartVersionInfo
+	Warnings    []warning
+}
+
+// htmlStatusOrder is the order of the statuses in the summary of the page
+var htmlStatusOrder = []string{statusVulnerable, statusOutdated, statusUnknown, statusUptodate, statusIgnored}
+
+// htmlTemplate is a page without external resources, so it can be attached to
+// an email or archived. Clicking a column header sorts the table by it.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"severityRank": severityRank,
+	"status":       func(name string) string { return cfg.Vocabulary.status(name) },
+	"severity":     func(name string) string { return cfg.Vocabulary.severity(name) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>helm-whatup report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+h1 { font-size: 1.5em; }
+.meta { color: #57606a; }
+.summary { display: flex; gap: 1em; margin: 1.5em 0; }
+.summary div { border: 1px solid #d0d7de; border-radius: 6px; padding: 0.5em 1em; }
+.summary strong { display: block; font-size: 1.5em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #d0d7de; padding: 0.4em 0.6em; text-align: left; vertical-align: middle; }
+th { cursor: pointer; background: #f6f8fa; user-select: none; }
+th::after { content: " \2195"; color: #8c959f; }
+td img { width: 1.2em; height: 1.2em; vertical-align: middle; margin-right: 0.4em; }
+.status-OUTDATED { color: #9a6700; }
+.status-VULNERABLE { color: #cf222e; font-weight: bold; }
+.status-UNKNOWN, .status-IGNORED { color: #57606a; }
+.status-UPTODATE { color: #1a7f37; }
+.severity { border-radius: 1em; padding: 0.1em 0.6em; font-size: 0.85em; }
+.severity-MAJOR { background: #ffebe9; color: #cf222e; }
+.severity-MINOR { background: #fff8c5; color: #9a6700; }
+.severity-PATCH { background: #dafbe1; color: #1a7f37; }
+.severity-TRIVIAL { background: #f6f8fa; color: #57606a; }
+.warnings { margin-top: 2em; color: #57606a; }
+</style>
+</head>
+<body>
+<h1>helm-whatup report</h1>
+<p class="meta">Generated {{ .GeneratedAt.Format "2006-01-02 15:04 MST" }} by helm-whatup {{ .Version }}</p>
+<div class="summary">
+{{- range .Summary }}
+<div class="status-{{ .Status }}"><strong>{{ .Count }}</strong>{{ status .Status }}</div>
+{{- end }}
+</div>
+<table id="results">
+<thead>
+<tr><th>Name</th><th>Namespace</th><th>Chart</th><th>Category</th><th>Installed</th><th>Latest</th><th>Severity</th><th>Status</th><th>Repository</th><th>Release notes</th></tr>
+</thead>
+<tbody>
+{{- range .Results }}
+<tr>
+<td>{{ if .Cluster }}{{ .Cluster }}/{{ end }}{{ .ReleaseName }}</td>
+<td>{{ .Namespace }}</td>
+<td>{{ if .ChartIcon }}<img src="{{ .ChartIcon }}" alt="">{{ end }}{{ .ChartName }}</td>
+<td>{{ .Category }}</td>
+<td>{{ .InstalledVersion }}</td>
+<td>{{ .LatestVersion }}</td>
+<td data-sort="{{ severityRank .UpdateSeverity }}">{{ if .UpdateSeverity }}<span class="severity severity-{{ .UpdateSeverity }}">{{ severity .UpdateSeverity }}</span>{{ end }}</td>
+<td class="status-{{ .Status }}">{{ status .Status }}</td>
+<td>{{ .RepoName }}</td>
+<td>{{ if .ReleaseNotesURL }}<a href="{{ .ReleaseNotesURL }}">{{ .LatestVersion }}</a>{{ end }}</td>
+</tr>
+{{- end }}
+</tbody>
+</table>
+{{- if .Warnings }}
+<div class="warnings">
+<h2>Warnings</h2>
+<ul>
+{{- range .Warnings }}
+<li>{{ .String }}</li>
+{{- end }}
+</ul>
+</div>
+{{- end }}
+<script>
+document.querySelectorAll("#results th").forEach(function (th, column) {
+  var ascending = true;
+  th.addEventListener("click", function () {
+    var tbody = document.querySelector("#results tbody");
+    var rows = Array.prototype.slice.call(tbody.rows);
+    var key = function (row) {
+      var cell = row.cells[column];
+      return cell.dataset.sort !== undefined ? Number(cell.dataset.sort) : cell.textContent.trim().toLowerCase();
+    };
+    rows.sort(function (a, b) {
+      var x = key(a), y = key(b);
+      return (x < y ? -1 : x > y ? 1 : 0) * (ascending ? 1 : -1);
+    });
+    ascending = !ascending;
+    rows.forEach(function (row) { tbody.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// formatHTML writes a standalone HTML page with the summary counts of the
+// statuses and a sortable table of all results
+func formatHTML(w io.Writer, report scanReport, now time.Time) error {
+	envelope := newReportEnvelope(report, now)
+
+	counts := make(map[string]int)
+	for _, versionInfo := range report.results {
+		counts[versionInfo.Status]++
+	}
+	var summary []htmlSummary
+	for _, status := range htmlStatusOrder {
+		if counts[status] > 0 {
+			summary = append(summary, htmlSummary{Status: status, Count: counts[status]})
+		}
+	}
+
+	page := htmlReport{
+		GeneratedAt: envelope.GeneratedAt,
+		Version:     envelope.Version,
+		Summary:     summary,
+		Results:     report.results,
+		Warnings:    report.warnings,
+	}
+	if err := htmlTemplate.Execute(w, page); err != nil {
+		return fmt.Errorf("failed to render HTML: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the HTML report counts the statuses and escapes the results
+func TestFormatHTML(t *testing.T) {
+	report := scanReport{
+		results: []ChartVersionInfo{
+			{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "2.0.0",
+				UpdateSeverity: "MAJOR", Status: statusOutdated, ChartIcon: "https://example.com/nginx.svg",
+				ReleaseNotesURL: "https://github.com/example/nginx/releases/tag/v2.0.0"},
+			{ReleaseName: "db", Namespace: "prod", ChartName: "postgresql", InstalledVersion: "12.0.0", LatestVersion: "12.0.0", Status: statusUptodate},
+			{ReleaseName: "<script>", Namespace: "dev", ChartName: "redis", Status: statusUptodate, ChartIcon: "javascript:alert(1)"},
+		},
+		warnings: []warning{{Code: warnStaleIndex, Name: "StaleIndex", Message: "The index of repository 'bitnami' is old"}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, formatHTML(&out, report, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)))
+	page := out.String()
+
+	assert.Contains(t, page, "Generated 2024-03-01 12:00 UTC")
+	assert.Contains(t, page, `<div class="status-OUTDATED"><strong>1</strong>OUTDATED</div>`)
+	assert.Contains(t, page, `<div class="status-UPTODATE"><strong>2</strong>UPTODATE</div>`)
+	assert.NotContains(t, page, "<strong>0</strong>")
+	assert.Contains(t, page, `<span class="severity severity-MAJOR">MAJOR</span>`)
+	assert.Contains(t, page, `<img src="https://example.com/nginx.svg" alt="">nginx`)
+	assert.Contains(t, page, `<a href="https://github.com/example/nginx/releases/tag/v2.0.0">2.0.0</a>`)
+	assert.Contains(t, page, "[W002 StaleIndex] The index of repository &#39;bitnami&#39; is old")
+
+	// Values from the cluster can't inject markup or scripts
+	assert.Contains(t, page, "&lt;script&gt;")
+	assert.NotContains(t, page, "javascript:alert")
+}
@@ -0,0 +1,39 @@
+package main
+
+import "strings"
+
+// Infrastructure-as-code tools that install releases through the Helm SDK
+const (
+	createdByTerraform = "terraform"
+	createdByPulumi    = "pulumi"
+)
+
+// iacLabels are the release storage labels checked for the tool that created a
+// release. The Terraform Helm provider and Pulumi record themselves as the
+// manager of the releases they create, e.g. managed-by=terraform.
+var iacLabels = []string{"app.kubernetes.io/managed-by", "managed-by", "created-by"}
+
+// releaseCreatedBy returns the infrastructure-as-code tool that created the
+// release, or an empty string for releases installed with the Helm CLI
+func releaseCreatedBy(rel *helmRelease) string {
+	for _, label := range iacLabels {
+		value := strings.ToLower(rel.Labels[label])
+		for _, tool := range []string{createdByTerraform, createdByPulumi} {
+			if strings.Contains(value, tool) {
+				return tool
+			}
+		}
+	}
+	return ""
+}
+
+// filterIaCReleases drops the releases created by infrastructure-as-code tools
+func filterIaCReleases(releases []*helmRelease) []*helmRelease {
+	var filtered []*helmRelease
+	for _, rel := range releases {
+		if releaseCreatedBy(rel) == "" {
+			filtered = append(filtered, rel)
+		}
+	}
+	return filtered
+}
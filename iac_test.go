@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Test that releases created by Terraform or Pulumi are recognized by their storage labels
+func TestReleaseCreatedBy(t *testing.T) {
+	terraform := newTestRelease("vpc-ingress", "default", "ingress-nginx", "4.10.0")
+	terraform.Labels = map[string]string{"managed-by": "Terraform"}
+	pulumi := newTestRelease("db", "default", "postgresql", "12.0.0")
+	pulumi.Labels = map[string]string{"app.kubernetes.io/managed-by": "pulumi"}
+	cli := newTestRelease("web", "default", "nginx", "1.0.0")
+
+	assert.Equal(t, createdByTerraform, releaseCreatedBy(terraform))
+	assert.Equal(t, createdByPulumi, releaseCreatedBy(pulumi))
+	assert.Empty(t, releaseCreatedBy(cli))
+
+	filtered := filterIaCReleases([]*release.Release{terraform, pulumi, cli})
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "web", filtered[0].Name)
+}
+
+// Test that releases managed by an infrastructure-as-code tool are not upgraded
+func TestUpgradeCandidatesIaC(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "default", RepoName: "bitnami", RepoConfidence: confidenceExact, Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "1.1.0"},
+		{ReleaseName: "db", Namespace: "default", Status: statusOutdated, CreatedBy: createdByTerraform},
+	}
+
+	candidates, err := upgradeCandidates(result, nil)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "web", candidates[0].ReleaseName)
+
+	_, err = upgradeCandidates(result, []string{"db"})
+	assert.ErrorContains(t, err, "managed by terraform")
+}
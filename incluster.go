@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// serviceAccountDir is where Kubernetes mounts the service account token of a pod
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// checkInCluster verifies that --in-cluster can be honored
+func checkInCluster() error {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" || os.Getenv("KUBERNETES_SERVICE_PORT") == "" {
+		return fmt.Errorf("--in-cluster requires running in a pod: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+	if _, err := os.Stat(filepath.Join(serviceAccountDir, "token")); err != nil {
+		return fmt.Errorf("--in-cluster requires a mounted service account token: %w", err)
+	}
+	return nil
+}
+
+// applyInClusterConfig points the settings at the API server of the cluster
+// the pod runs in, authenticating with the service account of the pod
+func applyInClusterConfig(settings *helmSettings) {
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		// Reported by checkInCluster
+		return
+	}
+	settings.KubeAPIServer = "https://" + net.JoinHostPort(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"))
+	settings.KubeToken = strings.TrimSpace(string(token))
+	settings.KubeCaFile = filepath.Join(serviceAccountDir, "ca.crt")
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that --in-cluster connects with the mounted service account
+func TestInClusterConfig(t *testing.T) {
+	dir := t.TempDir()
+	previous := serviceAccountDir
+	serviceAccountDir = dir
+	defer func() { serviceAccountDir, inCluster = previous, false }()
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	assert.Error(t, checkInCluster())
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "443")
+	assert.Error(t, checkInCluster())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("secret\n"), 0o600))
+	require.NoError(t, checkInCluster())
+
+	inCluster = true
+	settings := newSettings()
+	assert.Equal(t, "https://10.0.0.1:443", settings.KubeAPIServer)
+	assert.Equal(t, "secret", settings.KubeToken)
+	assert.Equal(t, filepath.Join(dir, "ca.crt"), settings.KubeCaFile)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// jsonPathPrefix starts the -o jsonpath=TEMPLATE output format
+const jsonPathPrefix = "jsonpath="
+
+// isJSONPath reports whether the output format is jsonpath
+func isJSONPath(format string) bool {
+	return strings.HasPrefix(format, jsonPathPrefix)
+}
+
+// parseJSONPathTemplate parses the template of a jsonpath output format with
+// the semantics of kubectl, e.g. {..latestVersion} or
+// {range .results[*]}{.releaseName}{"\n"}{end}
+func parseJSONPathTemplate(format string) (*jsonpath.JSONPath, error) {
+	template := strings.TrimPrefix(format, jsonPathPrefix)
+	if template == "" {
+		return nil, fmt.Errorf("jsonpath requires a template, e.g. jsonpath='{..latestVersion}'")
+	}
+	parser := jsonpath.New("output")
+	parser.AllowMissingKeys(true)
+	if err := parser.Parse(template); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath template %q: %w", template, err)
+	}
+	return parser, nil
+}
+
+// formatJSONPath executes the template against the report as written by -o json
+func formatJSONPath(w io.Writer, format string, report scanReport) error {
+	parser, err := parseJSONPathTemplate(format)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(newReportEnvelope(report, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	var object interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+
+	if err := parser.Execute(w, object); err != nil {
+		return fmt.Errorf("failed to execute jsonpath template: %w", err)
+	}
+	return nil
+}
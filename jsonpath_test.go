@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that jsonpath templates are executed against the JSON report like kubectl does
+func TestFormatJSONPath(t *testing.T) {
+	report := scanReport{results: []ChartVersionInfo{
+		{ReleaseName: "web", LatestVersion: "1.1.0", Status: statusOutdated},
+		{ReleaseName: "db", LatestVersion: "13.0.0", Status: statusUptodate},
+	}}
+
+	var out bytes.Buffer
+	require.NoError(t, formatJSONPath(&out, "jsonpath={..latestVersion}", report))
+	assert.Equal(t, "1.1.0 13.0.0", out.String())
+
+	out.Reset()
+	require.NoError(t, formatJSONPath(&out, `jsonpath={range .results[?(@.status=="OUTDATED")]}{.releaseName}{"\n"}{end}`, report))
+	assert.Equal(t, "web\n", out.String())
+
+	out.Reset()
+	require.NoError(t, formatJSONPath(&out, "jsonpath={.results[0].owner}", report))
+	assert.Empty(t, out.String())
+
+	assert.Error(t, formatJSONPath(&out, "jsonpath=", report))
+	assert.Error(t, formatJSONPath(&out, "jsonpath={.results[", report))
+}
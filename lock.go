@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Timing of the run lock
+const (
+	lockRetryInterval = time.Second
+	leaseDuration     = 10 * time.Minute
+)
+
+// errLocked is returned when the lock is held by another run
+var errLocked = errors.New("another run holds the lock")
+
+// runLock prevents overlapping runs
+type runLock interface {
+	// tryLock acquires the lock without waiting and returns errLocked when it is held by another run
+	tryLock(ctx context.Context) error
+	unlock(ctx context.Context) error
+}
+
+// lockHolder identifies this run as the holder of a lock
+func lockHolder() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// newRunLock creates the lock requested by --lock-file or --lock-lease, or nil if none was requested
+func newRunLock() (runLock, error) {
+	switch {
+	case lockFile != "" && lockLease != "":
+		return nil, fmt.Errorf("--lock-file and --lock-lease are mutually exclusive")
+	case lockFile != "":
+		return &fileLock{path: lockFile}, nil
+	case lockLease != "":
+		namespace, name, ok := strings.Cut(lockLease, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid lease %q, expected NAMESPACE/NAME", lockLease)
+		}
+		clientset, err := newKubeClientset(newSettings())
+		if err != nil {
+			return nil, err
+		}
+		return &leaseLock{clientset: clientset, namespace: namespace, name: name, holder: lockHolder()}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// acquireRunLock takes the lock, retrying until wait has elapsed. When the lock
+// is still held, an error with exit code exitCodeLocked is returned. The
+// returned function releases the lock.
+func acquireRunLock(ctx context.Context, lock runLock, wait time.Duration) (func(), error) {
+	if lock == nil {
+		return func() {}, nil
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		err := lock.tryLock(ctx)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) {
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			return nil, &exitError{code: exitCodeLocked, err: err}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+
+	return func() {
+		if err := lock.unlock(context.Background()); err != nil {
+			logger.Warn("failed to release lock", "error", err)
+		}
+	}, nil
+}
+
+// fileLock is an advisory lock on a local file, released when the process exits
+type fileLock struct {
+	path string
+	file *os.File
+}
+
+// leaseLock is a coordination.k8s.io Lease held in the cluster. The lease is
+// renewed while the run is in progress and expires if the run dies.
+type leaseLock struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+	holder    string
+	stop      chan struct{}
+}
+
+func (l *leaseLock) tryLock(ctx context.Context) error {
+	leases := l.clientset.CoordinationV1().Leases(l.namespace)
+	now := metav1.NewMicroTime(time.Now())
+	duration := int32(leaseDuration.Seconds())
+
+	lease, err := leases.Get(ctx, l.name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: l.name, Namespace: l.namespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &l.holder,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if _, err := leases.Create(ctx, lease, metav1.CreateOptions{}); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return errLocked
+			}
+			return fmt.Errorf("failed to create lease: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get lease: %w", err)
+	default:
+		if leaseHeld(lease, now.Time) {
+			return fmt.Errorf("%w: lease %s/%s is held by %s", errLocked, l.namespace, l.name, *lease.Spec.HolderIdentity)
+		}
+		lease.Spec.HolderIdentity = &l.holder
+		lease.Spec.LeaseDurationSeconds = &duration
+		lease.Spec.AcquireTime = &now
+		lease.Spec.RenewTime = &now
+		// The update fails on a conflict if another run took over the lease in the meantime
+		if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+			if apierrors.IsConflict(err) {
+				return errLocked
+			}
+			return fmt.Errorf("failed to update lease: %w", err)
+		}
+	}
+
+	stop := make(chan struct{})
+	l.stop = stop
+	go l.renew(stop)
+	return nil
+}
+
+// renew keeps the lease alive until stop is closed. It gives up when the
+// lease was taken over by another run, e.g. after this one was paused for
+// longer than the lease duration.
+func (l *leaseLock) renew(stop <-chan struct{}) {
+	ticker := time.NewTicker(leaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := l.renewLease(context.Background())
+			if errors.Is(err, errLeaseLost) {
+				logger.Warn("stopped renewing the lock", "lease", l.namespace+"/"+l.name, "error", err)
+				return
+			}
+			if err != nil {
+				logger.Warn("failed to renew the lock", "lease", l.namespace+"/"+l.name, "error", err)
+			}
+		}
+	}
+}
+
+// errLeaseLost is returned when the lease is held by another run
+var errLeaseLost = errors.New("the lease was taken over by another run")
+
+// renewLease updates the renew time of the lease, or returns errLeaseLost
+// when another run holds it
+func (l *leaseLock) renewLease(ctx context.Context) error {
+	leases := l.clientset.CoordinationV1().Leases(l.namespace)
+	lease, err := leases.Get(ctx, l.name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get lease: %w", err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != l.holder {
+		return errLeaseLost
+	}
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.RenewTime = &now
+	// The update fails on a conflict if another run took over the lease since it was read
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		if apierrors.IsConflict(err) {
+			return errLeaseLost
+		}
+		return fmt.Errorf("failed to renew lease: %w", err)
+	}
+	return nil
+}
+
+func (l *leaseLock) unlock(ctx context.Context) error {
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+
+	leases := l.clientset.CoordinationV1().Leases(l.namespace)
+	lease, err := leases.Get(ctx, l.name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get lease: %w", err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != l.holder {
+		return nil
+	}
+
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.RenewTime = nil
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// leaseHeld reports whether the lease has a holder that renewed it recently enough
+func leaseHeld(lease *coordinationv1.Lease, now time.Time) bool {
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || *spec.HolderIdentity == "" || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return false
+	}
+	return now.Before(spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second))
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+func (l *fileLock) tryLock(_ context.Context) error {
+	return errors.New("--lock-file is not supported on this platform, use --lock-lease instead")
+}
+
+func (l *fileLock) unlock(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Test that a Lease only lets one run hold the lock until it is released or expires
+func TestLeaseLock(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+
+	first := &leaseLock{clientset: clientset, namespace: "whatup", name: "scan", holder: "first"}
+	second := &leaseLock{clientset: clientset, namespace: "whatup", name: "scan", holder: "second"}
+
+	require.NoError(t, first.tryLock(ctx))
+	assert.ErrorIs(t, second.tryLock(ctx), errLocked)
+
+	unlock, err := acquireRunLock(ctx, second, 0)
+	assert.Nil(t, unlock)
+	assert.Equal(t, exitCodeLocked, exitCode(err))
+
+	require.NoError(t, first.unlock(ctx))
+	require.NoError(t, second.tryLock(ctx))
+
+	// An expired lease can be taken over
+	lease, err := clientset.CoordinationV1().Leases("whatup").Get(ctx, "scan", metav1.GetOptions{})
+	require.NoError(t, err)
+	expired := metav1.NewMicroTime(time.Now().Add(-2 * leaseDuration))
+	lease.Spec.RenewTime = &expired
+	_, err = clientset.CoordinationV1().Leases("whatup").Update(ctx, lease, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	assert.NoError(t, first.tryLock(ctx))
+
+	// The run whose lease was taken over stops renewing it
+	assert.ErrorIs(t, second.renewLease(ctx), errLeaseLost)
+	assert.NoError(t, first.renewLease(ctx))
+
+	require.NoError(t, first.unlock(ctx))
+	close(second.stop)
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that a lock file can only be held by one run at a time
+func TestFileLock(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "whatup.lock")
+
+	first := &fileLock{path: path}
+	second := &fileLock{path: path}
+
+	require.NoError(t, first.tryLock(ctx))
+	assert.ErrorIs(t, second.tryLock(ctx), errLocked)
+
+	require.NoError(t, first.unlock(ctx))
+	require.NoError(t, second.tryLock(ctx))
+	assert.NoError(t, second.unlock(ctx))
+}
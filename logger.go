@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Log formats accepted by --log-format
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// logLevels maps the levels accepted by --log-level to slog levels
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// logger writes diagnostics to stderr so they never mix with the report on
+// stdout. It is configured with --log-level and --log-format.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+// newLogger returns a logger writing records of at least level to w, as
+// logfmt-style text without timestamps or as JSON
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	lvl, ok := logLevels[strings.ToLower(level)]
+	if !ok {
+		return nil, fmt.Errorf("invalid --log-level %q, expected one of debug, info, warn, error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case logFormatText:
+		// Timestamps are noise when reading the output of a single run
+		opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		}
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case logFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid --log-format %q, expected text or json", format)
+	}
+}
+
+// setupLogging configures the logger from --log-level and --log-format.
+// HELM_DEBUG enables debug logs and --quiet only logs errors unless a level
+// was given. The logger also becomes the default one, which the Helm v4 SDK
+// logs to.
+func setupLogging() error {
+	level := logLevel
+	if level == "" {
+		switch {
+		case os.Getenv("HELM_DEBUG") != "":
+			level = "debug"
+		case quiet:
+			level = "error"
+		default:
+			level = "warn"
+		}
+	}
+
+	l, err := newLogger(os.Stderr, level, logFormat)
+	if err != nil {
+		return err
+	}
+	logger = l
+	slog.SetDefault(l)
+	return nil
+}
+
+// debug logs a printf-style debug message, e.g. of the Helm v3 SDK
+func debug(format string, v ...interface{}) {
+	logger.Debug(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+// logWarnings logs the warnings of a scan with their code and name
+func logWarnings(warnings []warning) {
+	for _, w := range warnings {
+		logger.Warn(w.Message, "code", string(w.Code), "name", w.Name)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the logger filters by level and logs text without timestamps or JSON
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := newLogger(&buf, "info", logFormatText)
+	require.NoError(t, err)
+	l.Debug("hidden")
+	l.Info("serving metrics", "address", ":9571")
+	assert.Equal(t, "level=INFO msg=\"serving metrics\" address=:9571\n", buf.String())
+
+	buf.Reset()
+	l, err = newLogger(&buf, "WARN", logFormatJSON)
+	require.NoError(t, err)
+	l.Info("hidden")
+	l.Warn("failed to release lock", "error", "boom")
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "failed to release lock", record["msg"])
+	assert.Equal(t, "boom", record["error"])
+	assert.Contains(t, record, "time")
+
+	_, err = newLogger(&buf, "verbose", logFormatText)
+	assert.EqualError(t, err, `invalid --log-level "verbose", expected one of debug, info, warn, error`)
+	_, err = newLogger(&buf, "warn", "xml")
+	assert.EqualError(t, err, `invalid --log-format "xml", expected text or json`)
+}
+
+// Test that scan warnings are logged with their code and name
+func TestLogWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := newLogger(&buf, "warn", logFormatJSON)
+	require.NoError(t, err)
+	previous := logger
+	logger = l
+	defer func() { logger = previous }()
+
+	var warnings []warning
+	addWarning(&warnings, warnRepoUnresolved, "The source repository could not be determined for '%s'", "web")
+	logWarnings(warnings)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "The source repository could not be determined for 'web'", record["msg"])
+	assert.Equal(t, "W001", record["code"])
+	assert.Equal(t, "RepoUnresolved", record["name"])
+}
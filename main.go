@@ -3,20 +3,22 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/gosuri/uitable"
+	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"gopkg.in/yaml.v2"
 
-	"helm.sh/helm/v3/pkg/action"
-	"helm.sh/helm/v3/pkg/cli"
-	"helm.sh/helm/v3/pkg/release"
-	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/client-go/kubernetes"
 )
 
 // Output format options
@@ -27,12 +29,14 @@ const (
 	outputFormatYAML  = "yaml"
 	outputFormatYML   = "yml"
 	outputFormatTable = "table"
+	outputFormatWide  = "wide"
 )
 
 // Status constants for chart versions
 const (
 	statusOutdated = "OUTDATED"
 	statusUptodate = "UPTODATE"
+	statusUnknown  = "UNKNOWN"
 )
 
 // Constants for URL parsing
@@ -50,20 +54,130 @@ var (
 	tlsCert      string
 	tlsKey       string
 	tlsVerify    bool
+	auditLog     string
+	changeLog    string
+	logLevel     string
+	logFormat    string
+	statsdAddr   string
+	telemetry    bool
+	emitEvents   bool
+	configFile   string
+	notify       bool
+	digest       string
+
+	ownerAnnotation string
+	collapseCanary  bool
+	checkHistory    bool
+	maxRevisions    int
+
+	storageNamespaces  []string
+	namespaces         []string
+	excludeNamespaces  []string
+	releaseFilterExpr  string
+	excludeIaC         bool
+	excludeAutoManaged bool
+	chartFilterExpr    string
+	severityHook       string
+	kubeConfig         string
+	kubeContext        string
+	kubeContexts       []string
+	kubeTimeout        time.Duration
+	repoTimeout        time.Duration
+	allContexts        bool
+	inCluster          bool
+	releaseStates      []string
+	correlateWorkloads bool
+	gitSources         bool
+	renovateFile       string
+	useArtifactHub     bool
+	probeRepos         bool
+	fixRepos           bool
+	maxReleaseAge      int
+	minVersionAge      int
+	showAvailable      bool
+	appVersionOnly     bool
+	updateRepos        bool
+	catalogPath        string
+	abandonedAfter     int
+	lockFile           string
+	lockLease          string
+	lockWait           time.Duration
+	dryRun             bool
+	strictRepo         bool
+	minRepoConfidence  string
+	minSeverity        string
+	failOnOutdated     bool
+	githubActions      bool
+	suppressWarnings   []string
+	compatMode         string
+	advisoryFeedFlags  []string
+	valuesSummary      bool
+	trivialUpdates     bool
+	pinFilePath        string
+	policyFilePath     string
+	ignoreReleases     []string
+	rowSeparators      bool
+	noPager            bool
+	noColor            bool
+	noHeaders          bool
+	quiet              bool
+	outputFile         string
+	signReports        bool
+	signKey            string
 )
 
+// defaultMaxRevisions is the default threshold for --max-revisions
+const defaultMaxRevisions = 50
+
 var version = "canary"
 
 // ChartVersionInfo stores information about a chart's version status
 // including the installed version and the latest available version.
 type ChartVersionInfo struct {
-	ReleaseName      string `json:"releaseName"`
-	Namespace        string `json:"namespace"`
-	ChartName        string `json:"chartName"`
-	InstalledVersion string `json:"installedVersion"`
-	LatestVersion    string `json:"latestVersion"`
-	RepoName         string `json:"repoName"`
-	Status           string `json:"status"`
+	ReleaseName      string           `json:"releaseName"`
+	Namespace        string           `json:"namespace"`
+	ChartName        string           `json:"chartName"`
+	InstalledVersion string           `json:"installedVersion"`
+	LatestVersion    string           `json:"latestVersion"`
+	RepoName         string           `json:"repoName"`
+	Status           string           `json:"status"`
+	Owner            string           `json:"owner,omitempty"`
+	Variants         []string         `json:"variants,omitempty"`
+	Revision         int              `json:"revision,omitempty"`
+	Revisions        int              `json:"revisions,omitempty"`
+	Flags            []string         `json:"flags,omitempty"`
+	Workload         *WorkloadStatus  `json:"workload,omitempty"`
+	Priority         string           `json:"priority,omitempty"`
+	Severity         string           `json:"severity,omitempty"`
+	DependsOn        []string         `json:"dependsOn,omitempty"`
+	UpgradeOrder     int              `json:"upgradeOrder,omitempty"`
+	ArtifactHub      *ArtifactHubInfo `json:"artifactHub,omitempty"`
+
+	SuggestedRepository *wellKnownRepository `json:"suggestedRepository,omitempty"`
+	Cluster             string               `json:"cluster,omitempty"`
+	ClusterLabels       map[string]string    `json:"clusterLabels,omitempty"`
+	LastDeployed        *time.Time           `json:"lastDeployed,omitempty"`
+	LatestReleaseDate   *time.Time           `json:"latestReleaseDate,omitempty"`
+	IndexGenerated      *time.Time           `json:"indexGenerated,omitempty"`
+	Advisories          []Advisory           `json:"advisories,omitempty"`
+	Values              *ValuesInfo          `json:"values,omitempty"`
+	RepoConfidence      string               `json:"repoConfidence,omitempty"`
+	UpdateSeverity      string               `json:"updateSeverity,omitempty"`
+	CreatedBy           string               `json:"createdBy,omitempty"`
+	VersionsBehind      int                  `json:"versionsBehind,omitempty"`
+	AvailableVersions   []AvailableVersion   `json:"availableVersions,omitempty"`
+	InstalledAppVersion string               `json:"installedAppVersion,omitempty"`
+	LatestAppVersion    string               `json:"latestAppVersion,omitempty"`
+	ReleaseStatus       string               `json:"releaseStatus,omitempty"`
+	UpstreamVersion     string               `json:"upstreamVersion,omitempty"`
+	ReleaseNotesURL     string               `json:"releaseNotesURL,omitempty"`
+	ChartIcon           string               `json:"chartIcon,omitempty"`
+	Category            string               `json:"category,omitempty"`
+	Source              string               `json:"source,omitempty"`
+	VersionConstraint   string               `json:"versionConstraint,omitempty"`
+	RepoURL             string               `json:"repoURL,omitempty"`
+	Deprecated          bool                 `json:"deprecated,omitempty"`
+	GitSource           *GitSource           `json:"gitSource,omitempty"`
 }
 
 func main() {
@@ -71,120 +185,500 @@ func main() {
 		Use:   "whatup [flags]",
 		Short: fmt.Sprintf("check if installed charts are out of date (helm-whatup %s)", version),
 		RunE:  run,
+
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := setupLogging(); err != nil {
+				return err
+			}
+			return loadConfigFile(cmd, args)
+		},
 	}
 
+	cmd.PersistentFlags().StringVar(&kubeConfig, "kubeconfig", "", "path to the kubeconfig file")
+	cmd.PersistentFlags().StringVar(&kubeContext, "kube-context", "", "name of the kubeconfig context to use")
+	cmd.PersistentFlags().DurationVar(&kubeTimeout, "kube-timeout", 0, "timeout of every request to the Kubernetes API, e.g. 2m for slow proxied clusters. 0 means no timeout")
+	cmd.PersistentFlags().BoolVar(&inCluster, "in-cluster", false, "connect with the service account of the pod whatup runs in, e.g. as a CronJob. Used automatically when there is no kubeconfig")
+	cmd.PersistentFlags().StringVar(&configFile, "config", defaultConfigFile(), "path to the whatup configuration file")
+	cmd.PersistentFlags().StringSliceVar(&suppressWarnings, "suppress-warnings", nil, "hide warnings with these codes or names (comma-separated), e.g. W001 or RepoUnresolved")
+	cmd.PersistentFlags().StringVar(&compatMode, "compat", "", "compatibility mode. Use \"legacy\" to reproduce the output, exit codes and scan of the original plugin: all namespaces are scanned regardless of HELM_NAMESPACE, the latest version is the first one of the index including pre-releases, any other version is outdated and a missing repositories file is an error")
+	cmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "minimum level of the logs written to stderr: debug, info, warn or error. Defaults to warn, or debug when HELM_DEBUG is set")
+	cmd.PersistentFlags().StringVar(&logFormat, "log-format", logFormatText, "format of the logs written to stderr: text or json")
+	cmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "do not color the table and plain output, also disabled by the NO_COLOR environment variable or when stdout is not a terminal")
+	cmd.PersistentFlags().StringVar(&auditLog, "audit-log", "", "append a JSON record of each scan and of the upgrades of the upgrade subcommand to this file (for compliance evidence)")
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "print the commands, HTTP calls and files that would be written instead of performing them")
+	cmd.PersistentFlags().StringVar(&lockFile, "lock-file", "", "hold a lock on this file during the scan so overlapping runs (e.g. from cron) are prevented")
+	cmd.PersistentFlags().StringVar(&lockLease, "lock-lease", "", "hold this Lease (NAMESPACE/NAME) in the cluster during the scan so overlapping runs are prevented")
+	cmd.PersistentFlags().DurationVar(&lockWait, "lock-wait", 0, "how long to wait for the lock held by another run before exiting with code 75")
+	cmd.PersistentFlags().BoolVar(&signReports, "sign", false, "sign the report written with --output-file keyless with cosign, writing the signature bundle next to it")
+	cmd.PersistentFlags().StringVar(&signKey, "sign-key", "", "sign the report written with --output-file with this cosign key instead of keyless")
+
+	// Flags scoping the scan also apply to the subcommands scanning the
+	// cluster, e.g. serve, upgrade and check
+	cmd.PersistentFlags().BoolVarP(&devel, "devel", "d", false, "include pre-release versions such as 1.2.0-rc.1 when looking for the latest version")
+	cmd.PersistentFlags().StringSliceVar(&releaseStates, "states", nil, "only check releases in these states (comma-separated): deployed, failed, pending-install, pending-upgrade, pending-rollback, uninstalling, uninstalled, superseded. Defaults to all states")
+	cmd.PersistentFlags().BoolVar(&updateRepos, "update-repos", false, "run `helm repo update` before the scan for the repositories hosting installed charts, retrying the ones that fail")
+	cmd.PersistentFlags().DurationVar(&repoTimeout, "repo-timeout", 0, "timeout of each request to a chart repository, including every `helm repo update` of --update-repos. 0 keeps the built-in defaults")
+	cmd.PersistentFlags().IntVar(&minVersionAge, "min-version-age", 0, "skip candidate versions published less than this number of days ago according to the created timestamp of the repository index")
+	cmd.PersistentFlags().StringSliceVarP(&namespaces, "namespaces", "n", nil, "only check releases in these namespaces (comma-separated or repeated). Defaults to the namespace helm was invoked with")
+	cmd.PersistentFlags().StringSliceVar(&excludeNamespaces, "exclude-namespaces", nil, "skip releases in namespaces matching these names or regular expressions (comma-separated or repeated)")
+	cmd.PersistentFlags().BoolVar(&excludeAutoManaged, "exclude-auto-managed", false, "skip releases upgraded automatically by GitOps controllers such as Flux or Argo CD")
+	cmd.PersistentFlags().BoolVar(&excludeIaC, "exclude-iac", false, "skip releases created by infrastructure-as-code tools such as the Terraform Helm provider or Pulumi")
+	cmd.PersistentFlags().StringVar(&releaseFilterExpr, "release-filter", "", "only check releases whose name matches this regular expression, e.g. ^prod-")
+	cmd.PersistentFlags().StringVar(&chartFilterExpr, "chart-filter", "", "only check releases whose chart name matches this regular expression")
+	cmd.PersistentFlags().StringSliceVar(&kubeContexts, "contexts", nil, "check the clusters of these kubeconfig contexts and merge the results into one report (comma-separated or repeated)")
+	cmd.PersistentFlags().BoolVar(&allContexts, "all-contexts", false, "check the clusters of all kubeconfig contexts and merge the results into one report")
+	cmd.PersistentFlags().StringSliceVar(&storageNamespaces, "storage-namespace", nil, "only read Helm release metadata stored in these namespaces (comma-separated or repeated)")
+	cmd.PersistentFlags().StringSliceVar(&ignoreReleases, "ignore", nil, "report these releases (name or namespace/name, comma-separated) as IGNORED instead of checking them for updates")
+	cmd.PersistentFlags().StringVar(&catalogPath, "catalog", "", "file or HTTP(S) URL of a catalog of approved chart versions. The latest version of a chart in the catalog is its latest approved version")
+	cmd.PersistentFlags().StringVar(&policyFilePath, "policy", "", "YAML file with update policy rules, e.g. which update types are reported for which charts and how old versions have to be")
+	cmd.PersistentFlags().StringVar(&pinFilePath, "pin-file", "", "YAML file mapping release or chart names to semver constraints the latest version has to satisfy, e.g. \"ingress-nginx: ~4.10\"")
+	cmd.PersistentFlags().StringVar(&renovateFile, "renovate-config", "", "import ignore and version constraint settings from the Helm package rules of a renovate.json")
+	cmd.PersistentFlags().StringVar(&minSeverity, "min-severity", "", "only report outdated releases whose update is at least this severe. Accepted levels: trivial, patch, minor, major")
+	cmd.PersistentFlags().StringVar(&minRepoConfidence, "min-repo-confidence", "", "report releases whose chart repository attribution is less confident than this level as unknown. Accepted levels: exact-annotation, url-match, index-match, prefix-heuristic, guess")
+	cmd.PersistentFlags().BoolVar(&strictRepo, "strict-repo", false, "report charts provided by several repositories as unknown instead of using the first one, unless the repository mappings pick one")
+	cmd.PersistentFlags().StringSliceVar(&advisoryFeedFlags, "advisories", nil, "mark releases affected by the advisories of these feeds (files or URLs, OSV JSON or whatup YAML) as VULNERABLE")
+
 	f := cmd.Flags()
+	// Accept --namespace like helm itself does, also in the subcommands
+	cmd.SetGlobalNormalizationFunc(namespaceFlagAlias)
 
-	f.StringVarP(&outputFormat, "output", "o", outputFormatTable, "output format. Accepted formats: plain, json, yaml, table, short")
-	f.BoolVarP(&devel, "devel", "d", false, "whether to include pre-releases or not")
+	addResultFlags(f)
 	f.BoolVar(&tlsEnable, "tls", false, "enable TLS for requests to the server")
 	f.StringVar(&tlsCaCert, "tls-ca-cert", "", "path to TLS CA certificate file")
 	f.StringVar(&tlsCert, "tls-cert", "", "path to TLS certificate file")
 	f.StringVar(&tlsKey, "tls-key", "", "path to TLS key file")
 	f.StringVar(&tlsHostname, "tls-hostname", "", "the server name used to verify the hostname on the returned certificates from the server")
 	f.BoolVar(&tlsVerify, "tls-verify", false, "enable TLS for requests to the server, and controls whether the client verifies the server's certificate chain and host name")
+	f.StringVar(&outputFile, "output-file", "", "write the report to this file instead of stdout, gzip-compressed if it ends with .gz, together with a .sha256 checksum file")
+	f.BoolVar(&noPager, "no-pager", false, "do not page human-readable output that doesn't fit the terminal")
+	f.BoolVar(&rowSeparators, "row-separators", false, "draw a line between the rows of the table output")
+	f.BoolVar(&emitEvents, "emit-events", false, "emit a Kubernetes Event in the namespace of each release that is outdated")
+	f.BoolVar(&notify, "notify", false, "send outdated releases to the receivers configured in the notifications section of the config file")
+	f.StringVar(&digest, "digest", "", "with --notify, accumulate findings and send one consolidated report per receiver and period. Accepted periods: daily, weekly")
+	f.StringVar(&ownerAnnotation, "owner-annotation", "", "namespace annotation (or label) holding the owning team of the releases in that namespace")
+	f.BoolVar(&collapseCanary, "collapse-canaries", false, "report primary/canary releases created by progressive delivery tools (Flagger, Argo Rollouts) as a single entry")
+	f.BoolVar(&checkHistory, "check-history", false, "report releases keeping an excessive number of revisions in storage")
+	f.IntVar(&maxRevisions, "max-revisions", defaultMaxRevisions, "with --check-history, the number of stored revisions above which a release is flagged")
+	f.BoolVar(&appVersionOnly, "app-version-only", false, "only report releases as outdated when the latest chart version ships a different application version")
+	f.BoolVar(&showAvailable, "show-available", false, "list every released version between the installed and the latest version, with its release date, in the JSON and YAML output")
+	f.IntVar(&maxReleaseAge, "max-release-age", 0, "flag releases that were not upgraded or redeployed for more than this number of days, regardless of their version status")
+	f.IntVar(&abandonedAfter, "abandoned-after", 0, "flag charts whose newest version in the repository is older than this number of months as possibly unmaintained")
+	f.StringVar(&severityHook, "severity-hook", "", "command run for every result with the result as JSON on stdin, printing {\"severity\": ..., \"status\": ...} to override them")
+	f.BoolVar(&gitSources, "git-sources", false, "look up the Git repository and path declaring the releases deployed by Flux or Argo CD")
+	f.BoolVar(&correlateWorkloads, "workloads", false, "correlate releases with their running pods (via the app.kubernetes.io/instance label) and report their health")
+	f.BoolVar(&useArtifactHub, "artifacthub", false, "look up charts on ArtifactHub and report whether they are official or from a verified publisher, and their stars")
+	f.BoolVar(&probeRepos, "probe-repos", false, "look up charts that are not in any configured repository in a list of well-known public repositories")
+	f.BoolVar(&fixRepos, "fix-repos", false, "run `helm repo add` for repositories discovered for charts that could not be resolved")
+	f.BoolVar(&trivialUpdates, "trivial-updates", false, "download the latest chart of outdated releases and classify updates that only change its documentation or metadata as TRIVIAL")
+	f.BoolVar(&valuesSummary, "values-summary", false, "report whether releases were configured with user-supplied values and how many keys they override")
+	f.StringVar(&statsdAddr, "statsd", "", "send the number of releases and of outdated releases per namespace to the StatsD or DogStatsD agent at this HOST:PORT")
+	f.StringVar(&changeLog, "change-log", "", "append a JSON record of every release whose status changed since the previous run to this file")
+	f.BoolVar(&telemetry, "telemetry", false, "record anonymous stats of the scan (releases scanned, duration, index hit rate) locally, see `helm whatup stats`, and send them to telemetry.endpoint when configured")
+
+	// Complete names from the cluster of the current context
+	_ = cmd.RegisterFlagCompletionFunc("namespaces", completeNamespaces)
+	_ = cmd.RegisterFlagCompletionFunc("storage-namespace", completeNamespaces)
+	_ = cmd.RegisterFlagCompletionFunc("ignore", completeReleaseList)
+
+	cmd.AddCommand(newReportDiffCmd())
+	cmd.AddCommand(newReportMergeCmd())
+	cmd.AddCommand(newReportCmd())
+	cmd.AddCommand(newUpgradeCmd())
+	cmd.AddCommand(newResolveCmd())
+	cmd.AddCommand(newScanDirCmd())
+	cmd.AddCommand(newCapabilitiesCmd())
+	cmd.AddCommand(newStatsCmd())
+	cmd.AddCommand(newServeCmd())
+	cmd.AddCommand(newCheckCmd())
+	cmd.AddCommand(newGenAlertsCmd())
+	cmd.AddCommand(newGenDashboardCmd())
+	cmd.AddCommand(newGetterCmd())
 
 	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+		// The original plugin exited with 1 on every failure
+		if legacyCompat() {
+			os.Exit(1)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// newSettings returns the Helm settings read from the environment, pointed at
+// the cluster selected with --in-cluster, --kubeconfig and --kube-context
+func newSettings() *helmSettings {
+	settings := newHelmSettings()
+	if inCluster {
+		applyInClusterConfig(settings)
+	}
+	if kubeConfig != "" {
+		settings.KubeConfig = kubeConfig
 	}
+	if kubeContext != "" {
+		settings.KubeContext = kubeContext
+	}
+	applyKubeTimeout(settings, kubeTimeout)
+	return settings
 }
 
-func newClient() (*action.Configuration, error) {
-	settings := cli.New()
-	actionConfig := new(action.Configuration)
+func newClient(settings *helmSettings, namespace string) (*helmConfiguration, error) {
+	actionConfig := new(helmConfiguration)
 
 	// Use "" for namespace to get all namespaces
-	if err := actionConfig.Init(settings.RESTClientGetter(), "", os.Getenv("HELM_DRIVER"), debug); err != nil {
+	if err := initHelmConfiguration(actionConfig, settings, namespace, os.Getenv("HELM_DRIVER")); err != nil {
 		return nil, fmt.Errorf("failed to initialize Helm client: %w", err)
 	}
 
 	return actionConfig, nil
 }
 
-func debug(format string, v ...interface{}) {
-	// Suppress debug output by default
-	if os.Getenv("HELM_DEBUG") != "" {
-		fmt.Fprintf(os.Stderr, format, v...)
+// newClients returns one Helm client per storage namespace to read releases
+// from, or a single client reading all namespaces when none were requested.
+func newClients(settings *helmSettings) ([]*helmConfiguration, error) {
+	namespaces := clientNamespaces()
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	clients := make([]*helmConfiguration, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		actionConfig, err := newClient(settings, namespace)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, actionConfig)
 	}
+
+	return clients, nil
 }
 
-func run(_ *cobra.Command, _ []string) error {
-	actionConfig, err := newClient()
+func newKubeClientset(settings *helmSettings) (*kubernetes.Clientset, error) {
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Kubernetes client configuration: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	return clientset, nil
+}
+
+// scanReport is the outcome of a scan of all clusters
+type scanReport struct {
+	results      []ChartVersionInfo
+	warnings     []warning
+	releases     int
+	repositories int
+	// kubeContexts and policies are recorded in the audit log
+	kubeContexts []string
+	policies     []string
+	// installed are the scanned releases by reportKey, for the analyzers of
+	// the check subcommand
+	installed map[string]*helmRelease
+}
+
+// addResultFlags adds the flags printing the results and setting the exit code
+// to the commands reporting releases, the root command and scan-dir
+func addResultFlags(f *pflag.FlagSet) {
+	f.StringVarP(&outputFormat, "output", "o", outputFormatTable, "output format. Accepted formats: plain, json, yaml, table, wide, short, dot, markdown, html, codequality, prometheus, custom-columns=HEADER:.path,..., jsonpath=TEMPLATE")
+	f.BoolVar(&githubActions, "github-actions", false, "write a workflow annotation per outdated release, attached to the file declaring it with scan-dir, and append a markdown table of them to $GITHUB_STEP_SUMMARY")
+	f.BoolVar(&failOnOutdated, "fail-on-outdated", false, "exit with code 2 when outdated releases are found (0 when everything is up to date, 1 on errors)")
+	f.BoolVarP(&quiet, "quiet", "q", false, "print nothing and only exit with code 2 when outdated releases are found, like --fail-on-outdated")
+	f.BoolVar(&noHeaders, "no-headers", false, "leave out the header of the table, wide and custom-columns output")
+}
+
+func run(cmd *cobra.Command, _ []string) error {
+	if err := validateCompat(); err != nil {
 		return err
 	}
+	if inCluster {
+		if err := checkInCluster(); err != nil {
+			return err
+		}
+	}
+	if isCustomColumns(outputFormat) {
+		if _, err := parseCustomColumns(outputFormat); err != nil {
+			return err
+		}
+	}
+	if isJSONPath(outputFormat) {
+		if _, err := parseJSONPathTemplate(outputFormat); err != nil {
+			return err
+		}
+	}
+	if signingEnabled() && outputFile == "" {
+		return fmt.Errorf("--sign and --sign-key require --output-file")
+	}
 
-	releases, err := fetchReleases(actionConfig)
+	report, err := scan(cmd.Context())
 	if err != nil {
 		return err
 	}
 
+	if report.releases == 0 {
+		if outputFormat == outputFormatPlain && !quiet {
+			fmt.Println("No releases found. All up to date!")
+		}
+		return nil
+	}
+
+	if report.repositories == 0 && len(report.results) == 0 {
+		if outputFormat == outputFormatPlain && !quiet {
+			if hasWarning(report.warnings, warnNoRepositories) {
+				fmt.Println("No chart repositories are configured. Add them with `helm repo add` or pass --artifacthub to look charts up on ArtifactHub.")
+			} else {
+				fmt.Println("No repositories found. Did you run `helm repo update`?")
+			}
+		}
+		return nil
+	}
+
+	if legacyCompat() {
+		return formatLegacyResults(os.Stdout, report)
+	}
+
+	// Output is collected first so it can be paged when it doesn't fit the terminal
+	var output bytes.Buffer
+
+	// Warnings go to stderr so they never corrupt the report, and are
+	// silenced by --quiet through the log level
+	logWarnings(report.warnings)
+
+	if err := formatAndPrintResults(&output, report); err != nil {
+		return err
+	}
+	if outputFile != "" {
+		if err := writeReportFile(outputFile, output.Bytes()); err != nil {
+			return err
+		}
+		if signingEnabled() {
+			if err := signReportFile(cmd.Context(), outputFile, signKey); err != nil {
+				return err
+			}
+		}
+	} else if !quiet {
+		if err := writePaged(cmd.Context(), os.Stdout, output.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if githubActions {
+		if err := reportToGitHubActions(os.Stdout, report.results); err != nil {
+			return err
+		}
+	}
+
+	if cfg.RepositoryPolicy.Enforce {
+		if err := repoPolicyError(report.results); err != nil {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = quiet
+			return err
+		}
+	}
+
+	if failOnOutdated || quiet {
+		if err := outdatedError(report.results); err != nil {
+			// Outdated releases are a result, not a usage error
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = quiet
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scan checks the releases of every cluster against the local repository
+// cache and runs the enabled integrations. Nothing is checked when there are
+// no releases, or no repositories and ArtifactHub is not enabled.
+func scan(ctx context.Context) (report scanReport, err error) {
+	start := time.Now()
+	lock, err := newRunLock()
+	if err != nil {
+		return report, err
+	}
+	unlock, err := acquireRunLock(ctx, lock, lockWait)
+	if err != nil {
+		return report, err
+	}
+	defer unlock()
+
+	defer func() {
+		recordScan(report, err)
+		recordStatusChanges(report.results, err)
+		recordStats(ctx, report, start, err)
+		recordStatsdMetrics(report, err)
+	}()
+
+	suppressed, err := suppressedWarnings(suppressWarnings)
+	if err != nil {
+		return report, err
+	}
+
+	if minRepoConfidence != "" && !containsString(confidenceLevels, minRepoConfidence) {
+		return report, fmt.Errorf("invalid --min-repo-confidence %q, expected one of %s", minRepoConfidence, strings.Join(confidenceLevels, ", "))
+	}
+
+	minimumSeverity := strings.ToUpper(minSeverity)
+	if minimumSeverity != "" && severityRank(minimumSeverity) < 0 {
+		return report, fmt.Errorf("invalid --min-severity %q, expected one of trivial, patch, minor, major", minSeverity)
+	}
+
+	clusters, err := selectedClusters()
+	if err != nil {
+		return report, err
+	}
+
+	targets, err := scanTargets(clusters)
+	if err != nil {
+		return report, err
+	}
+
+	scans := make([]*clusterScan, 0, len(targets))
+	report.installed = make(map[string]*helmRelease)
+	for _, target := range targets {
+		if auditLog != "" {
+			if name := auditKubeContext(target.settings); name != "" {
+				report.kubeContexts = append(report.kubeContexts, name)
+			}
+		}
+		cs, err := newClusterScan(target)
+		if err != nil {
+			return report, err
+		}
+		scans = append(scans, cs)
+		for _, rel := range cs.releases {
+			report.installed[reportKey(ChartVersionInfo{Cluster: target.cluster.Name, Namespace: rel.Namespace, ReleaseName: rel.Name})] = rel
+		}
+		report.releases += len(cs.releases)
+	}
+
+	if updateRepos {
+		var releases []*helmRelease
+		for _, cs := range scans {
+			releases = append(releases, cs.releases...)
+		}
+		if err := refreshRepositories(ctx, releases, &report.warnings); err != nil {
+			return report, err
+		}
+	}
+
 	repositories, err := fetchIndices()
 	if err != nil {
-		return err
+		return report, err
 	}
+	report.repositories = len(repositories)
 
 	// Get repository file data for reference
-	settings := cli.New()
+	settings := newSettings()
 	repoFile := settings.RepositoryConfig
-	repoFileData, err := repo.LoadFile(repoFile)
+	repoFileData, err := loadRepoFile(repoFile)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "WARNING: Failed to load repository file: %v\n", err)
+		logger.Warn("failed to load repository file", "error", err)
+	} else if len(repoFileData.Repositories) == 0 {
+		addWarning(&report.warnings, warnNoRepositories, "No chart repositories are configured in %s. Add them with `helm repo add`", repoFile)
 	}
 
 	// Create a map of chart names to repositories for quick lookup
 	chartRepoMap := buildChartRepoMap(repositories, repoFileData)
 
-	if len(releases) == 0 {
-		if outputFormat == outputFormatPlain {
-			fmt.Println("No releases found. All up to date!")
-		}
-		return nil
+	// Without repositories, charts can still be looked up on ArtifactHub
+	if report.releases == 0 || (report.repositories == 0 && !artifactHubEnabled()) {
+		return report, nil
+	}
+
+	rules, err := loadVersionRules(ctx)
+	if err != nil {
+		return report, err
 	}
+	report.policies = scanPolicies(rules, minimumSeverity)
 
-	if len(repositories) == 0 {
-		if outputFormat == outputFormatPlain {
-			fmt.Println("No repositories found. Did you run `helm repo update`?")
+	advisories, err := loadAdvisories(ctx, advisoryFeeds())
+	if err != nil {
+		return report, err
+	}
+
+	checkIndexFreshness(repositories, time.Now(), &report.warnings)
+
+	resolver, err := newRepoResolver(repoMappingsFile(), strictRepo)
+	if err != nil {
+		return report, err
+	}
+
+	inputs := scanInputs{
+		repositories: repositories,
+		repoFileData: repoFileData,
+		chartRepoMap: chartRepoMap,
+		rules:        rules,
+		advisories:   advisories,
+		artifactHub:  newArtifactHubClient(cfg.ArtifactHub),
+		resolver:     resolver,
+	}
+
+	// Process the releases of every cluster and build result
+	for _, cs := range scans {
+		clusterResult, err := cs.run(ctx, inputs, &report.warnings)
+		if err != nil {
+			return report, err
 		}
-		return nil
+		report.results = append(report.results, clusterResult...)
+	}
+	if len(scans) > 1 {
+		sortByPriority(report.results)
+	}
+	if minimumSeverity != "" {
+		report.results = filterBySeverity(report.results, minimumSeverity)
 	}
 
-	// Create a warning message buffer
-	var warnings []string
+	if err := resolver.save(); err != nil {
+		return report, err
+	}
 
-	// Process releases and build result
-	result := processReleases(
-		releases,
-		repositories,
-		repoFileData,
-		chartRepoMap,
-		&warnings,
-	)
+	if suggestions := repoAddSuggestions(report.results, repositoryURLs(repoFileData)); len(suggestions) > 0 {
+		if fixRepos {
+			addRepositories(ctx, suggestions, &report.warnings)
+		} else {
+			printRepoAddSuggestions(os.Stderr, suggestions)
+		}
+	}
 
-	// Print collected warnings if in plain format
-	if outputFormat == outputFormatPlain && len(warnings) > 0 {
-		fmt.Println()
-		for _, warning := range warnings {
-			fmt.Printf("WARNING: %s\n", warning)
+	if cfg.PostProcessHook != "" {
+		if report, err = runPostProcessHook(ctx, cfg.PostProcessHook, report, time.Now()); err != nil {
+			return report, err
 		}
 	}
 
-	return formatAndPrintResults(result)
+	if notify {
+		if err := sendNotifications(ctx, cfg.Notifications, report.results, &report.warnings); err != nil {
+			return report, err
+		}
+	}
+
+	report.warnings = filterWarnings(report.warnings, suppressed)
+
+	return report, nil
 }
 
-// buildChartRepoMap creates a map of chart names to repository names
-func buildChartRepoMap(repositories []*repo.IndexFile, repoFileData *repo.File) map[string]string {
+// repoIndex is the cached index of a configured repository
+type repoIndex struct {
+	name string
+	*helmIndexFile
+}
+
+// buildChartRepoMap creates a map of chart names to the name of a repository
+// whose index provides the chart. A repository whose name contains the chart
+// name is preferred when several provide it.
+func buildChartRepoMap(repositories []repoIndex, _ *helmRepoFile) map[string]string {
 	chartRepoMap := make(map[string]string)
-	for _, repo := range repoFileData.Repositories {
-		// Get the index file for this repository
-		for _, idx := range repositories {
-			// Check each chart in this repository
-			for chartName := range idx.Entries {
-				// Associate this chart with this repository
-				// Only if not already set or if the repository name matches the chart name partly
-				if _, exists := chartRepoMap[chartName]; !exists || strings.Contains(repo.Name, chartName) {
-					chartRepoMap[chartName] = repo.Name
-				}
+	for _, idx := range repositories {
+		// Check each chart in this repository
+		for chartName := range idx.Entries {
+			// Associate this chart with this repository
+			// Only if not already set or if the repository name matches the chart name partly
+			if _, exists := chartRepoMap[chartName]; !exists || strings.Contains(idx.name, chartName) {
+				chartRepoMap[chartName] = idx.name
 			}
 		}
 	}
@@ -193,54 +687,67 @@ func buildChartRepoMap(repositories []*repo.IndexFile, repoFileData *repo.File)
 
 // processReleases processes the releases and builds the result slice
 func processReleases(
-	releases []*release.Release,
-	repositories []*repo.IndexFile,
-	repoFileData *repo.File,
+	releases []*helmRelease,
+	repositories []repoIndex,
+	repoFileData *helmRepoFile,
 	chartRepoMap map[string]string,
-	warnings *[]string,
+	rules []versionRule,
+	resolver *repoResolver,
+	warnings *[]warning,
 ) []ChartVersionInfo {
 	var result []ChartVersionInfo
 
 	for _, release := range releases {
 		chartName := release.Chart.Metadata.Name
 		chartVersion := release.Chart.Metadata.Version
-		repoName := ""
 		chartFound := false
+		// excluded is the first index providing the chart whose versions
+		// were all rejected, e.g. pre-releases without --devel
+		var excluded *repoIndex
+		releaseRules := matchingRules(rules, release.Name, release.Namespace, chartName)
 
-		// Try to find the repository from annotations or labels
-		if release.Chart.Metadata.Annotations != nil {
-			if val, ok := release.Chart.Metadata.Annotations["artifacthub.io/repository"]; ok {
-				repoName = val
-			}
+		// Teams can ignore or pin their releases with an annotation or label
+		annotationRules, err := releaseAnnotationRules(release)
+		if err != nil {
+			addWarning(warnings, warnInvalidPin, "Ignoring the pin of '%s': %v", release.Name, err)
 		}
+		releaseRules = append(releaseRules, annotationRules...)
 
-		// If we haven't found a repo name, check our map
-		if repoName == "" {
-			if repo, exists := chartRepoMap[chartName]; exists {
-				repoName = repo
-			}
+		attribution, err := attributeRepo(release, repositories, repoFileData, chartRepoMap, resolver)
+		if err != nil {
+			addWarning(warnings, warnAmbiguousRepo, "Skipping '%s': %v", release.Name, err)
+			result = append(result, ChartVersionInfo{
+				ReleaseName:      release.Name,
+				Namespace:        release.Namespace,
+				ChartName:        chartName,
+				InstalledVersion: chartVersion,
+				Revision:         release.Version,
+				Status:           statusUnknown,
+			})
+			continue
 		}
+		repoName, confidence, candidates := attribution.repoName, attribution.confidence, attribution.candidates
 
 		// For each chart, check all repositories
-		for _, idx := range repositories {
+		for i, idx := range candidates {
 			// Check if the chart exists in this repository
 			entries, exists := idx.Entries[chartName]
 			if !exists || len(entries) == 0 {
 				continue
 			}
 
-			chartFound = true
-
-			// Find the latest version
-			latestVersion := findLatestVersion(entries, repoFileData, &repoName)
+			// Find the latest version allowed by the rules
+			now := time.Now()
+			latestVersion := findLatestVersion(entries, repoFileData, new(string), func(version string, created time.Time) bool {
+				return releaseRules.ignored() || (releaseRules.allows(chartVersion, version) && releaseRules.oldEnough(created, now))
+			})
 			if latestVersion == "" {
+				if excluded == nil {
+					excluded = &candidates[i]
+				}
 				continue
 			}
-
-			// Try different methods to find the repository name
-			if repoName == "" {
-				repoName = determineRepoName(chartName, entries, idx, repoFileData)
-			}
+			chartFound = true
 
 			versionStatus := ChartVersionInfo{
 				ReleaseName:      release.Name,
@@ -249,14 +756,55 @@ func processReleases(
 				InstalledVersion: chartVersion,
 				LatestVersion:    latestVersion,
 				RepoName:         repoName,
+				RepoURL:          chartRepoURL(repoName, repoFileData),
+				Revision:         release.Version,
+				RepoConfidence:   confidence,
+				CreatedBy:        releaseCreatedBy(release),
+
+				InstalledAppVersion: release.Chart.Metadata.AppVersion,
+				LatestAppVersion:    appVersionOf(entries, latestVersion),
+			}
+
+			// With a catalog the latest version is the latest approved one,
+			// record the upstream one to show how far the catalog lags
+			if releaseRules.catalogued() {
+				versionStatus.UpstreamVersion = findLatestVersion(entries, repoFileData, new(string), func(string, time.Time) bool { return true })
+			}
+
+			// Record how fresh the index backing the latest version is
+			if !idx.Generated.IsZero() {
+				generated := idx.Generated
+				versionStatus.IndexGenerated = &generated
 			}
 
-			// Simple string comparison may not work correctly for semver
-			// Using equal instead of direct string comparison
-			if versionStatus.InstalledVersion == versionStatus.LatestVersion {
+			// Rules such as --min-version-age or pins can leave a latest
+			// version older than the installed one, which is never an update.
+			// This includes releases installed past the allowed range.
+			outside := !releaseRules.ignored() && releaseRules.outside(chartVersion)
+			upToDate := !newerVersion(chartVersion, latestVersion)
+			if legacyCompat() {
+				upToDate = chartVersion == latestVersion
+			}
+			switch {
+			case releaseRules.ignored():
+				versionStatus.Status = statusIgnored
+			case upToDate:
 				versionStatus.Status = statusUptodate
-			} else {
+			case appVersionOnly && sameAppVersion(versionStatus):
+				// A chart-only bump of the same application
+				versionStatus.Status = statusUptodate
+			default:
 				versionStatus.Status = statusOutdated
+				versionStatus.Severity = releaseRules.severity()
+				versionStatus.UpdateSeverity = updateSeverity(chartVersion, latestVersion)
+				versionStatus.VersionsBehind = versionsBehind(entries, chartVersion, latestVersion)
+				versionStatus.ReleaseNotesURL = releaseNotesURL(entries, chartName, latestVersion)
+				if showAvailable {
+					versionStatus.AvailableVersions = availableVersions(entries, chartVersion, latestVersion)
+				}
+			}
+			if outside {
+				versionStatus.Flags = append(versionStatus.Flags, flagOutsideConstraint)
 			}
 
 			result = append(result, versionStatus)
@@ -265,128 +813,251 @@ func processReleases(
 			break
 		}
 
+		// The chart was found but no version is allowed, so there is nothing
+		// to update to
+		if !chartFound && excluded != nil {
+			addWarning(warnings, warnNoAllowedVersion, "No version of chart %s in repository %s is allowed for '%s', check --devel and the version rules", chartName, repoName, release.Name)
+			result = append(result, ChartVersionInfo{
+				ReleaseName:      release.Name,
+				Namespace:        release.Namespace,
+				ChartName:        chartName,
+				InstalledVersion: chartVersion,
+				RepoName:         repoName,
+				RepoURL:          chartRepoURL(repoName, repoFileData),
+				Revision:         release.Version,
+				Status:           statusUptodate,
+				RepoConfidence:   confidence,
+				CreatedBy:        releaseCreatedBy(release),
+			})
+			continue
+		}
+
 		// Output warning if chart's repo couldn't be determined
 		if !chartFound {
-			*warnings = append(*warnings, fmt.Sprintf("The source repository could not be determined for '%s'", release.Name))
+			addWarning(warnings, warnRepoUnresolved, "The source repository could not be determined for '%s'", release.Name)
+			unknown := ChartVersionInfo{
+				ReleaseName:      release.Name,
+				Namespace:        release.Namespace,
+				ChartName:        chartName,
+				InstalledVersion: chartVersion,
+				Revision:         release.Version,
+				Status:           statusUnknown,
+				CreatedBy:        releaseCreatedBy(release),
+			}
+			// The repository annotation may hold the URL of a repository that isn't configured
+			if isRepositoryURL(repoName) {
+				unknown.SuggestedRepository = &wellKnownRepository{
+					Name: suggestedRepoName(repoName),
+					URL:  repoName,
+				}
+			}
+			result = append(result, unknown)
 		}
 	}
 
 	return result
 }
 
-// findLatestVersion finds the latest version of a chart
-func findLatestVersion(entries repo.ChartVersions, repoFileData *repo.File, repoName *string) string {
-	latestVersion := ""
+// repoAttribution is the repository the chart of a release is attributed to
+type repoAttribution struct {
+	repoName   string
+	confidence string
+	// candidates are the indexes providing the chart, empty when no
+	// configured repository provides it
+	candidates []repoIndex
+}
 
-	// Get the latest version (index is already sorted with latest first)
-	for _, entry := range entries {
-		// Skip prerelease versions if devel flag is not set
-		if !devel && entry.APIVersion == "prerelease" {
-			continue
+// attributeRepo finds the repository providing the chart of a release, from
+// the repository annotation, the URL the chart was downloaded from, the map of
+// chart names or the choice among several repositories providing it, without
+// comparing versions. An error is returned for ambiguous charts in strict mode.
+func attributeRepo(release *helmRelease, repositories []repoIndex, repoFileData *helmRepoFile, chartRepoMap map[string]string, resolver *repoResolver) (repoAttribution, error) {
+	chartName := release.Chart.Metadata.Name
+	repoName := ""
+	confidence := confidenceGuess
+
+	// Try to find the repository from annotations or labels
+	if release.Chart.Metadata.Annotations != nil {
+		if val, ok := release.Chart.Metadata.Annotations[repositoryAnnotation]; ok {
+			repoName = val
+			confidence = confidenceExact
+		}
+	}
+
+	// The download URL of the chart names its repository without guessing
+	if repoName == "" {
+		if repo := chartSourceRepo(release, repoFileData); repo != "" {
+			repoName = repo
+			confidence = confidenceURL
+			resolver.remember(chartName, repo)
 		}
-		latestVersion = entry.Version
+	}
 
-		// If repository name is not set, try to find it
-		if *repoName == "" && len(entry.URLs) > 0 {
-			// Extract repository URL from chart URL
-			chartURL := entry.URLs[0]
+	// If we haven't found a repo name, check our map
+	if repoName == "" {
+		if repo, exists := chartRepoMap[chartName]; exists {
+			repoName = repo
+			confidence = confidenceIndex
+		}
+	}
 
-			// Try to match with known repositories
-			for _, repo := range repoFileData.Repositories {
-				if strings.Contains(chartURL, repo.URL) {
-					*repoName = repo.Name
-					break
-				}
-			}
+	// When several repositories provide the chart, the resolver may pick one
+	candidates, chosen, err := resolver.candidates(chartName, repositories)
+	if err != nil {
+		return repoAttribution{}, err
+	}
+	if chosen != "" && (chosen != repoName || confidence != confidenceURL) {
+		repoName = chosen
+		confidence = confidenceExact
+	} else if len(candidates) > 1 && confidence == confidenceIndex {
+		// Several repositories provide the chart and none was chosen
+		confidence = confidenceGuess
+	}
+	if len(candidates) == 0 {
+		return repoAttribution{repoName: repoName, confidence: confidence}, nil
+	}
+
+	// The URL of the latest version names the repository serving it
+	entries := candidates[0].Entries[chartName]
+	if repoName == "" {
+		findLatestVersion(entries, repoFileData, &repoName, func(string, time.Time) bool { return true })
+		if repoName != "" {
+			confidence = confidenceURL
 		}
+	}
 
-		break
+	// Try different methods to find the repository name
+	if repoName == "" {
+		repoName, confidence = determineRepoName(chartName, entries, candidates[0].helmIndexFile, repoFileData)
 	}
+	confidence = verifyRepoURL(confidence, repoName, entries, repoFileData)
 
-	return latestVersion
+	return repoAttribution{repoName: repoName, confidence: confidence, candidates: candidates}, nil
 }
 
-// determineRepoName determines the repository name using various methods
-func determineRepoName(chartName string, entries repo.ChartVersions, idx *repo.IndexFile, repoFileData *repo.File) string {
-	repoName := ""
+// findLatestVersion finds the highest semver version of a chart that is
+// accepted by allowed. All entries are compared since index files are not
+// necessarily sorted; entries whose version is not valid semver are skipped.
+func findLatestVersion(entries helmChartVersions, repoFileData *helmRepoFile, repoName *string, allowed func(version string, created time.Time) bool) string {
+	var latest *semver.Version
+	latestIndex := -1
+	if legacyCompat() {
+		latestIndex = legacyLatestIndex(entries, allowed)
+	} else {
+		for i, entry := range entries {
+			version, err := semver.NewVersion(entry.Version)
+			if err != nil {
+				continue
+			}
+			// Skip pre-release versions such as 1.2.0-rc.1 if devel flag is not set
+			if !devel && version.Prerelease() != "" {
+				continue
+			}
+			// Skip versions excluded by version rules
+			if !allowed(entry.Version, entry.Created) {
+				continue
+			}
+			if latest == nil || version.GreaterThan(latest) {
+				latest = version
+				latestIndex = i
+			}
+		}
+	}
+
+	if latestIndex < 0 {
+		return ""
+	}
+	entry := entries[latestIndex]
+
+	// If repository name is not set, try to find it
+	if *repoName == "" && len(entry.URLs) > 0 {
+		// Extract repository URL from chart URL
+		chartURL := entry.URLs[0]
+
+		// Try to match with known repositories
+		for _, repo := range repoFileData.Repositories {
+			if strings.Contains(chartURL, repo.URL) {
+				*repoName = repo.Name
+				break
+			}
+		}
+	}
 
+	return entry.Version
+}
+
+// determineRepoName determines the repository name using various methods and
+// returns it together with the confidence of the attribution
+func determineRepoName(chartName string, entries helmChartVersions, idx *helmIndexFile, repoFileData *helmRepoFile) (string, string) {
 	// Method 1: Check if this chart name is a known repo
 	for _, repo := range repoFileData.Repositories {
 		if repo.Name == chartName {
-			repoName = repo.Name
-			break
+			return repo.Name, confidencePrefix
 		}
 	}
 
 	// Method 2: Try to parse from index file name
-	if repoName == "" && idx.APIVersion != "" {
+	if idx.APIVersion != "" {
 		// Look for repo name in the index metadata
 		// The APIVersion field is sometimes used to store the path
 		nameFromPath := filepath.Base(idx.APIVersion)
 		if strings.HasSuffix(nameFromPath, "-index.yaml") {
-			repoName = strings.TrimSuffix(nameFromPath, "-index.yaml")
+			return strings.TrimSuffix(nameFromPath, "-index.yaml"), confidenceGuess
 		}
 	}
 
 	// Method 3: Match by URL patterns
-	if repoName == "" && len(entries) > 0 && len(entries[0].URLs) > 0 {
-		repoName = determineRepoNameFromURL(entries[0].URLs[0], repoFileData)
+	if len(entries) > 0 && len(entries[0].URLs) > 0 {
+		if repoName, confidence := determineRepoNameFromURL(entries[0].URLs[0], repoFileData); repoName != "" {
+			return repoName, confidence
+		}
 	}
 
 	// Last resort: Check the release name
-	if repoName == "" {
-		// For example, rke2-cilium likely comes from rke2-charts
-		for _, repo := range repoFileData.Repositories {
-			prefix := strings.Split(chartName, "-")[0]
-			if strings.HasPrefix(repo.Name, prefix) {
-				repoName = repo.Name
-				break
-			}
+	// For example, rke2-cilium likely comes from rke2-charts
+	for _, repo := range repoFileData.Repositories {
+		prefix := strings.Split(chartName, "-")[0]
+		if strings.HasPrefix(repo.Name, prefix) {
+			return repo.Name, confidencePrefix
 		}
 	}
 
 	// Final fallback
-	if repoName == "" {
-		repoName = "unknown"
-	}
-
-	return repoName
+	return "unknown", confidenceGuess
 }
 
-// determineRepoNameFromURL extracts repository name from a chart URL
-func determineRepoNameFromURL(chartURL string, repoFileData *repo.File) string {
-	repoName := ""
-
+// determineRepoNameFromURL extracts repository name from a chart URL. It is
+// a URL match when the chart URL belongs to a configured repository.
+func determineRepoNameFromURL(chartURL string, repoFileData *helmRepoFile) (string, string) {
 	// Common URL patterns
 	for _, repo := range repoFileData.Repositories {
 		if strings.Contains(chartURL, repo.URL) {
-			repoName = repo.Name
-			break
+			return repo.Name, confidenceURL
 		}
 	}
 
 	// Try to extract repo name from URL
-	if repoName == "" {
-		// Example: https://charts.bitnami.com/bitnami
-		// Extract "bitnami"
-		parts := strings.Split(chartURL, "/")
-		if len(parts) >= minURLParts {
-			domainParts := strings.Split(parts[2], ".")
-			if len(domainParts) >= minDomainParts {
-				repoName = domainParts[1]
-			}
+	// Example: https://charts.bitnami.com/bitnami
+	// Extract "bitnami"
+	parts := strings.Split(chartURL, "/")
+	if len(parts) >= minURLParts {
+		domainParts := strings.Split(parts[2], ".")
+		if len(domainParts) >= minDomainParts {
+			return domainParts[1], confidenceGuess
 		}
 	}
 
-	return repoName
+	return "", confidenceGuess
 }
 
 // formatAndPrintResults formats and prints the version information based on the selected output format
-func formatAndPrintResults(result []ChartVersionInfo) error {
-	// Check if we have any outdated charts
+func formatAndPrintResults(w io.Writer, report scanReport) error {
+	result := report.results
+
+	// Check if we have any outdated or flagged charts
 	hasOutdated := false
 	for _, versionInfo := range result {
-		if versionInfo.Status == statusOutdated {
+		if versionInfo.Status == statusOutdated || versionInfo.Status == statusVulnerable || len(versionInfo.Flags) > 0 {
 			hasOutdated = true
 			break
 		}
@@ -394,74 +1065,156 @@ func formatAndPrintResults(result []ChartVersionInfo) error {
 
 	// If no outdated charts and plain format, show a simpler message
 	if !hasOutdated && outputFormat == outputFormatPlain {
-		fmt.Println("No charts need updates. All up to date!")
+		fmt.Fprintln(w, "No charts need updates. All up to date!")
 		return nil
 	}
 
+	if isCustomColumns(outputFormat) {
+		return formatCustomColumns(w, outputFormat, result)
+	}
+	if isJSONPath(outputFormat) {
+		return formatJSONPath(w, outputFormat, report)
+	}
+
+	color := colorEnabled()
+	// paint colors the text of a status, version or severity when output is colored
+	paint := func(s, c string) string {
+		if !color {
+			return s
+		}
+		return colorize(s, c)
+	}
+
 	switch outputFormat {
 	case outputFormatPlain:
-		fmt.Println("\nWARNING: Charts marked as deprecated will not be shown in the results.")
-		fmt.Println()
+		fmt.Fprintln(w, "\nWARNING: Charts marked as deprecated will not be shown in the results.")
+		fmt.Fprintln(w)
 		for _, versionInfo := range result {
-			if versionInfo.LatestVersion != versionInfo.InstalledVersion {
-				fmt.Printf("There is an update available for release %s (%s)!\n"+
+			switch versionInfo.Status {
+			case statusOutdated:
+				fmt.Fprintf(w, "%s\n"+
 					"Installed version: %s\n"+
-					"Available version: %s\n\n",
+					"Available version: %s\n",
+					paint(fmt.Sprintf("There is an update available for release %s (%s)!", versionInfo.ReleaseName, versionInfo.ChartName), statusColors[statusOutdated]),
+					versionInfo.InstalledVersion,
+					paint(versionInfo.LatestVersion, severityColors[versionInfo.UpdateSeverity]))
+				if versionInfo.VersionsBehind > 0 {
+					fmt.Fprintf(w, "Versions behind: %d\n", versionInfo.VersionsBehind)
+				}
+				if versionInfo.ReleaseNotesURL != "" {
+					fmt.Fprintf(w, "Release notes: %s\n", versionInfo.ReleaseNotesURL)
+				}
+				fmt.Fprintln(w)
+			case statusVulnerable:
+				fmt.Fprintln(w, paint(fmt.Sprintf("Release %s (%s) version %s is affected by %s!",
 					versionInfo.ReleaseName,
 					versionInfo.ChartName,
 					versionInfo.InstalledVersion,
-					versionInfo.LatestVersion)
-			} else {
-				fmt.Printf("Release %s (%s) is up to date.\n", versionInfo.ReleaseName, versionInfo.ChartName)
+					advisoriesColumn(versionInfo)), statusColors[statusVulnerable]))
+				if versionInfo.LatestVersion != "" && versionInfo.LatestVersion != versionInfo.InstalledVersion {
+					fmt.Fprintf(w, "Available version: %s\n", versionInfo.LatestVersion)
+				}
+				fmt.Fprintln(w)
+			case statusIgnored:
+				fmt.Fprintf(w, "Release %s (%s) is ignored.\n", versionInfo.ReleaseName, versionInfo.ChartName)
+			case statusUnknown:
+				// Already reported as a warning
+
+			default:
+				fmt.Fprintln(w, paint(fmt.Sprintf("Release %s (%s) is up to date.", versionInfo.ReleaseName, versionInfo.ChartName), statusColors[statusUptodate]))
+			}
+			if hasFlag(versionInfo, flagStorageBloat) {
+				fmt.Fprintf(w, "HINT: %s\n\n", historyHint(versionInfo))
+			}
+			if hasFlag(versionInfo, flagAutoManaged) {
+				fmt.Fprintf(w, "HINT: %s\n\n", autoManagedHint(versionInfo))
+			}
+			if hasFlag(versionInfo, flagStaleRelease) {
+				fmt.Fprintf(w, "HINT: %s\n\n", releaseAgeHint(versionInfo, time.Now()))
+			}
+			if hasFlag(versionInfo, flagAbandoned) {
+				fmt.Fprintf(w, "HINT: %s\n\n", abandonedHint(versionInfo))
+			}
+			if hasFlag(versionInfo, flagUnapprovedRepo) {
+				fmt.Fprintf(w, "HINT: %s\n\n", repoPolicyHint(versionInfo))
+			}
+			if hasFlag(versionInfo, flagOutsideConstraint) {
+				fmt.Fprintf(w, "HINT: %s\n\n", outsideConstraintHint(versionInfo))
+			}
+			if versionInfo.Status == statusOutdated && releaseStatusColumn(versionInfo) != "" {
+				fmt.Fprintf(w, "HINT: %s\n\n", releaseStatusHint(versionInfo))
+			}
+			if versionInfo.Status == statusOutdated && versionInfo.Values != nil && versionInfo.Values.UserSupplied {
+				fmt.Fprintf(w, "HINT: %s\n\n", valuesHint(versionInfo))
 			}
 		}
-		fmt.Println("Done.")
+		fmt.Fprintln(w, summarize(result))
+		fmt.Fprintln(w, "Done.")
 	case outputFormatShort:
 		for _, versionInfo := range result {
-			if versionInfo.LatestVersion != versionInfo.InstalledVersion {
-				fmt.Printf("%s (%s): %s --> %s\n", versionInfo.ReleaseName, versionInfo.ChartName, versionInfo.InstalledVersion, versionInfo.LatestVersion)
+			switch versionInfo.Status {
+			case statusOutdated:
+				fmt.Fprintf(w, "%s (%s): %s --> %s%s\n", versionInfo.ReleaseName, versionInfo.ChartName, versionInfo.InstalledVersion, versionInfo.LatestVersion, behindSuffix(versionInfo))
+			case statusVulnerable:
+				fmt.Fprintf(w, "%s (%s): %s is vulnerable (%s)\n", versionInfo.ReleaseName, versionInfo.ChartName, versionInfo.InstalledVersion, advisoriesColumn(versionInfo))
 			}
 		}
 	case outputFormatJSON:
-		outputBytes, err := json.MarshalIndent(result, "", "    ")
+		outputBytes, err := json.MarshalIndent(newReportEnvelope(report, time.Now()), "", "    ")
 		if err != nil {
 			return fmt.Errorf("failed to marshal JSON: %w", err)
 		}
-		fmt.Println(string(outputBytes))
+		fmt.Fprintln(w, string(outputBytes))
 	case outputFormatYML, outputFormatYAML:
-		outputBytes, err := yaml.Marshal(result)
+		outputBytes, err := yaml.Marshal(newReportEnvelope(report, time.Now()))
 		if err != nil {
 			return fmt.Errorf("failed to marshal YAML: %w", err)
 		}
-		fmt.Println(string(outputBytes))
-	case outputFormatTable:
-		fmt.Println("\nWARNING: Charts marked as deprecated will not be shown in the results.")
-		fmt.Println()
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatDot:
+		fmt.Fprint(w, formatDot(result))
+	case outputFormatMarkdown:
+		fmt.Fprint(w, formatMarkdown(result))
+	case outputFormatHTML:
+		return formatHTML(w, report, time.Now())
+	case outputFormatCodeQuality:
+		return formatCodeQuality(w, result)
+	case outputFormatPrometheus:
+		formatPrometheus(w, result, time.Now())
+	case outputFormatTable, outputFormatWide:
+		if !noHeaders {
+			fmt.Fprintln(w, "\nWARNING: Charts marked as deprecated will not be shown in the results.")
+			fmt.Fprintln(w)
+		}
 
 		// Show outdated charts
-		table := uitable.New()
-		table.MaxColWidth = 50
-		table.Wrap = true
-
-		// Add column padding
-		table.Separator = "  "
-
-		table.AddRow("NAME", "NAMESPACE", "INSTALLED VERSION", "LATEST VERSION", "CHART", "REPOSITORY")
-
+		var rows []ChartVersionInfo
 		for _, versionInfo := range result {
-			if versionInfo.LatestVersion != versionInfo.InstalledVersion {
-				// Use the correct namespace from the release
-				table.AddRow(
-					versionInfo.ReleaseName,
-					versionInfo.Namespace,
-					versionInfo.InstalledVersion,
-					versionInfo.LatestVersion,
-					versionInfo.ChartName,
-					versionInfo.RepoName,
-				)
+			if versionInfo.Status == statusOutdated || versionInfo.Status == statusVulnerable || len(versionInfo.Flags) > 0 {
+				rows = append(rows, versionInfo)
 			}
 		}
-		fmt.Println(table)
+
+		// Optional columns are only shown when they have data, e.g. after ownership was resolved
+		columns := visibleColumns(tableColumns, rows)
+		opts := tableOptions{width: terminalWidth(), rowSeparators: rowSeparators, noHeader: noHeaders}
+		if outputFormat == outputFormatWide {
+			// Like kubectl, the wide table is never shrunk to the terminal
+			columns = visibleColumns(wideTableColumns(), rows)
+			opts.width = 0
+		}
+		cells := make([][]string, 0, len(rows))
+		for _, versionInfo := range rows {
+			cells = append(cells, tableRow(columns, versionInfo))
+			if color {
+				opts.colors = append(opts.colors, tableColors(columns, versionInfo))
+			}
+		}
+		renderTable(w, columns, tableHeader(columns), cells, opts)
+		if !noHeaders {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, summarize(result))
+		}
 	default:
 		return fmt.Errorf("invalid formatter: %s", outputFormat)
 	}
@@ -469,30 +1222,73 @@ func formatAndPrintResults(result []ChartVersionInfo) error {
 	return nil
 }
 
-func fetchReleases(actionConfig *action.Configuration) ([]*release.Release, error) {
-	listAction := action.NewList(actionConfig)
-	// Configure the list action
-	listAction.All = true
-	listAction.AllNamespaces = true // Make sure we get releases from all namespaces
-	listAction.SetStateMask()       // Make sure we get all release states
+func fetchReleases(clients []*helmConfiguration) ([]*helmRelease, error) {
+	var releases []*helmRelease
+
+	stateMask, err := releaseStateMask(releaseStates)
+	if err != nil {
+		return nil, err
+	}
+
+	excludes, err := compileNamespaceExcludes(excludeNamespaces)
+	if err != nil {
+		return nil, err
+	}
 
-	releases, err := listAction.Run()
+	filter, err := newReleaseFilter(releaseFilterExpr, chartFilterExpr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list releases: %w", err)
+		return nil, err
 	}
 
-	return releases, nil
+	for _, actionConfig := range clients {
+		// Make sure we get releases from all namespaces, unless the namespaces were restricted
+		namespaceReleases, err := listHelmReleases(actionConfig, len(clientNamespaces()) == 0, stateMask)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+		releases = append(releases, namespaceReleases...)
+	}
+
+	if excludeIaC {
+		releases = filterIaCReleases(releases)
+	}
+	if excludeAutoManaged {
+		releases = filterAutoManagedReleases(releases)
+	}
+
+	return filter.apply(filterNamespaces(releases, selectedNamespaces(), excludes)), nil
+}
+
+// releaseStateMask converts the names accepted by --states into a list state
+// mask. No names means the default of listing every state.
+func releaseStateMask(states []string) (helmListStates, error) {
+	var mask helmListStates
+	for _, name := range states {
+		state := mask.FromName(strings.TrimSpace(name))
+		if state == helmListUnknown {
+			return 0, fmt.Errorf("invalid release state: %s", name)
+		}
+		mask |= state
+	}
+	return mask, nil
 }
 
-func fetchIndices() ([]*repo.IndexFile, error) {
-	indices := []*repo.IndexFile{}
-	settings := cli.New()
+func fetchIndices() ([]repoIndex, error) {
+	indices := []repoIndex{}
+	settings := newSettings()
 
 	// Get repositories file
 	repoFile := settings.RepositoryConfig
 
+	// The original plugin failed without a repositories file
+	if legacyCompat() {
+		if _, err := os.Stat(repoFile); err != nil {
+			return nil, fmt.Errorf("failed to load repository file: %w", err)
+		}
+	}
+
 	// Load repositories
-	repoFileData, err := repo.LoadFile(repoFile)
+	repoFileData, err := loadRepoFile(repoFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load repository file: %w", err)
 	}
@@ -503,13 +1299,13 @@ func fetchIndices() ([]*repo.IndexFile, error) {
 		cachePath := filepath.Join(settings.RepositoryCache, indexFileName)
 
 		// Load the index file
-		indexFile, err := repo.LoadIndexFile(cachePath)
+		indexFile, err := loadIndexFile(cachePath)
 		if err != nil {
 			// Skip repositories with errors
 			continue
 		}
 
-		indices = append(indices, indexFile)
+		indices = append(indices, repoIndex{name: repoEntry.Name, helmIndexFile: indexFile})
 	}
 
 	return indices, nil
@@ -7,9 +7,18 @@ import (
 	"io"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
 	"k8s.io/helm/pkg/proto/hapi/services"
 )
 
@@ -113,6 +122,193 @@ func TestJSONOutput(t *testing.T) {
 	assert.Contains(t, capturedOutput, statusOutdated)
 }
 
+// newTestClient creates a Helm client backed by in-memory release storage
+func newTestClient(t *testing.T, namespace string, releases ...*release.Release) *action.Configuration {
+	t.Helper()
+
+	mem := driver.NewMemory()
+	mem.SetNamespace(namespace)
+	store := storage.Init(mem)
+	for _, rel := range releases {
+		if err := store.Create(rel); err != nil {
+			t.Fatalf("Failed to store release: %v", err)
+		}
+	}
+
+	return &action.Configuration{
+		Releases:   store,
+		KubeClient: &kubefake.PrintingKubeClient{Out: io.Discard},
+		Log:        func(_ string, _ ...interface{}) {},
+	}
+}
+
+// newTestRelease creates a deployed release of the given chart
+func newTestRelease(name, namespace, chartName, chartVersion string) *release.Release {
+	return &release.Release{
+		Name:      name,
+		Namespace: namespace,
+		Version:   1,
+		Info:      &release.Info{Status: release.StatusDeployed},
+		Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: chartName, Version: chartVersion}},
+	}
+}
+
+// Test that releases are only read from the requested storage namespaces
+func TestFetchReleasesStorageNamespaces(t *testing.T) {
+	storageNamespaces = []string{"team-a", "team-b"}
+	defer func() { storageNamespaces = nil }()
+
+	clients := []*action.Configuration{
+		newTestClient(t, "team-a", newTestRelease("api", "team-a", "api", "1.0.0")),
+		newTestClient(t, "team-b", newTestRelease("web", "team-b", "web", "2.0.0")),
+	}
+
+	releases, err := fetchReleases(clients)
+	assert.NoError(t, err)
+	assert.Len(t, releases, 2)
+	assert.Equal(t, "api", releases[0].Name)
+	assert.Equal(t, "web", releases[1].Name)
+}
+
+// newTestIndex creates a repository index with the given versions of a chart, latest first
+func newTestIndex(chartName string, versions ...string) repoIndex {
+	idx := repo.NewIndexFile()
+	for _, version := range versions {
+		idx.Entries[chartName] = append(idx.Entries[chartName], &repo.ChartVersion{
+			Metadata: &chart.Metadata{Name: chartName, Version: version},
+		})
+	}
+	return repoIndex{name: chartName, helmIndexFile: idx}
+}
+
+// Test that --states restricts the releases to the requested states
+func TestFetchReleasesStates(t *testing.T) {
+	stuck := newTestRelease("old", "default", "old", "1.0.0")
+	stuck.Info.Status = release.StatusUninstalling
+	clients := []*action.Configuration{
+		newTestClient(t, "default", newTestRelease("api", "default", "api", "1.0.0"), stuck),
+	}
+
+	releases, err := fetchReleases(clients)
+	assert.NoError(t, err)
+	assert.Len(t, releases, 2)
+
+	releaseStates = []string{"uninstalling"}
+	defer func() { releaseStates = nil }()
+
+	releases, err = fetchReleases(clients)
+	assert.NoError(t, err)
+	require.Len(t, releases, 1)
+	assert.Equal(t, "old", releases[0].Name)
+
+	releaseStates = []string{"deployed", "stuck"}
+	_, err = fetchReleases(clients)
+	assert.Error(t, err)
+}
+
+// Test that version rules constrain the latest version and mark ignored releases
+func TestProcessReleasesVersionRules(t *testing.T) {
+	releases := []*release.Release{
+		newTestRelease("ingress", "default", "ingress-nginx", "4.10.0"),
+		newTestRelease("legacy", "default", "legacy-app", "1.0.0"),
+	}
+	repositories := []repoIndex{
+		newTestIndex("ingress-nginx", "4.11.0", "4.10.1", "4.10.0"),
+		newTestIndex("legacy-app", "2.0.0", "1.0.0"),
+	}
+	rules := []versionRule{
+		{Source: "test", Charts: []string{"ingress-nginx"}, Constraint: "~4.10"},
+		{Source: "test", Releases: []string{"default/legacy"}, Ignore: true},
+	}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, rules, nil, &warnings)
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, "4.10.1", result[0].LatestVersion)
+	assert.Equal(t, statusOutdated, result[0].Status)
+	assert.Equal(t, statusIgnored, result[1].Status)
+}
+
+// Test that results record the generation time of the index they were compared against
+func TestProcessReleasesIndexGenerated(t *testing.T) {
+	releases := []*release.Release{newTestRelease("ingress", "default", "ingress-nginx", "4.10.0")}
+	idx := newTestIndex("ingress-nginx", "4.11.0")
+	idx.Generated = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	var warnings []warning
+	result := processReleases(releases, []repoIndex{idx}, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+
+	require.Len(t, result, 1)
+	require.NotNil(t, result[0].IndexGenerated)
+	assert.Equal(t, idx.Generated, *result[0].IndexGenerated)
+}
+
+// Test that pre-release versions are only reported as the latest version with --devel
+func TestProcessReleasesDevel(t *testing.T) {
+	defer func() { devel = false }()
+	releases := []*release.Release{newTestRelease("ingress", "default", "ingress-nginx", "4.10.0")}
+	repositories := []repoIndex{newTestIndex("ingress-nginx", "4.11.0-rc.1", "4.11.0-beta.2", "4.10.1")}
+
+	var warnings []warning
+	devel = false
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+	require.Len(t, result, 1)
+	assert.Equal(t, "4.10.1", result[0].LatestVersion)
+
+	devel = true
+	result = processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+	require.Len(t, result, 1)
+	assert.Equal(t, "4.11.0-rc.1", result[0].LatestVersion)
+}
+
+// Test that a release is still reported when no version of its chart is allowed
+func TestProcessReleasesNoAllowedVersion(t *testing.T) {
+	releases := []*release.Release{newTestRelease("ingress", "default", "ingress-nginx", "4.11.0-rc.1")}
+	repositories := []repoIndex{newTestIndex("ingress-nginx", "4.11.0-rc.1", "4.11.0-rc.2")}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+	require.Len(t, result, 1)
+	assert.Equal(t, statusUptodate, result[0].Status)
+	assert.Equal(t, "4.11.0-rc.1", result[0].InstalledVersion)
+	assert.Empty(t, result[0].LatestVersion)
+	assert.True(t, hasWarning(warnings, warnNoAllowedVersion))
+	assert.False(t, hasWarning(warnings, warnRepoUnresolved))
+}
+
+// Test that the latest version is the semver maximum, whatever the order of the index
+func TestProcessReleasesUnsortedIndex(t *testing.T) {
+	releases := []*release.Release{newTestRelease("ingress", "default", "ingress-nginx", "4.10.0")}
+	repositories := []repoIndex{newTestIndex("ingress-nginx", "4.9.0", "4.11.0", "not-semver", "4.10.1", "4.2.0")}
+	rules := []versionRule{{Source: "test", Constraint: "<4.11"}}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+	require.Len(t, result, 1)
+	assert.Equal(t, "4.11.0", result[0].LatestVersion)
+
+	result = processReleases(releases, repositories, &repo.File{}, map[string]string{}, rules, nil, &warnings)
+	require.Len(t, result, 1)
+	assert.Equal(t, "4.10.1", result[0].LatestVersion)
+}
+
+// Test that a latest version held back by rules below the installed version is not reported as an update
+func TestProcessReleasesLatestOlderThanInstalled(t *testing.T) {
+	releases := []*release.Release{newTestRelease("web", "default", "nginx", "2.0.0")}
+	idx := newTestIndex("nginx", "2.0.0", "1.9.0")
+	idx.Entries["nginx"][0].Created = time.Now().Add(-time.Hour)
+	idx.Entries["nginx"][1].Created = time.Now().Add(-30 * 24 * time.Hour)
+	rules := []versionRule{{Source: "--min-version-age", MinAge: 7 * 24 * time.Hour}}
+
+	var warnings []warning
+	result := processReleases(releases, []repoIndex{idx}, &repo.File{}, map[string]string{}, rules, nil, &warnings)
+	require.Len(t, result, 1)
+	assert.Equal(t, "1.9.0", result[0].LatestVersion)
+	assert.Equal(t, statusUptodate, result[0].Status)
+	assert.Empty(t, result[0].UpdateSeverity)
+}
+
 // For a more complete test suite, you would add tests for:
 // 1. The fetchReleases function (mocking the Helm client)
 // 2. The fetchIndices function
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// outputFormatMarkdown renders the outdated releases as a GitHub-flavored
+// markdown table
+const outputFormatMarkdown = "markdown"
+
+// formatMarkdown renders a markdown table of the outdated releases, ready to
+// paste into pull requests, wikis or chat. The cluster column is only added
+// for fleet scans.
+func formatMarkdown(result []ChartVersionInfo) string {
+	var rows []ChartVersionInfo
+	withCluster := false
+	for _, versionInfo := range result {
+		if versionInfo.Status != statusOutdated {
+			continue
+		}
+		rows = append(rows, versionInfo)
+		withCluster = withCluster || versionInfo.Cluster != ""
+	}
+	if len(rows) == 0 {
+		return "No charts need updates. All up to date!\n"
+	}
+
+	header := []string{"Release", "Namespace", "Chart", "Installed", "Latest", "Severity", "Release notes"}
+	if withCluster {
+		header = append([]string{"Cluster"}, header...)
+	}
+
+	var b strings.Builder
+	writeMarkdownRow(&b, header)
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	writeMarkdownRow(&b, separator)
+
+	for _, versionInfo := range rows {
+		notes := ""
+		if versionInfo.ReleaseNotesURL != "" {
+			notes = fmt.Sprintf("[%s](%s)", versionInfo.LatestVersion, versionInfo.ReleaseNotesURL)
+		}
+		row := []string{
+			versionInfo.ReleaseName,
+			versionInfo.Namespace,
+			versionInfo.ChartName,
+			versionInfo.InstalledVersion,
+			versionInfo.LatestVersion,
+			cfg.Vocabulary.severity(versionInfo.UpdateSeverity),
+			notes,
+		}
+		if withCluster {
+			row = append([]string{versionInfo.Cluster}, row...)
+		}
+		writeMarkdownRow(&b, row)
+	}
+	return b.String()
+}
+
+// writeMarkdownRow writes a row of a markdown table, escaping the characters
+// that would break the table
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.NewReplacer("|", `\|`, "\n", " ").Replace(cell)
+	}
+	fmt.Fprintf(b, "| %s |\n", strings.Join(escaped, " | "))
+}
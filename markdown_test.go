@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that the markdown table lists the outdated releases with links to their release notes
+func TestFormatMarkdown(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "2.0.0",
+			UpdateSeverity: "MAJOR", ReleaseNotesURL: "https://github.com/example/nginx/releases/tag/v2.0.0", Status: statusOutdated},
+		{ReleaseName: "db", Namespace: "prod", ChartName: "postgresql", InstalledVersion: "12.0.0", LatestVersion: "12.0.0", Status: statusUptodate},
+		{ReleaseName: "a|b", Namespace: "dev", ChartName: "redis", InstalledVersion: "1.0.0", LatestVersion: "1.0.1",
+			UpdateSeverity: "PATCH", Status: statusOutdated},
+	}
+
+	expected := "| Release | Namespace | Chart | Installed | Latest | Severity | Release notes |\n" +
+		"| --- | --- | --- | --- | --- | --- | --- |\n" +
+		"| web | prod | nginx | 1.0.0 | 2.0.0 | MAJOR | [2.0.0](https://github.com/example/nginx/releases/tag/v2.0.0) |\n" +
+		"| a\\|b | dev | redis | 1.0.0 | 1.0.1 | PATCH |  |\n"
+	assert.Equal(t, expected, formatMarkdown(result))
+
+	result[0].Cluster = "prod-eu"
+	assert.Contains(t, formatMarkdown(result), "| Cluster | Release |")
+
+	assert.Equal(t, "No charts need updates. All up to date!\n", formatMarkdown(result[1:2]))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// selectedNamespaces returns the namespaces the check is restricted to. Without
+// --namespaces the namespace helm was invoked with is used, unless it is the
+// default namespace helm sets when none was given.
+func selectedNamespaces() []string {
+	if len(namespaces) > 0 {
+		return namespaces
+	}
+	// The original plugin always scanned all namespaces
+	if legacyCompat() {
+		return nil
+	}
+	if namespace := os.Getenv("HELM_NAMESPACE"); namespace != "" && namespace != "default" {
+		return []string{namespace}
+	}
+	return nil
+}
+
+// clientNamespaces returns the namespaces to read release metadata from, or
+// nil to read all namespaces
+func clientNamespaces() []string {
+	if len(storageNamespaces) > 0 {
+		return storageNamespaces
+	}
+	return selectedNamespaces()
+}
+
+// compileNamespaceExcludes compiles the values of --exclude-namespaces. Every
+// value must match the whole namespace, so plain names only exclude themselves.
+func compileNamespaceExcludes(values []string) ([]*regexp.Regexp, error) {
+	excludes := make([]*regexp.Regexp, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace exclusion %q: %w", value, err)
+		}
+		excludes = append(excludes, re)
+	}
+	return excludes, nil
+}
+
+// filterNamespaces drops the releases outside the selected namespaces and
+// those in excluded namespaces
+func filterNamespaces(releases []*helmRelease, selected []string, excludes []*regexp.Regexp) []*helmRelease {
+	var filtered []*helmRelease
+	for _, rel := range releases {
+		if len(selected) > 0 && !containsString(selected, rel.Namespace) {
+			continue
+		}
+		if namespaceExcluded(rel.Namespace, excludes) {
+			continue
+		}
+		filtered = append(filtered, rel)
+	}
+	return filtered
+}
+
+// namespaceExcluded reports whether the namespace matches one of the exclusions
+func namespaceExcluded(namespace string, excludes []*regexp.Regexp) bool {
+	for _, re := range excludes {
+		if re.MatchString(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceFlagAlias accepts --namespace, the flag helm itself uses, as an
+// alias of --namespaces
+func namespaceFlagAlias(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "namespace" {
+		name = "namespaces"
+	}
+	return pflag.NormalizedName(name)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// Test that HELM_NAMESPACE only restricts the check when a namespace was passed to helm
+func TestSelectedNamespaces(t *testing.T) {
+	t.Setenv("HELM_NAMESPACE", "default")
+	assert.Empty(t, selectedNamespaces())
+
+	t.Setenv("HELM_NAMESPACE", "team-a")
+	assert.Equal(t, []string{"team-a"}, selectedNamespaces())
+	assert.Equal(t, []string{"team-a"}, clientNamespaces())
+
+	namespaces = []string{"team-b", "team-c"}
+	defer func() { namespaces = nil }()
+	assert.Equal(t, []string{"team-b", "team-c"}, selectedNamespaces())
+}
+
+// Test that releases are filtered on the selected and excluded namespaces
+func TestFetchReleasesNamespaces(t *testing.T) {
+	t.Setenv("HELM_NAMESPACE", "")
+	defer func() { namespaces, excludeNamespaces = nil, nil }()
+
+	clients := []*action.Configuration{
+		newTestClient(t, "team-a", newTestRelease("api", "team-a", "api", "1.0.0")),
+		newTestClient(t, "team-b", newTestRelease("web", "team-b", "web", "1.0.0")),
+		newTestClient(t, "kube-system", newTestRelease("agent", "kube-system", "agent", "1.0.0")),
+		newTestClient(t, "monitoring-eu", newTestRelease("exporter", "monitoring-eu", "exporter", "1.0.0")),
+	}
+
+	excludeNamespaces = []string{"kube-system", "monitoring-.*"}
+	releases, err := fetchReleases(clients)
+	require.NoError(t, err)
+	require.Len(t, releases, 2)
+	assert.Equal(t, "api", releases[0].Name)
+	assert.Equal(t, "web", releases[1].Name)
+
+	// Exclusions match the whole namespace
+	excludeNamespaces = []string{"team"}
+	releases, err = fetchReleases(clients)
+	require.NoError(t, err)
+	assert.Len(t, releases, 4)
+
+	excludeNamespaces = []string{"team-("}
+	_, err = fetchReleases(clients)
+	assert.Error(t, err)
+}
+
+func TestFilterNamespaces(t *testing.T) {
+	releases := filterNamespaces(
+		[]*release.Release{
+			newTestRelease("api", "team-a", "api", "1.0.0"),
+			newTestRelease("web", "team-b", "web", "1.0.0"),
+		},
+		[]string{"team-b"},
+		nil,
+	)
+	require.Len(t, releases, 1)
+	assert.Equal(t, "web", releases[0].Name)
+}
+
+// Test that --namespace is accepted like in helm
+func TestNamespaceFlagAlias(t *testing.T) {
+	var selected []string
+	fs := pflag.NewFlagSet("whatup", pflag.ContinueOnError)
+	fs.StringSliceVarP(&selected, "namespaces", "n", nil, "")
+	fs.SetNormalizeFunc(namespaceFlagAlias)
+
+	require.NoError(t, fs.Parse([]string{"--namespace", "team-a", "-n", "team-b"}))
+	assert.Equal(t, []string{"team-a", "team-b"}, selected)
+}
+
+// Test that --kubeconfig and --kube-context override the Helm environment
+func TestNewSettings(t *testing.T) {
+	t.Setenv("HELM_KUBECONTEXT", "from-env")
+	assert.Equal(t, "from-env", newSettings().KubeContext)
+
+	kubeConfig, kubeContext = "/etc/kube/config", "prod"
+	defer func() { kubeConfig, kubeContext = "", "" }()
+	settings := newSettings()
+	assert.Equal(t, "/etc/kube/config", settings.KubeConfig)
+	assert.Equal(t, "prod", settings.KubeContext)
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// notificationTimeout bounds each HTTP call made by a receiver
+const notificationTimeout = 30 * time.Second
+
+// slackMaxTextLength is the default length of the text of a Slack message,
+// above which Slack truncates or collapses it
+const slackMaxTextLength = 4000
+
+// webhookMaxBytes is the default size of the JSON payload of a webhook, below
+// the request body limits of common ingress controllers and API gateways
+const webhookMaxBytes = 1 << 20
+
+// notificationConfig configures the notification router. It is modeled after
+// the Alertmanager route tree: every finding enters the root route and is
+// handed to the receiver of the deepest matching route.
+type notificationConfig struct {
+	Route     notificationRoute `yaml:"route"`
+	Receivers []receiverConfig  `yaml:"receivers"`
+}
+
+// notificationRoute is a node of the routing tree
+type notificationRoute struct {
+	Receiver string              `yaml:"receiver"`
+	Match    map[string]string   `yaml:"match"`
+	MatchRE  map[string]string   `yaml:"match_re"`
+	Continue bool                `yaml:"continue"`
+	Routes   []notificationRoute `yaml:"routes"`
+}
+
+// notifierTypes are the receiver types a receiver can be configured with
+var notifierTypes = []string{"webhook", "slack"}
+
+// receiverConfig configures a named receiver. Exactly one receiver type should be set.
+type receiverConfig struct {
+	Name    string         `yaml:"name"`
+	Webhook *webhookConfig `yaml:"webhook"`
+	Slack   *slackConfig   `yaml:"slack"`
+}
+
+// webhookConfig configures a receiver posting the findings as JSON.
+// Notifications larger than MaxBytes, webhookMaxBytes by default, are split
+// across several requests.
+type webhookConfig struct {
+	URL      string            `yaml:"url"`
+	Headers  map[string]string `yaml:"headers"`
+	MaxBytes int               `yaml:"maxBytes"`
+}
+
+// slackConfig configures a receiver posting to a Slack incoming webhook.
+// Messages longer than MaxLength, slackMaxTextLength by default, are split.
+type slackConfig struct {
+	WebhookURL string `yaml:"webhookURL"`
+	Channel    string `yaml:"channel"`
+	MaxLength  int    `yaml:"maxLength"`
+}
+
+// notification is a message delivered to a single receiver. Notifications
+// split across several messages number their parts from 1.
+type notification struct {
+	Receiver string             `json:"receiver"`
+	Findings []ChartVersionInfo `json:"findings"`
+	Digest   *digestDelta       `json:"digest,omitempty"`
+	Part     int                `json:"part,omitempty"`
+	Parts    int                `json:"parts,omitempty"`
+}
+
+// notifier delivers notifications to a receiver
+type notifier interface {
+	notify(ctx context.Context, n notification) error
+}
+
+// findingLabels returns the labels a finding can be routed on. The labels of
+// the cluster are included but never override the labels of the finding.
+func findingLabels(versionInfo ChartVersionInfo) map[string]string {
+	labels := make(map[string]string, len(versionInfo.ClusterLabels)+9)
+	for name, value := range versionInfo.ClusterLabels {
+		labels[name] = value
+	}
+	labels["release"] = versionInfo.ReleaseName
+	labels["namespace"] = versionInfo.Namespace
+	labels["chart"] = versionInfo.ChartName
+	labels["repo"] = versionInfo.RepoName
+	labels["status"] = versionInfo.Status
+	labels["updateSeverity"] = versionInfo.UpdateSeverity
+	labels["owner"] = versionInfo.Owner
+	labels["priority"] = versionInfo.Priority
+	labels["cluster"] = versionInfo.Cluster
+	return labels
+}
+
+// matches reports whether the labels satisfy all matchers of the route
+func (r *notificationRoute) matches(labels map[string]string) (bool, error) {
+	return matchLabels(r.Match, r.MatchRE, labels)
+}
+
+// matchLabels reports whether the labels equal every value in match and fully
+// match every regular expression in matchRE.
+func matchLabels(match, matchRE, labels map[string]string) (bool, error) {
+	for name, value := range match {
+		if labels[name] != value {
+			return false, nil
+		}
+	}
+	for name, pattern := range matchRE {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return false, fmt.Errorf("invalid match_re for %q: %w", name, err)
+		}
+		if !re.MatchString(labels[name]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// receiversFor walks the routing tree and returns the receivers the labels are
+// routed to. Child routes are evaluated in order; the first match wins unless it
+// sets continue. When no child matches, the route's own receiver is used.
+func (r *notificationRoute) receiversFor(labels map[string]string, parentReceiver string) ([]string, error) {
+	receiver := r.Receiver
+	if receiver == "" {
+		receiver = parentReceiver
+	}
+
+	var receivers []string
+	for i := range r.Routes {
+		child := &r.Routes[i]
+		ok, err := child.matches(labels)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		childReceivers, err := child.receiversFor(labels, receiver)
+		if err != nil {
+			return nil, err
+		}
+		receivers = append(receivers, childReceivers...)
+		if !child.Continue {
+			break
+		}
+	}
+
+	if len(receivers) == 0 && receiver != "" {
+		receivers = append(receivers, receiver)
+	}
+
+	return receivers, nil
+}
+
+// routeFindings groups the findings by the receiver they are routed to
+func routeFindings(config notificationConfig, findings []ChartVersionInfo) (map[string][]ChartVersionInfo, error) {
+	routed := make(map[string][]ChartVersionInfo)
+	for _, finding := range findings {
+		receivers, err := config.Route.receiversFor(findingLabels(finding), "")
+		if err != nil {
+			return nil, err
+		}
+		for _, receiver := range receivers {
+			routed[receiver] = append(routed[receiver], finding)
+		}
+	}
+	return routed, nil
+}
+
+// sortedKeys returns the receiver names of routed findings in a stable order
+func sortedKeys(routed map[string][]ChartVersionInfo) []string {
+	keys := make([]string, 0, len(routed))
+	for key := range routed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newNotifier creates the notifier for a receiver configuration
+func newNotifier(config receiverConfig) (notifier, error) {
+	switch {
+	case config.Webhook != nil:
+		return &webhookNotifier{config: *config.Webhook, client: &http.Client{Timeout: notificationTimeout}}, nil
+	case config.Slack != nil:
+		return &slackNotifier{config: *config.Slack, client: &http.Client{Timeout: notificationTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("receiver %q has no receiver type configured", config.Name)
+	}
+}
+
+// sendNotifications routes the outdated and vulnerable findings and delivers
+// them to their receivers. When a digest period is set, findings are
+// accumulated and only sent once per period. Delivery failures are collected
+// as warnings.
+func sendNotifications(ctx context.Context, config notificationConfig, result []ChartVersionInfo, warnings *[]warning) error {
+	var findings []ChartVersionInfo
+	for _, versionInfo := range result {
+		if versionInfo.Status == statusOutdated || versionInfo.Status == statusVulnerable {
+			findings = append(findings, versionInfo)
+		}
+	}
+
+	routed, err := routeFindings(config, findings)
+	if err != nil {
+		return err
+	}
+
+	var notifications []notification
+	if digest != "" {
+		notifications, err = collectDigests(digestStateFile(), digest, config, result, routed, time.Now())
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, name := range sortedKeys(routed) {
+			notifications = append(notifications, notification{Receiver: name, Findings: routed[name]})
+		}
+	}
+
+	return deliverNotifications(ctx, config, notifications, warnings)
+}
+
+// deliverNotifications sends each notification to its configured receiver
+func deliverNotifications(ctx context.Context, config notificationConfig, notifications []notification, warnings *[]warning) error {
+	receivers := make(map[string]receiverConfig, len(config.Receivers))
+	for _, receiver := range config.Receivers {
+		receivers[receiver.Name] = receiver
+	}
+
+	for _, msg := range notifications {
+		receiver, ok := receivers[msg.Receiver]
+		if !ok {
+			return fmt.Errorf("route references unknown receiver %q", msg.Receiver)
+		}
+
+		n, err := newNotifier(receiver)
+		if err != nil {
+			return err
+		}
+
+		if err := n.notify(ctx, msg); err != nil {
+			addWarning(warnings, warnNotifyFailed, "Failed to notify receiver '%s': %v", msg.Receiver, err)
+		}
+	}
+
+	return nil
+}
+
+// postJSON sends payload as a JSON POST request and checks the response status
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	if dryRun {
+		dryRunf("POST %s %s", url, body)
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("notification rejected with status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// webhookNotifier posts the findings as JSON to an arbitrary endpoint
+type webhookNotifier struct {
+	config webhookConfig
+	client *http.Client
+}
+
+func (w *webhookNotifier) notify(ctx context.Context, n notification) error {
+	n.Findings = cfg.Vocabulary.translate(n.Findings)
+	if n.Digest != nil {
+		delta := *n.Digest
+		delta.New = cfg.Vocabulary.translate(delta.New)
+		delta.Resolved = cfg.Vocabulary.translate(delta.Resolved)
+		n.Digest = &delta
+	}
+	maxBytes := w.config.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = webhookMaxBytes
+	}
+	parts, err := splitNotification(n, maxBytes)
+	if err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if err := postJSON(ctx, w.client, w.config.URL, w.config.Headers, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitNotification splits a notification whose JSON encoding exceeds
+// maxBytes into parts that fit, the digest going with the first part. A
+// finding too large on its own is sent in a part of its own. Zero never
+// splits.
+func splitNotification(n notification, maxBytes int) ([]notification, error) {
+	if maxBytes <= 0 {
+		return []notification{n}, nil
+	}
+	body, err := json.Marshal(n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	if len(body) <= maxBytes {
+		return []notification{n}, nil
+	}
+
+	// The numbers of the parts are only known at the end, reserve room for them
+	empty := n
+	empty.Findings = []ChartVersionInfo{}
+	empty.Part, empty.Parts = 9999, 9999
+	base, err := json.Marshal(empty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	empty.Digest = nil
+	baseWithoutDigest, err := json.Marshal(empty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	var parts []notification
+	current := notification{Receiver: n.Receiver, Digest: n.Digest}
+	size := len(base)
+	for _, finding := range n.Findings {
+		data, err := json.Marshal(finding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal notification: %w", err)
+		}
+		// Findings are separated by commas
+		if len(current.Findings) > 0 && size+len(data)+1 > maxBytes {
+			parts = append(parts, current)
+			current = notification{Receiver: n.Receiver}
+			size = len(baseWithoutDigest)
+		}
+		if len(current.Findings) > 0 {
+			size++
+		}
+		current.Findings = append(current.Findings, finding)
+		size += len(data)
+	}
+	parts = append(parts, current)
+
+	for i := range parts {
+		parts[i].Part = i + 1
+		parts[i].Parts = len(parts)
+	}
+	return parts, nil
+}
+
+// slackNotifier posts a text summary of the findings to a Slack incoming webhook
+type slackNotifier struct {
+	config slackConfig
+	client *http.Client
+}
+
+// slackPayload is the body of a Slack incoming webhook message
+type slackPayload struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+func (s *slackNotifier) notify(ctx context.Context, n notification) error {
+	maxLength := s.config.MaxLength
+	if maxLength <= 0 {
+		maxLength = slackMaxTextLength
+	}
+	for _, text := range splitText(formatNotificationText(n), maxLength) {
+		if err := postJSON(ctx, s.client, s.config.WebhookURL, nil, slackPayload{Channel: s.config.Channel, Text: text}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitText splits a message longer than maxLength characters into messages
+// of whole lines, numbered like "(2/3) ". Lines longer than a message are cut.
+func splitText(text string, maxLength int) []string {
+	if len([]rune(text)) <= maxLength {
+		return []string{text}
+	}
+
+	// Leave room for the numbering
+	const numberingLength = len("(999/999) ")
+	limit := maxLength - numberingLength
+	if limit < 1 {
+		limit = 1
+	}
+
+	var chunks []string
+	var current []rune
+	for _, line := range strings.SplitAfter(text, "\n") {
+		runes := []rune(line)
+		if len(current) > 0 && len(current)+len(runes) > limit {
+			chunks = append(chunks, string(current))
+			current = nil
+		}
+		for len(runes) > limit {
+			chunks = append(chunks, string(runes[:limit]))
+			runes = runes[limit:]
+		}
+		current = append(current, runes...)
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, string(current))
+	}
+
+	for i := range chunks {
+		chunks[i] = fmt.Sprintf("(%d/%d) %s", i+1, len(chunks), chunks[i])
+	}
+	return chunks
+}
+
+// formatNotificationText renders a notification as a short human-readable message.
+// Digests highlight the findings that are new since the previous digest.
+func formatNotificationText(n notification) string {
+	var b strings.Builder
+
+	if n.Digest != nil {
+		fmt.Fprintf(&b, "%s digest: %d release(s) have updates available (%d new, %d resolved since the last digest)\n",
+			strings.ToUpper(n.Digest.Period[:1])+n.Digest.Period[1:],
+			len(n.Findings),
+			len(n.Digest.New),
+			len(n.Digest.Resolved))
+	} else {
+		fmt.Fprintf(&b, "%d release(s) have updates available:\n", len(n.Findings))
+	}
+
+	for _, finding := range n.Findings {
+		marker := ""
+		if n.Digest != nil && n.Digest.isNew(finding) {
+			marker = " [NEW]"
+		}
+		fmt.Fprintf(&b, "• %s (%s): %s --> %s%s\n",
+			reportKey(finding),
+			finding.ChartName,
+			finding.InstalledVersion,
+			finding.LatestVersion,
+			marker)
+	}
+
+	if n.Digest != nil && len(n.Digest.Resolved) > 0 {
+		b.WriteString("Resolved since the last digest:\n")
+		for _, finding := range n.Digest.Resolved {
+			fmt.Fprintf(&b, "• %s (%s)\n", reportKey(finding), finding.ChartName)
+		}
+	}
+
+	return b.String()
+}
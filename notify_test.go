@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const testNotificationConfig = `
+route:
+  receiver: digest
+  routes:
+    - match_re:
+        namespace: prod-.*
+      receiver: sre
+      continue: true
+    - match:
+        repo: bitnami
+      receiver: security
+`
+
+// Test that findings are routed through the route tree like Alertmanager
+func TestRouteFindings(t *testing.T) {
+	var config notificationConfig
+	require.NoError(t, yaml.Unmarshal([]byte(testNotificationConfig), &config))
+
+	findings := []ChartVersionInfo{
+		{ReleaseName: "api", Namespace: "prod-payments", RepoName: "bitnami"},
+		{ReleaseName: "web", Namespace: "prod-shop", RepoName: "stable"},
+		{ReleaseName: "cache", Namespace: "dev", RepoName: "bitnami"},
+		{ReleaseName: "sandbox", Namespace: "dev", RepoName: "stable"},
+	}
+
+	routed, err := routeFindings(config, findings)
+	require.NoError(t, err)
+
+	names := func(receiver string) []string {
+		var result []string
+		for _, finding := range routed[receiver] {
+			result = append(result, finding.ReleaseName)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"api", "web"}, names("sre"))
+	assert.Equal(t, []string{"api", "cache"}, names("security"))
+	assert.Equal(t, []string{"sandbox"}, names("digest"))
+}
+
+// Test that findings can be routed on the severity of their update
+func TestRouteFindingsUpdateSeverity(t *testing.T) {
+	config := notificationConfig{
+		Route: notificationRoute{
+			Receiver: "digest",
+			Routes: []notificationRoute{
+				{Match: map[string]string{"updateSeverity": "MAJOR"}, Receiver: "pager"},
+			},
+		},
+	}
+	findings := []ChartVersionInfo{
+		{ReleaseName: "api", Status: statusOutdated, UpdateSeverity: "MAJOR"},
+		{ReleaseName: "web", Status: statusOutdated, UpdateSeverity: "PATCH"},
+	}
+
+	routed, err := routeFindings(config, findings)
+	require.NoError(t, err)
+	require.Len(t, routed["pager"], 1)
+	assert.Equal(t, "api", routed["pager"][0].ReleaseName)
+	require.Len(t, routed["digest"], 1)
+	assert.Equal(t, "web", routed["digest"][0].ReleaseName)
+}
+
+// Test that the webhook receiver posts outdated findings as JSON
+func TestSendNotificationsWebhook(t *testing.T) {
+	var payload notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := notificationConfig{
+		Route: notificationRoute{Receiver: "hook"},
+		Receivers: []receiverConfig{
+			{Name: "hook", Webhook: &webhookConfig{URL: server.URL, Headers: map[string]string{"X-Token": "secret"}}},
+		},
+	}
+	result := []ChartVersionInfo{
+		{ReleaseName: "outdated", Status: statusOutdated},
+		{ReleaseName: "current", Status: statusUptodate},
+	}
+
+	var warnings []warning
+	require.NoError(t, sendNotifications(context.Background(), config, result, &warnings))
+	assert.Empty(t, warnings)
+	assert.Equal(t, "hook", payload.Receiver)
+	require.Len(t, payload.Findings, 1)
+	assert.Equal(t, "outdated", payload.Findings[0].ReleaseName)
+}
+
+// Test that webhook notifications larger than maxBytes are split into numbered parts
+func TestSendNotificationsWebhookSplit(t *testing.T) {
+	var payloads []notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var result []ChartVersionInfo
+	for i := 0; i < 50; i++ {
+		result = append(result, ChartVersionInfo{ReleaseName: fmt.Sprintf("release-%02d", i), Status: statusOutdated})
+	}
+	config := notificationConfig{
+		Route:     notificationRoute{Receiver: "hook"},
+		Receivers: []receiverConfig{{Name: "hook", Webhook: &webhookConfig{URL: server.URL, MaxBytes: 1024}}},
+	}
+
+	var warnings []warning
+	require.NoError(t, sendNotifications(context.Background(), config, result, &warnings))
+	assert.Empty(t, warnings)
+	require.Greater(t, len(payloads), 1)
+
+	var names []string
+	for i, payload := range payloads {
+		assert.Equal(t, i+1, payload.Part)
+		assert.Equal(t, len(payloads), payload.Parts)
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(body), 1024)
+		for _, finding := range payload.Findings {
+			names = append(names, finding.ReleaseName)
+		}
+	}
+	assert.Len(t, names, 50)
+	assert.Equal(t, "release-49", names[49])
+}
+
+// Test that webhook notifications are split at webhookMaxBytes when the
+// receiver sets no maxBytes
+func TestSendNotificationsWebhookDefaultMaxBytes(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		sizes = append(sizes, len(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// About 1.5 MiB of findings
+	notes := strings.Repeat("x", 1024)
+	var result []ChartVersionInfo
+	for i := 0; i < 1500; i++ {
+		result = append(result, ChartVersionInfo{ReleaseName: fmt.Sprintf("release-%04d", i), Status: statusOutdated, ReleaseNotesURL: notes})
+	}
+	config := notificationConfig{
+		Route:     notificationRoute{Receiver: "hook"},
+		Receivers: []receiverConfig{{Name: "hook", Webhook: &webhookConfig{URL: server.URL}}},
+	}
+
+	var warnings []warning
+	require.NoError(t, sendNotifications(context.Background(), config, result, &warnings))
+	assert.Empty(t, warnings)
+	require.Len(t, sizes, 2)
+	for _, size := range sizes {
+		assert.LessOrEqual(t, size, webhookMaxBytes)
+	}
+}
+
+// Test that long Slack messages are split on line boundaries
+func TestSplitText(t *testing.T) {
+	assert.Equal(t, []string{"short\n"}, splitText("short\n", 100))
+
+	text := strings.Repeat("• default/release (chart): 1.0.0 --> 2.0.0\n", 10)
+	chunks := splitText(text, 120)
+	require.Len(t, chunks, 5)
+	assert.Equal(t, "(1/5) • default/release (chart): 1.0.0 --> 2.0.0\n• default/release (chart): 1.0.0 --> 2.0.0\n", chunks[0])
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len([]rune(chunk)), 120)
+	}
+}
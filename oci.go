@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ociCacheTTL and ociOffline control the reuse of cached OCI tags, set by
+// the --oci-cache-ttl and --fast flags of scan-dir
+var (
+	ociCacheTTL = defaultOCICacheTTL
+	ociOffline  bool
+)
+
+// Defaults of the OCI tag lookups
+const (
+	// defaultOCICacheTTL is how long listed tags are reused from the cache
+	defaultOCICacheTTL = time.Hour
+	// ociConcurrency is the number of charts whose tags are listed at once
+	ociConcurrency = 8
+)
+
+// ociTagsFile returns the location of the cache of listed OCI tags
+func ociTagsFile() string {
+	return helmCachePath("whatup", "oci-tags.yaml")
+}
+
+// ociTags are the tags of a chart in an OCI registry and when they were listed
+type ociTags struct {
+	Tags   []string  `yaml:"tags"`
+	Listed time.Time `yaml:"listed"`
+}
+
+// ociTagCache caches the tags of OCI charts by reference, e.g.
+// registry.example.com/charts/nginx, so registries aren't asked again within
+// the TTL. Offline caches never list tags and use cached tags of any age.
+type ociTagCache struct {
+	path    string
+	ttl     time.Duration
+	offline bool
+
+	mu      sync.Mutex
+	entries map[string]ociTags
+	changed bool
+}
+
+// loadOCITagCache reads the tag cache, returning an empty cache if none exists yet
+func loadOCITagCache(path string, ttl time.Duration, offline bool) (*ociTagCache, error) {
+	cache := &ociTagCache{path: path, ttl: ttl, offline: offline, entries: map[string]ociTags{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cache, nil
+		}
+		return nil, fmt.Errorf("failed to read OCI tag cache: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cache.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse OCI tag cache %s: %w", path, err)
+	}
+	if cache.entries == nil {
+		cache.entries = map[string]ociTags{}
+	}
+	return cache, nil
+}
+
+// get returns the cached tags of a chart when they are recent enough
+func (c *ociTagCache) get(ref string, now time.Time) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[ref]
+	if !ok || (!c.offline && now.Sub(entry.Listed) >= c.ttl) {
+		return nil, false
+	}
+	return entry.Tags, true
+}
+
+// put records the tags listed for a chart
+func (c *ociTagCache) put(ref string, tags []string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[ref] = ociTags{Tags: tags, Listed: now}
+	c.changed = true
+}
+
+// save writes the cache to disk if tags were listed
+func (c *ociTagCache) save() error {
+	if !c.changed {
+		return nil
+	}
+	data, err := yaml.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal OCI tag cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create OCI tag cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write OCI tag cache: %w", err)
+	}
+	return nil
+}
+
+// listOCITags returns the tags of every chart reference. Every reference is
+// listed once, up to ociConcurrency at a time, unless its cached tags are
+// recent enough; the references that couldn't be listed are returned with
+// their errors.
+func listOCITags(ctx context.Context, refs []string, cache *ociTagCache, list func(ref string) ([]string, error)) (map[string][]string, map[string]error) {
+	tags := map[string][]string{}
+	failed := map[string]error{}
+
+	var pending []string
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		if cached, ok := cache.get(ref, time.Now()); ok {
+			tags[ref] = cached
+		} else if cache.offline {
+			failed[ref] = fmt.Errorf("the tags of %s are not cached", ref)
+		} else {
+			pending = append(pending, ref)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, ociConcurrency)
+	for _, ref := range pending {
+		wg.Add(1)
+		go func(ref string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			var listed []string
+			err := ctx.Err()
+			if err == nil {
+				listed, err = list(ref)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[ref] = err
+				return
+			}
+			tags[ref] = listed
+			cache.put(ref, listed, time.Now())
+		}(ref)
+	}
+	wg.Wait()
+
+	return tags, failed
+}
+
+// ociDeclared reports whether a chart is declared in an oci:// repository
+func ociDeclared(declarations []chartDeclaration) bool {
+	for _, declaration := range declarations {
+		if strings.HasPrefix(declaration.Repository, "oci://") {
+			return true
+		}
+	}
+	return false
+}
+
+// ociChartRef returns the reference of a chart of an oci:// repository whose
+// tags are listed, e.g. registry.example.com/charts/nginx
+func ociChartRef(repository, chartName string) string {
+	return strings.TrimPrefix(strings.TrimSuffix(repository, "/"), "oci://") + "/" + chartName
+}
+
+// fetchOCIIndexes lists the tags of the charts declared in oci:// repositories
+// and returns an index per repository, named after its URL, whose chart
+// versions are the tags. Charts whose tags can't be listed are reported as
+// warnings and left out.
+func fetchOCIIndexes(ctx context.Context, declarations []chartDeclaration, cache *ociTagCache, list func(ref string) ([]string, error), warnings *[]warning) []repoIndex {
+	charts := map[string]map[string]string{}
+	var refs []string
+	for _, declaration := range declarations {
+		if !strings.HasPrefix(declaration.Repository, "oci://") {
+			continue
+		}
+		repository := strings.TrimSuffix(declaration.Repository, "/")
+		ref := ociChartRef(repository, declaration.Chart)
+		if charts[repository] == nil {
+			charts[repository] = map[string]string{}
+		}
+		charts[repository][declaration.Chart] = ref
+		refs = append(refs, ref)
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	tags, failed := listOCITags(ctx, refs, cache, list)
+	failedRefs := make([]string, 0, len(failed))
+	for ref := range failed {
+		failedRefs = append(failedRefs, ref)
+	}
+	sort.Strings(failedRefs)
+	for _, ref := range failedRefs {
+		addWarning(warnings, warnOCITagsFailed, "Failed to list the tags of oci://%s: %v", ref, failed[ref])
+	}
+
+	repositories := make([]string, 0, len(charts))
+	for repository := range charts {
+		repositories = append(repositories, repository)
+	}
+	sort.Strings(repositories)
+
+	var indexes []repoIndex
+	for _, repository := range repositories {
+		versions := map[string][]string{}
+		for chartName, ref := range charts[repository] {
+			if listed, ok := tags[ref]; ok {
+				versions[chartName] = listed
+			}
+		}
+		if len(versions) > 0 {
+			indexes = append(indexes, repoIndex{name: repository, helmIndexFile: newOCIIndexFile(versions)})
+		}
+	}
+	return indexes
+}
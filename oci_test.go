@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the tags of every chart are listed once and reused from the cache within the TTL
+func TestListOCITags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oci-tags.yaml")
+	cache, err := loadOCITagCache(path, time.Hour, false)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	list := func(ref string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[ref]++
+		if ref == "registry.example.com/charts/broken" {
+			return nil, errors.New("unauthorized")
+		}
+		return []string{"2.0.0", "1.0.0"}, nil
+	}
+
+	refs := []string{"registry.example.com/charts/nginx", "registry.example.com/charts/redis", "registry.example.com/charts/nginx", "registry.example.com/charts/broken"}
+	tags, failed := listOCITags(context.Background(), refs, cache, list)
+	assert.Equal(t, map[string][]string{
+		"registry.example.com/charts/nginx": {"2.0.0", "1.0.0"},
+		"registry.example.com/charts/redis": {"2.0.0", "1.0.0"},
+	}, tags)
+	assert.EqualError(t, failed["registry.example.com/charts/broken"], "unauthorized")
+	assert.Equal(t, 1, calls["registry.example.com/charts/nginx"])
+	require.NoError(t, cache.save())
+
+	// The next run reuses the cached tags
+	cache, err = loadOCITagCache(path, time.Hour, false)
+	require.NoError(t, err)
+	tags, _ = listOCITags(context.Background(), refs[:2], cache, list)
+	assert.Len(t, tags, 2)
+	assert.Equal(t, 1, calls["registry.example.com/charts/nginx"])
+
+	// Expired tags are listed again, unless offline
+	cache, err = loadOCITagCache(path, 0, false)
+	require.NoError(t, err)
+	listOCITags(context.Background(), refs[:1], cache, list)
+	assert.Equal(t, 2, calls["registry.example.com/charts/nginx"])
+
+	cache, err = loadOCITagCache(path, 0, true)
+	require.NoError(t, err)
+	tags, failed = listOCITags(context.Background(), []string{"registry.example.com/charts/redis", "registry.example.com/charts/grafana"}, cache, list)
+	assert.Len(t, tags, 1)
+	assert.EqualError(t, failed["registry.example.com/charts/grafana"], "the tags of registry.example.com/charts/grafana are not cached")
+	assert.Equal(t, 1, calls["registry.example.com/charts/redis"])
+}
+
+// Test that charts declared in OCI registries are checked against their tags
+func TestScanDeclarationsOCI(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"repositories.yaml": "repositories: []\n",
+		"cache/whatup/oci-tags.yaml": `
+registry.example.com/charts/podinfo:
+  tags: ["6.5.0", "6.4.0"]
+  listed: ` + time.Now().Format(time.RFC3339) + `
+`,
+	})
+	t.Setenv("HELM_REPOSITORY_CONFIG", filepath.Join(dir, "repositories.yaml"))
+	t.Setenv("HELM_REPOSITORY_CACHE", filepath.Join(dir, "cache"))
+	t.Setenv("HELM_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	report, err := scanDeclarations(cmd, []chartDeclaration{
+		{Source: "podinfo.yaml", Release: "podinfo", Namespace: "apps", Chart: "podinfo", Version: "6.4.0", Repository: "oci://registry.example.com/charts/"},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, report.results, 1)
+	assert.Equal(t, statusOutdated, report.results[0].Status)
+	assert.Equal(t, "6.5.0", report.results[0].LatestVersion)
+	assert.Equal(t, "oci://registry.example.com/charts", report.results[0].RepoName)
+}
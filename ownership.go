@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// resolveOwners sets the Owner of every result from the configured annotation
+// of its namespace. Labels with the same key are used as a fallback so both
+// `kubectl annotate` and `kubectl label` style ownership work. Namespaces are
+// only looked up once per run.
+func resolveOwners(ctx context.Context, clientset kubernetes.Interface, annotation string, result []ChartVersionInfo, warnings *[]warning) {
+	owners := make(map[string]string)
+
+	for i := range result {
+		namespace := result[i].Namespace
+		owner, seen := owners[namespace]
+		if !seen {
+			ns, err := clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+			if err != nil {
+				addWarning(warnings, warnOwnerLookupFailed, "Failed to look up the owner of namespace '%s': %v", namespace, err)
+			} else if value, ok := ns.Annotations[annotation]; ok {
+				owner = value
+			} else {
+				owner = ns.Labels[annotation]
+			}
+			owners[namespace] = owner
+		}
+		result[i].Owner = owner
+	}
+}
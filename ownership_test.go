@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Test that owners are resolved from namespace annotations with a label fallback
+func TestResolveOwners(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Annotations: map[string]string{"owner": "payments-team"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: map[string]string{"owner": "shop-team"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "sandbox"}},
+	)
+	result := []ChartVersionInfo{
+		{ReleaseName: "api", Namespace: "payments"},
+		{ReleaseName: "web", Namespace: "shop"},
+		{ReleaseName: "redis", Namespace: "sandbox"},
+		{ReleaseName: "ghost", Namespace: "missing"},
+	}
+
+	var warnings []warning
+	resolveOwners(context.Background(), clientset, "owner", result, &warnings)
+
+	assert.Equal(t, "payments-team", result[0].Owner)
+	assert.Equal(t, "shop-team", result[1].Owner)
+	assert.Empty(t, result[2].Owner)
+	assert.Empty(t, result[3].Owner)
+	assert.Len(t, warnings, 1)
+}
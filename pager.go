@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// defaultPager is used when neither WHATUP_PAGER nor PAGER is set. less
+// provides searching; -R keeps colors and -X leaves the output on screen.
+const defaultPager = "less -RX"
+
+// pagedFormats are the human-readable output formats that may be paged
+var pagedFormats = map[string]bool{
+	outputFormatPlain: true,
+	outputFormatShort: true,
+	outputFormatTable: true,
+	outputFormatWide:  true,
+}
+
+// pagerCommand returns the pager to use, or an empty string when paging is disabled
+func pagerCommand() string {
+	if noPager || (!pagedFormats[outputFormat] && !isCustomColumns(outputFormat)) {
+		return ""
+	}
+	if pager, ok := os.LookupEnv("WHATUP_PAGER"); ok {
+		return pager
+	}
+	if pager, ok := os.LookupEnv("PAGER"); ok {
+		return pager
+	}
+	return defaultPager
+}
+
+// writePaged writes the output to w. When w is the terminal and the output
+// has more lines than the terminal, it is piped through the pager like git
+// and kubectl do. Output is written directly if the pager can't be started.
+func writePaged(ctx context.Context, w io.Writer, output []byte) error {
+	pager := strings.Fields(pagerCommand())
+	file, ok := w.(*os.File)
+	if len(pager) == 0 || !ok || !term.IsTerminal(int(file.Fd())) {
+		_, err := w.Write(output)
+		return err
+	}
+
+	_, height, err := term.GetSize(int(file.Fd()))
+	if err != nil || bytes.Count(output, []byte("\n")) < height {
+		_, err := w.Write(output)
+		return err
+	}
+
+	// #nosec G204 -- the pager is configured by the user running the plugin
+	cmd := exec.CommandContext(ctx, pager[0], pager[1:]...)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout = file
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		logger.Debug("failed to start pager", "pager", pager[0], "error", err)
+		_, err := w.Write(output)
+		return err
+	}
+	// Quitting the pager early is not an error
+	_ = cmd.Wait()
+	return nil
+}
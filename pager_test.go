@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the pager selection and that output which isn't a terminal is never paged
+func TestPager(t *testing.T) {
+	t.Setenv("PAGER", "more")
+	assert.Equal(t, "more", pagerCommand())
+	t.Setenv("WHATUP_PAGER", "less -S")
+	assert.Equal(t, "less -S", pagerCommand())
+
+	noPager = true
+	assert.Empty(t, pagerCommand())
+	noPager = false
+
+	previous := outputFormat
+	defer func() { outputFormat = previous }()
+	outputFormat = outputFormatJSON
+	assert.Empty(t, pagerCommand())
+	outputFormat = outputFormatTable
+
+	var out bytes.Buffer
+	require.NoError(t, writePaged(context.Background(), &out, []byte("a\nb\n")))
+	assert.Equal(t, "a\nb\n", out.String())
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// flagOutsideConstraint marks releases whose installed version doesn't satisfy their version constraint
+const flagOutsideConstraint = "OutsideConstraint"
+
+// pinFile returns the pin file given with --pin-file or in the config file
+func pinFile() string {
+	if pinFilePath != "" {
+		return pinFilePath
+	}
+	return cfg.PinFile
+}
+
+// loadPinRules reads a pin file mapping release names (name or
+// namespace/name) or chart names to semver constraints, e.g.
+//
+//	ingress-nginx: ~4.10
+//	prod/postgres: ">=12.0.0, <13.0.0"
+//
+// Every entry becomes a version rule limiting the reported latest version to
+// its constraint.
+func loadPinRules(path string) ([]versionRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pin file: %w", err)
+	}
+
+	var pins map[string]string
+	if err := yaml.UnmarshalStrict(data, &pins); err != nil {
+		return nil, fmt.Errorf("failed to parse pin file %s: %w", path, err)
+	}
+
+	names := make([]string, 0, len(pins))
+	for name := range pins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := make([]versionRule, 0, len(names))
+	for _, name := range names {
+		rule := versionRule{
+			Source:     path,
+			Releases:   []string{name},
+			Charts:     []string{name},
+			Constraint: pins[name],
+		}
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// outsideConstraintHint returns the hint shown for releases outside their version constraint
+func outsideConstraintHint(versionInfo ChartVersionInfo) string {
+	return fmt.Sprintf("Release %s is installed with version %s of chart %s, which is outside the allowed version range.",
+		versionInfo.ReleaseName,
+		versionInfo.InstalledVersion,
+		versionInfo.ChartName)
+}
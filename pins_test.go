@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that pinned releases are only outdated within their constraint and flagged outside of it
+func TestPinRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pins.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("ingress-nginx: ~4.10\nprod/db: \">=12.0.0, <13.0.0\"\n"), 0o600))
+
+	rules, err := loadPinRules(path)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	releases := []*release.Release{
+		newTestRelease("ingress", "default", "ingress-nginx", "4.10.0"),
+		newTestRelease("db", "prod", "postgresql", "13.1.0"),
+		newTestRelease("db", "staging", "postgresql", "12.0.0"),
+	}
+	repositories := []repoIndex{
+		newTestIndex("ingress-nginx", "4.11.0", "4.10.1", "4.10.0"),
+		newTestIndex("postgresql", "13.2.0", "12.5.0"),
+	}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, rules, nil, &warnings)
+	require.Len(t, result, 3)
+
+	assert.Equal(t, "4.10.1", result[0].LatestVersion)
+	assert.Equal(t, statusOutdated, result[0].Status)
+	assert.Empty(t, result[0].Flags)
+
+	assert.Equal(t, "12.5.0", result[1].LatestVersion)
+	assert.Equal(t, statusUptodate, result[1].Status)
+	assert.True(t, hasFlag(result[1], flagOutsideConstraint))
+	assert.Contains(t, outsideConstraintHint(result[1]), "version 13.1.0 of chart postgresql")
+
+	assert.Equal(t, "13.2.0", result[2].LatestVersion)
+	assert.Equal(t, statusOutdated, result[2].Status)
+
+	require.NoError(t, os.WriteFile(path, []byte("ingress-nginx: not-a-range\n"), 0o600))
+	_, err = loadPinRules(path)
+	assert.Error(t, err)
+}
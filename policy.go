@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// updateTypes are the update types a policy rule can select
+var updateTypes = []string{"major", "minor", "patch"}
+
+// updatePolicy is the content of a policy file
+type updatePolicy struct {
+	Rules []policyRule `yaml:"rules"`
+}
+
+// policyRule is a single rule of a policy file. A rule without selectors
+// applies to every release.
+type policyRule struct {
+	Charts        []string `yaml:"charts"`
+	ChartPatterns []string `yaml:"chartPatterns"`
+	Releases      []string `yaml:"releases"`
+
+	// Ignore reports the matching releases as IGNORED
+	Ignore bool `yaml:"ignore"`
+	// Updates lists the only update types that are reported
+	Updates []string `yaml:"updates"`
+	// IgnoreUpdates lists update types that are not reported
+	IgnoreUpdates []string `yaml:"ignoreUpdates"`
+	// MinAgeDays is the number of days a version has to be published before it is reported
+	MinAgeDays int `yaml:"minAgeDays"`
+	// Severity is assigned to the matching releases when they are outdated
+	Severity string `yaml:"severity"`
+}
+
+// policyFile returns the policy file given with --policy or in the config file
+func policyFile() string {
+	if policyFilePath != "" {
+		return policyFilePath
+	}
+	return cfg.PolicyFile
+}
+
+// loadPolicyRules reads a policy file and translates its rules into version
+// rules, e.g.
+//
+//	rules:
+//	  # Ignore patch bumps cluster-wide
+//	  - ignoreUpdates: [patch]
+//	  # Only report major updates of these charts
+//	  - charts: [cert-manager, ingress-nginx]
+//	    updates: [major]
+//	    severity: high
+//	  # Only report versions that were published a week ago
+//	  - minAgeDays: 7
+//
+// Every rule is evaluated per release; all rules matching a release apply.
+func loadPolicyRules(path string) ([]versionRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy updatePolicy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", path, err)
+	}
+
+	rules := make([]versionRule, 0, len(policy.Rules))
+	for i, policyRule := range policy.Rules {
+		source := fmt.Sprintf("rule %d of %s", i+1, path)
+		for _, kind := range append(policyRule.Updates, policyRule.IgnoreUpdates...) {
+			if !containsString(updateTypes, kind) {
+				return nil, fmt.Errorf("invalid update type %q in %s, expected one of major, minor, patch", kind, source)
+			}
+		}
+		if policyRule.MinAgeDays < 0 {
+			return nil, fmt.Errorf("invalid minAgeDays %d in %s", policyRule.MinAgeDays, source)
+		}
+
+		blocked := append([]string(nil), policyRule.IgnoreUpdates...)
+		if len(policyRule.Updates) > 0 {
+			for _, kind := range updateTypes {
+				if !containsString(policyRule.Updates, kind) {
+					blocked = append(blocked, kind)
+				}
+			}
+		}
+
+		rule := versionRule{
+			Source:         source,
+			Charts:         policyRule.Charts,
+			ChartPatterns:  policyRule.ChartPatterns,
+			Releases:       policyRule.Releases,
+			Ignore:         policyRule.Ignore,
+			BlockedUpdates: blocked,
+			MinAge:         time.Duration(policyRule.MinAgeDays) * 24 * time.Hour,
+			Severity:       policyRule.Severity,
+		}
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
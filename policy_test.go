@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that policy rules filter the reported updates per release and assign severities
+func TestPolicyRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`rules:
+  - ignoreUpdates: [patch]
+  - charts: [cert-manager]
+    updates: [major]
+    severity: high
+  - releases: [prod/api]
+    minAgeDays: 7
+`), 0o600))
+
+	rules, err := loadPolicyRules(path)
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	releases := []*release.Release{
+		newTestRelease("web", "default", "nginx", "1.0.0"),
+		newTestRelease("certs", "default", "cert-manager", "1.0.0"),
+		newTestRelease("api", "prod", "api", "1.0.0"),
+	}
+	api := newTestIndex("api", "1.2.0", "1.1.0", "1.0.0")
+	api.Entries["api"][0].Created = time.Now().Add(-24 * time.Hour)
+	api.Entries["api"][1].Created = time.Now().Add(-30 * 24 * time.Hour)
+	repositories := []repoIndex{
+		newTestIndex("nginx", "1.0.1", "1.0.0"),
+		newTestIndex("cert-manager", "2.0.0", "1.1.0", "1.0.0"),
+		api,
+	}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, rules, nil, &warnings)
+	require.Len(t, result, 3)
+
+	assert.Equal(t, statusUptodate, result[0].Status)
+
+	assert.Equal(t, "2.0.0", result[1].LatestVersion)
+	assert.Equal(t, statusOutdated, result[1].Status)
+	assert.Equal(t, "high", result[1].Severity)
+
+	assert.Equal(t, "1.1.0", result[2].LatestVersion)
+	assert.Empty(t, result[2].Severity)
+}
+
+// Test that invalid policy files are rejected
+func TestPolicyRulesInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	for _, content := range []string{
+		"rules:\n  - updates: [breaking]\n",
+		"rules:\n  - minAgeDays: -1\n",
+		"rules:\n  - unknown: true\n",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := loadPolicyRules(path)
+		assert.Error(t, err, content)
+	}
+}
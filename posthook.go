@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// runPostProcessHook passes the report as JSON to the hook on stdin and
+// replaces it with the report the hook prints on stdout, before the report is
+// formatted and notifications are sent. The hook may print either a full
+// report or only the list of results, in which case the warnings are kept.
+func runPostProcessHook(ctx context.Context, hook string, report scanReport, now time.Time) (scanReport, error) {
+	input, err := json.Marshal(reportEnvelope{
+		GeneratedAt: now.UTC(),
+		Version:     version,
+		Results:     report.results,
+		Warnings:    report.warnings,
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to marshal report for post-process hook: %w", err)
+	}
+
+	var output bytes.Buffer
+	// #nosec G204 -- the hook is configured by the user running the plugin
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return report, fmt.Errorf("post-process hook failed: %w", err)
+	}
+
+	envelope, err := decodeReport(output.Bytes())
+	if err != nil {
+		return report, fmt.Errorf("invalid output of post-process hook: %w", err)
+	}
+
+	report.results = envelope.Results
+	if envelope.Warnings != nil {
+		report.warnings = envelope.Warnings
+	}
+	return report, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the post-process hook can change the report
+func TestRunPostProcessHook(t *testing.T) {
+	report := scanReport{
+		results:  []ChartVersionInfo{{ReleaseName: "web", Status: statusOutdated}},
+		warnings: []warning{{Code: warnStaleIndex, Name: "StaleIndex", Message: "stale"}},
+	}
+
+	hook := writeHook(t, `sed 's/"releaseName":"web"/"releaseName":"web","owner":"team-web"/'`+"\n")
+	updated, err := runPostProcessHook(context.Background(), hook, report, time.Now())
+	require.NoError(t, err)
+	require.Len(t, updated.results, 1)
+	assert.Equal(t, "team-web", updated.results[0].Owner)
+	assert.Equal(t, report.warnings, updated.warnings)
+
+	// Hooks may print only the results
+	hook = writeHook(t, `echo '[{"releaseName": "db", "status": "UPTODATE"}]'`+"\n")
+	updated, err = runPostProcessHook(context.Background(), hook, report, time.Now())
+	require.NoError(t, err)
+	require.Len(t, updated.results, 1)
+	assert.Equal(t, "db", updated.results[0].ReleaseName)
+	assert.Equal(t, report.warnings, updated.warnings)
+
+	_, err = runPostProcessHook(context.Background(), writeHook(t, "exit 1\n"), report, time.Now())
+	assert.Error(t, err)
+
+	_, err = runPostProcessHook(context.Background(), writeHook(t, "echo '{'\n"), report, time.Now())
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// priorityLabel is the release label that sets the priority of a release
+const priorityLabel = "whatup.helm.sh/priority"
+
+// Priority classifications, from most to least important
+const (
+	priorityCritical = "critical"
+	priorityHigh     = "high"
+	priorityMedium   = "medium"
+	priorityLow      = "low"
+)
+
+// priorityRanks orders the priority classifications. Unclassified releases rank last.
+var priorityRanks = map[string]int{
+	priorityCritical: 4,
+	priorityHigh:     3,
+	priorityMedium:   2,
+	priorityLow:      1,
+}
+
+// priorityRule assigns a priority to the releases it matches. The matchers
+// work like the ones of notification routes and use the same labels.
+type priorityRule struct {
+	Priority string            `yaml:"priority"`
+	Match    map[string]string `yaml:"match"`
+	MatchRE  map[string]string `yaml:"match_re"`
+}
+
+// releaseLabelsByKey indexes the labels of the releases by namespace and name
+func releaseLabelsByKey(releases []*helmRelease) map[string]map[string]string {
+	labels := make(map[string]map[string]string, len(releases))
+	for _, rel := range releases {
+		labels[rel.Namespace+"/"+rel.Name] = rel.Labels
+	}
+	return labels
+}
+
+// assignPriorities classifies every result. A priority label on the release
+// wins over the configured rules, of which the first matching one applies.
+// The results are then ordered from the most to the least important.
+func assignPriorities(rules []priorityRule, releaseLabels map[string]map[string]string, result []ChartVersionInfo) error {
+	for _, rule := range rules {
+		if _, ok := priorityRanks[rule.Priority]; !ok {
+			return fmt.Errorf("invalid priority %q in config, accepted priorities: critical, high, medium, low", rule.Priority)
+		}
+	}
+
+	for i := range result {
+		if value, ok := releaseLabels[findingKey(result[i])][priorityLabel]; ok {
+			if _, valid := priorityRanks[value]; valid {
+				result[i].Priority = value
+				continue
+			}
+		}
+
+		labels := findingLabels(result[i])
+		for _, rule := range rules {
+			ok, err := matchLabels(rule.Match, rule.MatchRE, labels)
+			if err != nil {
+				return err
+			}
+			if ok {
+				result[i].Priority = rule.Priority
+				break
+			}
+		}
+	}
+
+	sortByPriority(result)
+
+	return nil
+}
+
+// sortByPriority orders the results from the most to the least important,
+// keeping the existing order of results with the same priority
+func sortByPriority(result []ChartVersionInfo) {
+	sort.SliceStable(result, func(i, j int) bool {
+		return priorityRanks[result[i].Priority] > priorityRanks[result[j].Priority]
+	})
+}
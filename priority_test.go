@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that priorities come from release labels and config rules, and order the results
+func TestAssignPriorities(t *testing.T) {
+	rules := []priorityRule{
+		{Priority: priorityCritical, MatchRE: map[string]string{"release": "payment-.*"}},
+		{Priority: priorityLow, Match: map[string]string{"namespace": "sandbox"}},
+	}
+	releaseLabels := map[string]map[string]string{
+		"apps/web": {priorityLabel: priorityHigh},
+	}
+	result := []ChartVersionInfo{
+		{ReleaseName: "redis", Namespace: "sandbox"},
+		{ReleaseName: "untagged", Namespace: "apps"},
+		{ReleaseName: "web", Namespace: "apps"},
+		{ReleaseName: "payment-gateway", Namespace: "prod"},
+	}
+
+	require.NoError(t, assignPriorities(rules, releaseLabels, result))
+
+	assert.Equal(t, "payment-gateway", result[0].ReleaseName)
+	assert.Equal(t, priorityCritical, result[0].Priority)
+	assert.Equal(t, "web", result[1].ReleaseName)
+	assert.Equal(t, priorityHigh, result[1].Priority)
+	assert.Equal(t, "redis", result[2].ReleaseName)
+	assert.Equal(t, priorityLow, result[2].Priority)
+	assert.Equal(t, "untagged", result[3].ReleaseName)
+	assert.Empty(t, result[3].Priority)
+}
+
+// Test that unknown priorities in the config are rejected
+func TestAssignPrioritiesInvalid(t *testing.T) {
+	err := assignPriorities([]priorityRule{{Priority: "urgent"}}, nil, nil)
+	assert.Error(t, err)
+}
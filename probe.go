@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// probeTimeout bounds the download of a single well-known repository index
+const probeTimeout = 60 * time.Second
+
+// maxIndexSize limits the size of a downloaded repository index
+const maxIndexSize = 256 << 20
+
+// wellKnownRepository is a public chart repository that is probed for charts
+// that could not be found in the locally configured repositories
+type wellKnownRepository struct {
+	Name string `yaml:"name" json:"name"`
+	URL  string `yaml:"url" json:"url"`
+}
+
+// defaultWellKnownRepositories is used when the config does not list any
+var defaultWellKnownRepositories = []wellKnownRepository{
+	{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"},
+	{Name: "prometheus-community", URL: "https://prometheus-community.github.io/helm-charts"},
+	{Name: "ingress-nginx", URL: "https://kubernetes.github.io/ingress-nginx"},
+	{Name: "jetstack", URL: "https://charts.jetstack.io"},
+	{Name: "grafana", URL: "https://grafana.github.io/helm-charts"},
+	{Name: "argo", URL: "https://argoproj.github.io/argo-helm"},
+	{Name: "external-secrets", URL: "https://charts.external-secrets.io"},
+	{Name: "metrics-server", URL: "https://kubernetes-sigs.github.io/metrics-server"},
+	{Name: "hashicorp", URL: "https://helm.releases.hashicorp.com"},
+	{Name: "elastic", URL: "https://helm.elastic.co"},
+}
+
+// fetchRemoteIndex downloads and parses the index.yaml of a chart repository
+func fetchRemoteIndex(ctx context.Context, client *http.Client, repoURL string) (*helmIndexFile, error) {
+	indexURL := strings.TrimSuffix(repoURL, "/") + "/index.yaml"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create index request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", indexURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", indexURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIndexSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", indexURL, err)
+	}
+
+	idx := &helmIndexFile{}
+	if err := yaml.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", indexURL, err)
+	}
+	idx.SortEntries()
+
+	return idx, nil
+}
+
+// probeWellKnownRepositories looks up the charts of UNKNOWN results in public
+// repositories that are not configured locally. Matches are reported as a
+// suggested repository together with the latest version found there; the
+// status stays UNKNOWN since the chart may only share its name.
+func probeWellKnownRepositories(
+	ctx context.Context,
+	candidates []wellKnownRepository,
+	repoFileData *helmRepoFile,
+	result []ChartVersionInfo,
+	warnings *[]warning,
+) {
+	var unknown []int
+	for i := range result {
+		if result[i].Status == statusUnknown {
+			unknown = append(unknown, i)
+		}
+	}
+	if len(unknown) == 0 {
+		return
+	}
+
+	configured := make(map[string]bool)
+	for _, url := range repositoryURLs(repoFileData) {
+		configured[strings.TrimSuffix(url, "/")] = true
+	}
+
+	client := &http.Client{Timeout: repoClientTimeout(probeTimeout)}
+	for _, candidate := range candidates {
+		if configured[strings.TrimSuffix(candidate.URL, "/")] {
+			continue
+		}
+
+		var idx *helmIndexFile
+		for _, i := range unknown {
+			if result[i].SuggestedRepository != nil {
+				continue
+			}
+
+			if idx == nil {
+				var err error
+				idx, err = fetchRemoteIndex(ctx, client, candidate.URL)
+				if err != nil {
+					addWarning(warnings, warnProbeFailed, "Failed to probe repository '%s': %v", candidate.Name, err)
+					break
+				}
+			}
+
+			latest := findLatestVersion(idx.Entries[result[i].ChartName], &helmRepoFile{}, new(string), func(string, time.Time) bool { return true })
+			if latest == "" {
+				continue
+			}
+
+			suggestion := candidate
+			result[i].SuggestedRepository = &suggestion
+			result[i].LatestVersion = latest
+			addWarning(warnings, warnRepoNotAdded, "Chart '%s' of release '%s' is available in repository '%s' (%s) which you haven't added",
+				result[i].ChartName, result[i].ReleaseName, candidate.Name, candidate.URL)
+		}
+	}
+}
+
+// wellKnownRepositories returns the repositories to probe
+func wellKnownRepositories() []wellKnownRepository {
+	if len(cfg.WellKnownRepositories) > 0 {
+		return cfg.WellKnownRepositories
+	}
+	return defaultWellKnownRepositories
+}
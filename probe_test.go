@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const testRemoteIndex = `apiVersion: v1
+entries:
+  redis:
+    - name: redis
+      version: 17.0.0
+    - name: redis
+      version: 18.1.0
+`
+
+// Test that unknown charts are looked up in well-known repositories that are not configured
+func TestProbeWellKnownRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/index.yaml", r.URL.Path)
+		_, _ = w.Write([]byte(testRemoteIndex))
+	}))
+	defer server.Close()
+
+	candidates := []wellKnownRepository{
+		{Name: "configured", URL: "https://charts.example.com"},
+		{Name: "public", URL: server.URL},
+	}
+	repoFileData := &repo.File{Repositories: []*repo.Entry{{Name: "mine", URL: "https://charts.example.com/"}}}
+	result := []ChartVersionInfo{
+		{ReleaseName: "cache", ChartName: "redis", InstalledVersion: "17.0.0", Status: statusUnknown},
+		{ReleaseName: "private", ChartName: "private-app", Status: statusUnknown},
+		{ReleaseName: "web", ChartName: "nginx", Status: statusUptodate},
+	}
+
+	var warnings []warning
+	probeWellKnownRepositories(context.Background(), candidates, repoFileData, result, &warnings)
+
+	require.NotNil(t, result[0].SuggestedRepository)
+	assert.Equal(t, "public", result[0].SuggestedRepository.Name)
+	assert.Equal(t, "18.1.0", result[0].LatestVersion)
+	assert.Equal(t, statusUnknown, result[0].Status)
+	assert.Nil(t, result[1].SuggestedRepository)
+	assert.Nil(t, result[2].SuggestedRepository)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, warnRepoNotAdded, warnings[0].Code)
+	assert.Contains(t, warnings[0].Message, "which you haven't added")
+}
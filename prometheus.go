@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// outputFormatPrometheus renders the results as metrics for the textfile
+// collector of the node_exporter
+const outputFormatPrometheus = "prometheus"
+
+// prometheusLabelEscaper escapes label values in the text exposition format
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusLabels renders the labels identifying the release of a result,
+// followed by the extra label name and value pairs. The cluster label is only
+// added for fleet scans.
+func prometheusLabels(versionInfo ChartVersionInfo, withCluster bool, extra ...string) string {
+	labels := []string{
+		"release", versionInfo.ReleaseName,
+		"namespace", versionInfo.Namespace,
+		"chart", versionInfo.ChartName,
+		"repo", versionInfo.RepoName,
+	}
+	if withCluster {
+		labels = append(labels, "cluster", versionInfo.Cluster)
+	}
+	labels = append(labels, extra...)
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], prometheusLabelEscaper.Replace(labels[i+1])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatPrometheus writes a gauge per release telling whether it is outdated,
+// labeled with its priority and update severity, and how many versions it is
+// behind, and the time of the run so stale files can be alerted on
+func formatPrometheus(w io.Writer, result []ChartVersionInfo, now time.Time) {
+	withCluster := false
+	for _, versionInfo := range result {
+		withCluster = withCluster || versionInfo.Cluster != ""
+	}
+
+	fmt.Fprintln(w, "# HELP helm_whatup_release_outdated Whether a newer version of the chart of the release is available.")
+	fmt.Fprintln(w, "# TYPE helm_whatup_release_outdated gauge")
+	for _, versionInfo := range result {
+		outdated := 0
+		if versionInfo.Status == statusOutdated {
+			outdated = 1
+		}
+		labels := prometheusLabels(versionInfo, withCluster, "priority", cfg.Vocabulary.priority(versionInfo.Priority), "update_severity", cfg.Vocabulary.severity(versionInfo.UpdateSeverity))
+		fmt.Fprintf(w, "helm_whatup_release_outdated%s %d\n", labels, outdated)
+	}
+
+	fmt.Fprintln(w, "# HELP helm_whatup_versions_behind Number of chart versions released since the installed one.")
+	fmt.Fprintln(w, "# TYPE helm_whatup_versions_behind gauge")
+	for _, versionInfo := range result {
+		fmt.Fprintf(w, "helm_whatup_versions_behind%s %d\n", prometheusLabels(versionInfo, withCluster), versionInfo.VersionsBehind)
+	}
+
+	fmt.Fprintln(w, "# HELP helm_whatup_last_run_timestamp_seconds Time the releases were last checked.")
+	fmt.Fprintln(w, "# TYPE helm_whatup_last_run_timestamp_seconds gauge")
+	fmt.Fprintf(w, "helm_whatup_last_run_timestamp_seconds %d\n", now.Unix())
+}
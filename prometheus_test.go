@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that every release is exported as textfile collector metrics
+func TestFormatPrometheus(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", RepoName: "bitnami", Status: statusOutdated, VersionsBehind: 3, Priority: priorityCritical, UpdateSeverity: "MAJOR"},
+		{ReleaseName: "db", Namespace: "prod", ChartName: "postgresql", RepoName: "bitnami", Status: statusUptodate},
+		{ReleaseName: `odd"name`, Namespace: "prod", ChartName: "custom", Status: statusUnknown},
+	}
+
+	var out bytes.Buffer
+	formatPrometheus(&out, result, time.Unix(1717200000, 0))
+	assert.Equal(t, `# HELP helm_whatup_release_outdated Whether a newer version of the chart of the release is available.
+# TYPE helm_whatup_release_outdated gauge
+helm_whatup_release_outdated{release="web",namespace="prod",chart="nginx",repo="bitnami",priority="critical",update_severity="MAJOR"} 1
+helm_whatup_release_outdated{release="db",namespace="prod",chart="postgresql",repo="bitnami",priority="",update_severity=""} 0
+helm_whatup_release_outdated{release="odd\"name",namespace="prod",chart="custom",repo="",priority="",update_severity=""} 0
+# HELP helm_whatup_versions_behind Number of chart versions released since the installed one.
+# TYPE helm_whatup_versions_behind gauge
+helm_whatup_versions_behind{release="web",namespace="prod",chart="nginx",repo="bitnami"} 3
+helm_whatup_versions_behind{release="db",namespace="prod",chart="postgresql",repo="bitnami"} 0
+helm_whatup_versions_behind{release="odd\"name",namespace="prod",chart="custom",repo=""} 0
+# HELP helm_whatup_last_run_timestamp_seconds Time the releases were last checked.
+# TYPE helm_whatup_last_run_timestamp_seconds gauge
+helm_whatup_last_run_timestamp_seconds 1717200000
+`, out.String())
+
+	out.Reset()
+	formatPrometheus(&out, []ChartVersionInfo{{ReleaseName: "web", Namespace: "prod", Cluster: "eu-1", Status: statusOutdated}}, time.Unix(0, 0))
+	assert.Contains(t, out.String(), `helm_whatup_release_outdated{release="web",namespace="prod",chart="",repo="",cluster="eu-1",priority="",update_severity=""} 1`)
+}
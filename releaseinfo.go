@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+// releaseStatusDeployed is the Helm status of a healthy release
+const releaseStatusDeployed = "deployed"
+
+// addReleaseInfo records the Helm status, revision, last deployment time and
+// chart deprecation of the release behind every result, so the health of an
+// outdated release can be checked before planning its upgrade
+func addReleaseInfo(releases []*helmRelease, result []ChartVersionInfo) {
+	byKey := make(map[string]*helmRelease, len(releases))
+	for _, rel := range releases {
+		byKey[rel.Namespace+"/"+rel.Name] = rel
+	}
+
+	for i := range result {
+		rel, ok := byKey[findingKey(result[i])]
+		if !ok {
+			continue
+		}
+		result[i].Revision = rel.Version
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			result[i].Deprecated = rel.Chart.Metadata.Deprecated
+		}
+		if rel.Info == nil {
+			continue
+		}
+		result[i].ReleaseStatus = rel.Info.Status.String()
+		if !rel.Info.LastDeployed.IsZero() {
+			lastDeployed := rel.Info.LastDeployed.Time
+			result[i].LastDeployed = &lastDeployed
+		}
+	}
+}
+
+// releaseStatusColumn renders the Helm status of releases that are not
+// healthy, leaving the cell empty for deployed releases
+func releaseStatusColumn(versionInfo ChartVersionInfo) string {
+	if versionInfo.ReleaseStatus == releaseStatusDeployed {
+		return ""
+	}
+	return versionInfo.ReleaseStatus
+}
+
+// releaseStatusHint returns the hint shown for outdated releases that are not healthy
+func releaseStatusHint(versionInfo ChartVersionInfo) string {
+	return fmt.Sprintf("Release %s is %s at revision %d. Bring it back to a deployed state before upgrading it.",
+		versionInfo.ReleaseName,
+		versionInfo.ReleaseStatus,
+		versionInfo.Revision)
+}
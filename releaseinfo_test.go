@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+// Test that the Helm status, revision and last deployment time are recorded for every result
+func TestAddReleaseInfo(t *testing.T) {
+	deployed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	web := newTestRelease("web", "default", "nginx", "1.0.0")
+	web.Version = 7
+	web.Info.LastDeployed = helmtime.Time{Time: deployed}
+	db := newTestRelease("db", "default", "postgresql", "12.0.0")
+	db.Info.Status = release.StatusFailed
+
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "default", Status: statusOutdated},
+		{ReleaseName: "db", Namespace: "default", Status: statusOutdated},
+	}
+	addReleaseInfo([]*release.Release{web, db}, result)
+
+	assert.Equal(t, "deployed", result[0].ReleaseStatus)
+	assert.Equal(t, 7, result[0].Revision)
+	assert.Equal(t, deployed, *result[0].LastDeployed)
+	assert.Empty(t, releaseStatusColumn(result[0]))
+
+	assert.Equal(t, "failed", result[1].ReleaseStatus)
+	assert.Nil(t, result[1].LastDeployed)
+	assert.Equal(t, "failed", releaseStatusColumn(result[1]))
+	assert.Contains(t, releaseStatusHint(result[1]), "Release db is failed at revision 1.")
+}
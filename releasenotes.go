@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// releaseNotesURL derives a best-effort URL of the release notes of a chart
+// version from the GitHub repository in the chart's sources (or home). Charts
+// published from a monorepo with chart-releaser are tagged <chart>-<version>,
+// charts living in a repository of their own name are tagged v<version>.
+func releaseNotesURL(entries helmChartVersions, chartName, chartVersion string) string {
+	for _, entry := range entries {
+		if entry.Version != chartVersion || entry.Metadata == nil {
+			continue
+		}
+		candidates := append([]string{}, entry.Sources...)
+		if entry.Home != "" {
+			candidates = append(candidates, entry.Home)
+		}
+		for _, source := range candidates {
+			owner, repo, ok := githubRepository(source)
+			if !ok {
+				continue
+			}
+			tag := chartName + "-" + chartVersion
+			if strings.EqualFold(repo, chartName) {
+				tag = "v" + strings.TrimPrefix(chartVersion, "v")
+			}
+			return "https://github.com/" + owner + "/" + repo + "/releases/tag/" + url.PathEscape(tag)
+		}
+	}
+	return ""
+}
+
+// githubRepository extracts the owner and name of a GitHub repository from a
+// URL such as https://github.com/kubernetes/ingress-nginx/tree/main/charts
+func githubRepository(source string) (string, string, bool) {
+	parsed, err := url.Parse(source)
+	if err != nil || !strings.EqualFold(parsed.Hostname(), "github.com") {
+		return "", "", false
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) < 2 || segments[0] == "" || segments[1] == "" {
+		return "", "", false
+	}
+	return segments[0], strings.TrimSuffix(segments[1], ".git"), true
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that release notes URLs are derived from the GitHub sources of the chart
+func TestReleaseNotesURL(t *testing.T) {
+	ingress := newTestIndex("ingress-nginx", "4.11.0").Entries["ingress-nginx"]
+	ingress[0].Sources = []string{"https://example.com/docs", "https://github.com/kubernetes/ingress-nginx"}
+	assert.Equal(t, "https://github.com/kubernetes/ingress-nginx/releases/tag/v4.11.0", releaseNotesURL(ingress, "ingress-nginx", "4.11.0"))
+
+	redis := newTestIndex("redis", "18.0.0").Entries["redis"]
+	redis[0].Home = "https://github.com/bitnami/charts.git"
+	assert.Equal(t, "https://github.com/bitnami/charts/releases/tag/redis-18.0.0", releaseNotesURL(redis, "redis", "18.0.0"))
+
+	assert.Empty(t, releaseNotesURL(redis, "redis", "17.0.0"))
+	assert.Empty(t, releaseNotesURL(newTestIndex("web", "1.0.0").Entries["web"], "web", "1.0.0"))
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// renovateHelmManagers are the Renovate managers that update Helm charts
+var renovateHelmManagers = map[string]bool{
+	"argocd":            true,
+	"fleet":             true,
+	"flux":              true,
+	"helm-requirements": true,
+	"helmfile":          true,
+	"helmsman":          true,
+	"helmv3":            true,
+}
+
+// renovateConfig is the subset of a renovate.json that is translated into version rules
+type renovateConfig struct {
+	IgnoreDeps   []string              `json:"ignoreDeps"`
+	PackageRules []renovatePackageRule `json:"packageRules"`
+}
+
+// renovatePackageRule is a single entry of the Renovate packageRules list
+type renovatePackageRule struct {
+	MatchManagers        []string `json:"matchManagers"`
+	MatchPackageNames    []string `json:"matchPackageNames"`
+	MatchDepNames        []string `json:"matchDepNames"`
+	MatchPackagePatterns []string `json:"matchPackagePatterns"`
+	MatchPackagePrefixes []string `json:"matchPackagePrefixes"`
+	MatchUpdateTypes     []string `json:"matchUpdateTypes"`
+	PackageNames         []string `json:"packageNames"`
+	PackagePatterns      []string `json:"packagePatterns"`
+	Enabled              *bool    `json:"enabled"`
+	AllowedVersions      string   `json:"allowedVersions"`
+}
+
+// appliesToHelm reports whether the rule targets one of the Helm managers
+func (r *renovatePackageRule) appliesToHelm() bool {
+	if len(r.MatchManagers) == 0 {
+		return true
+	}
+	for _, manager := range r.MatchManagers {
+		if renovateHelmManagers[manager] {
+			return true
+		}
+	}
+	return false
+}
+
+// loadRenovateRules reads a renovate.json and translates its Helm package
+// rules into version rules:
+//
+//   - ignoreDeps and rules with `enabled: false` ignore the matching charts
+//   - `enabled: false` together with matchUpdateTypes only blocks those update types
+//   - allowedVersions becomes a semver constraint, or a version pattern when it
+//     is a /regex/; an exact version pins the chart
+func loadRenovateRules(path string) ([]versionRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Renovate config: %w", err)
+	}
+
+	var config renovateConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse Renovate config %s: %w", path, err)
+	}
+
+	var rules []versionRule
+	if len(config.IgnoreDeps) > 0 {
+		rules = append(rules, versionRule{Source: path, Charts: config.IgnoreDeps, Ignore: true})
+	}
+
+	for i := range config.PackageRules {
+		packageRule := &config.PackageRules[i]
+		if !packageRule.appliesToHelm() {
+			continue
+		}
+
+		rule := versionRule{Source: path}
+		rule.Charts = append(rule.Charts, packageRule.MatchPackageNames...)
+		rule.Charts = append(rule.Charts, packageRule.MatchDepNames...)
+		rule.Charts = append(rule.Charts, packageRule.PackageNames...)
+		rule.ChartPatterns = append(rule.ChartPatterns, packageRule.MatchPackagePatterns...)
+		rule.ChartPatterns = append(rule.ChartPatterns, packageRule.PackagePatterns...)
+		for _, prefix := range packageRule.MatchPackagePrefixes {
+			rule.ChartPatterns = append(rule.ChartPatterns, "^"+regexp.QuoteMeta(prefix))
+		}
+
+		if packageRule.Enabled != nil && !*packageRule.Enabled {
+			for _, updateType := range packageRule.MatchUpdateTypes {
+				if updateType == updateMajor || updateType == updateMinor || updateType == updatePatch {
+					rule.BlockedUpdates = append(rule.BlockedUpdates, updateType)
+				}
+			}
+			// Rules that only disable update types we don't track are not translated
+			rule.Ignore = len(packageRule.MatchUpdateTypes) == 0
+			if !rule.Ignore && len(rule.BlockedUpdates) == 0 {
+				continue
+			}
+		}
+
+		if allowed := packageRule.AllowedVersions; allowed != "" {
+			if len(allowed) > 1 && strings.HasPrefix(allowed, "/") && strings.HasSuffix(allowed, "/") {
+				rule.VersionPattern = allowed[1 : len(allowed)-1]
+			} else {
+				rule.Constraint = allowed
+			}
+		}
+
+		if !rule.Ignore && rule.Constraint == "" && rule.VersionPattern == "" && len(rule.BlockedUpdates) == 0 {
+			continue
+		}
+
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
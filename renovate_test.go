@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRenovateConfig = `{
+  "ignoreDeps": ["legacy-app"],
+  "packageRules": [
+    {"matchManagers": ["npm"], "matchPackageNames": ["left-pad"], "enabled": false},
+    {"matchManagers": ["helmv3"], "matchPackageNames": ["ingress-nginx"], "allowedVersions": "~4.10"},
+    {"matchManagers": ["helmv3"], "matchPackageNames": ["redis"], "allowedVersions": "/^17\\./"},
+    {"matchPackagePrefixes": ["kube-"], "matchUpdateTypes": ["major"], "enabled": false},
+    {"matchDepNames": ["postgresql"], "enabled": false}
+  ]
+}`
+
+// Test that Renovate Helm package rules are translated into version rules
+func TestLoadRenovateRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "renovate.json")
+	require.NoError(t, os.WriteFile(path, []byte(testRenovateConfig), 0o600))
+
+	rules, err := loadRenovateRules(path)
+	require.NoError(t, err)
+	require.Len(t, rules, 5)
+
+	assert.True(t, matchingRules(rules, "app", "default", "legacy-app").ignored())
+	assert.True(t, matchingRules(rules, "db", "default", "postgresql").ignored())
+	assert.False(t, matchingRules(rules, "pad", "default", "left-pad").ignored())
+
+	nginx := matchingRules(rules, "ingress", "default", "ingress-nginx")
+	assert.True(t, nginx.allows("4.10.0", "4.10.3"))
+	assert.False(t, nginx.allows("4.10.0", "4.11.0"))
+
+	redis := matchingRules(rules, "cache", "default", "redis")
+	assert.True(t, redis.allows("17.0.0", "17.3.1"))
+	assert.False(t, redis.allows("17.0.0", "18.0.0"))
+
+	kube := matchingRules(rules, "metrics", "default", "kube-state-metrics")
+	assert.True(t, kube.allows("4.0.0", "4.2.0"))
+	assert.False(t, kube.allows("4.0.0", "5.0.0"))
+}
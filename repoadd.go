@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// helmBinary returns the Helm binary that invoked the plugin
+func helmBinary() string {
+	if bin := os.Getenv("HELM_BIN"); bin != "" {
+		return bin
+	}
+	return "helm"
+}
+
+// repoAddSuggestions returns the distinct repositories that were discovered
+// for unresolved charts and are not configured locally, sorted by name
+func repoAddSuggestions(result []ChartVersionInfo, repoURLs map[string]string) []wellKnownRepository {
+	configured := make(map[string]bool, len(repoURLs))
+	for _, repoURL := range repoURLs {
+		configured[strings.TrimSuffix(repoURL, "/")] = true
+	}
+
+	seen := make(map[string]bool)
+	var suggestions []wellKnownRepository
+	for _, versionInfo := range result {
+		suggestion := versionInfo.SuggestedRepository
+		if suggestion == nil {
+			continue
+		}
+		repoURL := strings.TrimSuffix(suggestion.URL, "/")
+		if configured[repoURL] || seen[repoURL] {
+			continue
+		}
+		seen[repoURL] = true
+		suggestions = append(suggestions, *suggestion)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].Name < suggestions[j].Name
+	})
+	return suggestions
+}
+
+// printRepoAddSuggestions prints the commands that add the suggested repositories
+func printRepoAddSuggestions(w io.Writer, suggestions []wellKnownRepository) {
+	if len(suggestions) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nSome charts were found in repositories you haven't added. Add them with:")
+	for _, suggestion := range suggestions {
+		fmt.Fprintf(w, "  %s repo add %s %s\n", helmBinary(), suggestion.Name, suggestion.URL)
+	}
+}
+
+// addRepositories runs `helm repo add` for every suggested repository so the
+// next run can resolve the charts from the local repository configuration.
+// Repositories that can't be added are reported as warnings.
+func addRepositories(ctx context.Context, suggestions []wellKnownRepository, warnings *[]warning) {
+	for _, suggestion := range suggestions {
+		if dryRun {
+			dryRunf("%s repo add %s %s", helmBinary(), suggestion.Name, suggestion.URL)
+			continue
+		}
+		// #nosec G204 -- the Helm binary is provided by Helm itself when running the plugin
+		cmd := exec.CommandContext(ctx, helmBinary(), "repo", "add", suggestion.Name, suggestion.URL)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			addWarning(warnings, warnRepoAddFailed, "Failed to add repository %s (%s): %v", suggestion.Name, suggestion.URL, err)
+		}
+	}
+}
+
+// isRepositoryURL reports whether an annotation value looks like a repository URL
+func isRepositoryURL(value string) bool {
+	return strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "http://")
+}
+
+// suggestedRepoName derives a repository name from its URL, using the last
+// path segment (e.g. bitnami for https://charts.bitnami.com/bitnami) or
+// otherwise the host name without its top-level domain
+func suggestedRepoName(repoURL string) string {
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return repoURL
+	}
+	if segments := strings.Split(strings.Trim(parsed.Path, "/"), "/"); segments[len(segments)-1] != "" {
+		return segments[len(segments)-1]
+	}
+	host := parsed.Hostname()
+	if i := strings.LastIndex(host, "."); i > 0 {
+		host = host[:i]
+	}
+	return strings.ReplaceAll(host, ".", "-")
+}
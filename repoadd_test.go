@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that suggestions are deduplicated and skip configured repositories
+func TestRepoAddSuggestions(t *testing.T) {
+	bitnami := &wellKnownRepository{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"}
+	result := []ChartVersionInfo{
+		{ReleaseName: "cache", SuggestedRepository: bitnami},
+		{ReleaseName: "db", SuggestedRepository: bitnami},
+		{ReleaseName: "web", SuggestedRepository: &wellKnownRepository{Name: "mine", URL: "https://charts.example.com/"}},
+		{ReleaseName: "api"},
+	}
+
+	suggestions := repoAddSuggestions(result, map[string]string{"mine": "https://charts.example.com"})
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, "bitnami", suggestions[0].Name)
+
+	t.Setenv("HELM_BIN", "helm")
+	var out bytes.Buffer
+	printRepoAddSuggestions(&out, suggestions)
+	assert.Contains(t, out.String(), "helm repo add bitnami https://charts.bitnami.com/bitnami")
+}
+
+// Test that discovered repositories are added with the Helm binary
+func TestAddRepositories(t *testing.T) {
+	suggestions := []wellKnownRepository{
+		{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"},
+		{Name: "jetstack", URL: "https://charts.jetstack.io"},
+	}
+
+	var warnings []warning
+	t.Setenv("HELM_BIN", "true")
+	addRepositories(context.Background(), suggestions, &warnings)
+	assert.Empty(t, warnings)
+
+	// A failing repository doesn't stop the others from being added
+	t.Setenv("HELM_BIN", "false")
+	addRepositories(context.Background(), suggestions, &warnings)
+	require.Len(t, warnings, 2)
+	assert.Equal(t, warnRepoAddFailed, warnings[0].Code)
+	assert.Contains(t, warnings[1].Message, "jetstack")
+}
+
+// Test that repository names are derived from their URL
+func TestSuggestedRepoName(t *testing.T) {
+	assert.Equal(t, "bitnami", suggestedRepoName("https://charts.bitnami.com/bitnami"))
+	assert.Equal(t, "ingress-nginx", suggestedRepoName("https://kubernetes.github.io/ingress-nginx/"))
+	assert.Equal(t, "charts-jetstack", suggestedRepoName("https://charts.jetstack.io"))
+}
+
+// Test that the most trustworthy ArtifactHub package is preferred
+func TestBestArtifactHubMatch(t *testing.T) {
+	packages := []artifactHubPackage{
+		{Name: "redis", Stars: 500, Repository: artifactHubRepository{Name: "popular"}},
+		{Name: "redis", Stars: 10, Repository: artifactHubRepository{Name: "verified", VerifiedPublisher: true}},
+		{Name: "redis", Stars: 900, Repository: artifactHubRepository{Name: "other"}},
+	}
+	assert.Equal(t, "verified", bestArtifactHubMatch(packages).Repository.Name)
+	assert.Nil(t, bestArtifactHubMatch(nil))
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
+)
+
+// repoMappings is the mapping cache recording which repository a chart comes
+// from when several configured repositories provide a chart of the same name.
+type repoMappings struct {
+	Charts map[string]string `yaml:"charts"`
+}
+
+// repoMappingsFile returns the location of the mapping cache
+func repoMappingsFile() string {
+	return helmCachePath("whatup", "repo-mappings.yaml")
+}
+
+// loadRepoMappings reads the mapping cache, returning empty mappings if none exist yet
+func loadRepoMappings(path string) (repoMappings, error) {
+	mappings := repoMappings{Charts: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return mappings, nil
+		}
+		return mappings, fmt.Errorf("failed to read repository mappings: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &mappings); err != nil {
+		return mappings, fmt.Errorf("failed to parse repository mappings %s: %w", path, err)
+	}
+	if mappings.Charts == nil {
+		mappings.Charts = map[string]string{}
+	}
+
+	return mappings, nil
+}
+
+// saveRepoMappings writes the mapping cache to disk
+func saveRepoMappings(path string, mappings repoMappings) error {
+	data, err := yaml.Marshal(mappings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repository mappings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create repository mappings directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write repository mappings: %w", err)
+	}
+	return nil
+}
+
+// repoResolver picks the repository of charts that are provided by several
+// configured repositories. It uses the mapping cache first, then asks the
+// user when running in a terminal. Otherwise the first repository is used,
+// unless strict is set.
+type repoResolver struct {
+	path     string
+	mappings repoMappings
+	strict   bool
+	// prompt asks the user to choose among the repositories, nil when not interactive
+	prompt  func(chartName string, repoNames []string) (string, error)
+	changed bool
+}
+
+// newRepoResolver loads the mapping cache and prompts on the terminal if there is one
+func newRepoResolver(path string, strict bool) (*repoResolver, error) {
+	mappings, err := loadRepoMappings(path)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := &repoResolver{path: path, mappings: mappings, strict: strict}
+	if term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd())) {
+		reader := bufio.NewReader(os.Stdin)
+		resolver.prompt = func(chartName string, repoNames []string) (string, error) {
+			return promptRepository(reader, os.Stderr, chartName, repoNames)
+		}
+	}
+
+	return resolver, nil
+}
+
+// candidates returns the repositories to check for the chart, and the
+// repository that was chosen when several provide it. An error is returned
+// for ambiguous charts in strict mode.
+func (r *repoResolver) candidates(chartName string, repositories []repoIndex) ([]repoIndex, string, error) {
+	var providers []repoIndex
+	for _, idx := range repositories {
+		if entries, exists := idx.Entries[chartName]; exists && len(entries) > 0 {
+			providers = append(providers, idx)
+		}
+	}
+	if r == nil || len(providers) < 2 {
+		return providers, "", nil
+	}
+
+	names := make([]string, 0, len(providers))
+	for _, idx := range providers {
+		names = append(names, idx.name)
+	}
+
+	choice := r.mappings.Charts[chartName]
+	if !containsString(names, choice) {
+		choice = ""
+	}
+
+	if choice == "" && r.prompt != nil {
+		selected, err := r.prompt(chartName, names)
+		if err != nil {
+			return nil, "", err
+		}
+		choice = selected
+		r.mappings.Charts[chartName] = choice
+		r.changed = true
+	}
+
+	if choice == "" {
+		if r.strict {
+			return nil, "", fmt.Errorf("chart %s is provided by several repositories (%s), add it to the repository mappings in %s",
+				chartName, strings.Join(names, ", "), r.path)
+		}
+		return providers, "", nil
+	}
+
+	for _, idx := range providers {
+		if idx.name == choice {
+			return []repoIndex{idx}, choice, nil
+		}
+	}
+	return providers, "", nil
+}
+
+// remember records the repository a chart was found to come from, so it is
+// chosen when several repositories provide the chart in later runs
+func (r *repoResolver) remember(chartName, repoName string) {
+	if r == nil || r.mappings.Charts[chartName] == repoName {
+		return
+	}
+	r.mappings.Charts[chartName] = repoName
+	r.changed = true
+}
+
+// save persists the choices made during the run
+func (r *repoResolver) save() error {
+	if r == nil || !r.changed {
+		return nil
+	}
+	if dryRun {
+		dryRunf("write repository mappings to %s", r.path)
+		return nil
+	}
+	return saveRepoMappings(r.path, r.mappings)
+}
+
+// promptRepository asks the user which repository a chart comes from
+func promptRepository(in *bufio.Reader, out io.Writer, chartName string, repoNames []string) (string, error) {
+	fmt.Fprintf(out, "Chart %s is provided by several repositories:\n", chartName)
+	for i, name := range repoNames {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, name)
+	}
+
+	for {
+		fmt.Fprintf(out, "Select the repository of %s [1-%d]: ", chartName, len(repoNames))
+		line, err := in.ReadString('\n')
+		if choice, convErr := strconv.Atoi(strings.TrimSpace(line)); convErr == nil && choice >= 1 && choice <= len(repoNames) {
+			return repoNames[choice-1], nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read repository choice: %w", err)
+		}
+	}
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
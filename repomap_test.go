@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that charts provided by several repositories are disambiguated by the mapping cache or a prompt
+func TestRepoResolver(t *testing.T) {
+	bitnami := newTestIndex("nginx", "15.0.0")
+	bitnami.name = "bitnami"
+	community := newTestIndex("nginx", "1.2.0")
+	community.name = "community"
+	repositories := []repoIndex{bitnami, community, newTestIndex("redis", "7.0.0")}
+
+	var nilResolver *repoResolver
+	candidates, chosen, err := nilResolver.candidates("nginx", repositories)
+	require.NoError(t, err)
+	assert.Len(t, candidates, 2)
+	assert.Empty(t, chosen)
+
+	path := filepath.Join(t.TempDir(), "repo-mappings.yaml")
+	resolver := &repoResolver{path: path, mappings: repoMappings{Charts: map[string]string{}}, strict: true}
+	_, _, err = resolver.candidates("nginx", repositories)
+	assert.ErrorContains(t, err, "bitnami, community")
+
+	candidates, _, err = resolver.candidates("redis", repositories)
+	require.NoError(t, err)
+	assert.Len(t, candidates, 1)
+
+	resolver.prompt = func(chartName string, repoNames []string) (string, error) {
+		assert.Equal(t, []string{"bitnami", "community"}, repoNames)
+		return "community", nil
+	}
+	candidates, chosen, err = resolver.candidates("nginx", repositories)
+	require.NoError(t, err)
+	assert.Equal(t, "community", chosen)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "community", candidates[0].name)
+	require.NoError(t, resolver.save())
+
+	// The choice is persisted and used by the next, non-interactive run
+	mappings, err := loadRepoMappings(path)
+	require.NoError(t, err)
+	resolver = &repoResolver{path: path, mappings: mappings, strict: true}
+
+	var warnings []warning
+	releases := []*release.Release{newTestRelease("web", "default", "nginx", "1.0.0")}
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, resolver, &warnings)
+	require.Len(t, result, 1)
+	assert.Equal(t, "community", result[0].RepoName)
+	assert.Equal(t, "1.2.0", result[0].LatestVersion)
+	assert.Empty(t, warnings)
+}
+
+// Test that the prompt asks again until a valid repository is selected
+func TestPromptRepository(t *testing.T) {
+	var out bytes.Buffer
+	in := bufio.NewReader(strings.NewReader("x\n7\n2\n"))
+
+	choice, err := promptRepository(in, &out, "nginx", []string{"bitnami", "community"})
+	require.NoError(t, err)
+	assert.Equal(t, "community", choice)
+	assert.Contains(t, out.String(), "  2) community\n")
+	assert.Equal(t, 3, strings.Count(out.String(), "Select the repository of nginx [1-2]: "))
+
+	_, err = promptRepository(bufio.NewReader(strings.NewReader("")), &out, "nginx", []string{"bitnami", "community"})
+	assert.Error(t, err)
+}
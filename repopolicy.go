@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// flagUnapprovedRepo marks releases installed from a repository the repository policy doesn't allow
+const flagUnapprovedRepo = "UnapprovedRepository"
+
+// repositoryPolicy restricts the repositories charts may be installed from.
+// Patterns match the name or the URL of a repository and may contain * wildcards.
+type repositoryPolicy struct {
+	Allow   []string `yaml:"allow"`
+	Deny    []string `yaml:"deny"`
+	Enforce bool     `yaml:"enforce"`
+}
+
+// enabled reports whether the policy restricts any repository
+func (p repositoryPolicy) enabled() bool {
+	return len(p.Allow) > 0 || len(p.Deny) > 0
+}
+
+// approved reports whether charts may be installed from the repository. A
+// repository must not match a deny pattern and, when there is an allowlist,
+// must match an allow pattern.
+func (p repositoryPolicy) approved(name, url string) bool {
+	if matchesRepository(p.Deny, name, url) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchesRepository(p.Allow, name, url)
+}
+
+// matchesRepository reports whether one of the patterns matches the repository name or URL
+func matchesRepository(patterns []string, name, url string) bool {
+	for _, pattern := range patterns {
+		re := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$")
+		if re.MatchString(name) || (url != "" && re.MatchString(strings.TrimSuffix(url, "/"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRepositoryPolicy flags the results whose repository the policy doesn't
+// allow. Results without a known repository are skipped.
+func checkRepositoryPolicy(policy repositoryPolicy, repoURLs map[string]string, result []ChartVersionInfo) {
+	if !policy.enabled() {
+		return
+	}
+	for i := range result {
+		if result[i].RepoName == "" {
+			continue
+		}
+		if !policy.approved(result[i].RepoName, repoURLs[result[i].RepoName]) {
+			result[i].Flags = append(result[i].Flags, flagUnapprovedRepo)
+		}
+	}
+}
+
+// repoPolicyHint returns the hint shown for releases violating the repository policy
+func repoPolicyHint(versionInfo ChartVersionInfo) string {
+	return fmt.Sprintf("Release %s is installed from repository %s, which is not approved by the repository policy.",
+		versionInfo.ReleaseName,
+		versionInfo.RepoName)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that releases from repositories outside the allowlist or on the denylist are flagged
+func TestCheckRepositoryPolicy(t *testing.T) {
+	policy := repositoryPolicy{
+		Allow: []string{"stable", "https://charts.example.com/*"},
+		Deny:  []string{"https://charts.example.com/experimental"},
+	}
+	repoURLs := map[string]string{
+		"stable":       "https://charts.helm.sh/stable",
+		"internal":     "https://charts.example.com/platform/",
+		"experimental": "https://charts.example.com/experimental",
+		"bitnami":      "https://charts.bitnami.com/bitnami",
+	}
+	result := []ChartVersionInfo{
+		{ReleaseName: "a", RepoName: "stable"},
+		{ReleaseName: "b", RepoName: "internal"},
+		{ReleaseName: "c", RepoName: "experimental"},
+		{ReleaseName: "d", RepoName: "bitnami"},
+		{ReleaseName: "e", Status: statusUnknown},
+	}
+
+	checkRepositoryPolicy(policy, repoURLs, result)
+	assert.False(t, hasFlag(result[0], flagUnapprovedRepo))
+	assert.False(t, hasFlag(result[1], flagUnapprovedRepo))
+	assert.True(t, hasFlag(result[2], flagUnapprovedRepo))
+	assert.True(t, hasFlag(result[3], flagUnapprovedRepo))
+	assert.False(t, hasFlag(result[4], flagUnapprovedRepo))
+
+	err := repoPolicyError(result)
+	assert.EqualError(t, err, "2 release(s) are installed from unapproved repositories")
+	assert.Equal(t, exitCodePolicy, exitCode(err))
+	assert.NoError(t, repoPolicyError(result[:2]))
+}
+
+// Test that a denylist alone allows every other repository
+func TestRepositoryPolicyDenyOnly(t *testing.T) {
+	policy := repositoryPolicy{Deny: []string{"shadow-*"}}
+	assert.True(t, policy.approved("stable", "https://charts.helm.sh/stable"))
+	assert.False(t, policy.approved("shadow-it", ""))
+	assert.False(t, repositoryPolicy{}.enabled())
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Constants for refreshing repository indexes
+const (
+	repoUpdateAttempts = 3
+	repoUpdateBackoff  = 2 * time.Second
+)
+
+// reposToRefresh returns the configured repositories whose index has to be
+// refreshed for the installed charts: the repositories whose cached index
+// provides one of the charts or that the mapping cache attributes one to,
+// and the repositories without a cached index, which can't be told apart.
+func reposToRefresh(charts map[string]bool, cached []repoIndex, repoFileData *helmRepoFile, mappings repoMappings) []string {
+	indexes := make(map[string]repoIndex, len(cached))
+	for _, idx := range cached {
+		indexes[idx.name] = idx
+	}
+	mapped := make(map[string]bool)
+	for chart, repoName := range mappings.Charts {
+		if charts[chart] {
+			mapped[repoName] = true
+		}
+	}
+
+	var names []string
+	for _, repoEntry := range repoFileData.Repositories {
+		idx, ok := indexes[repoEntry.Name]
+		if !ok || mapped[repoEntry.Name] {
+			names = append(names, repoEntry.Name)
+			continue
+		}
+		for chart := range charts {
+			if _, provides := idx.Entries[chart]; provides {
+				names = append(names, repoEntry.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// refreshRepositories runs `helm repo update` for the repositories hosting the
+// installed charts. Repositories are updated one by one so that only the
+// failed ones are retried; the ones still failing are reported as warnings
+// and scanned with their cached index.
+func refreshRepositories(ctx context.Context, releases []*helmRelease, warnings *[]warning) error {
+	repoFileData, err := loadRepoFile(newSettings().RepositoryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to load repository file: %w", err)
+	}
+	cached, err := fetchIndices()
+	if err != nil {
+		return err
+	}
+	mappings, err := loadRepoMappings(repoMappingsFile())
+	if err != nil {
+		return err
+	}
+
+	charts := make(map[string]bool, len(releases))
+	for _, rel := range releases {
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			charts[rel.Chart.Metadata.Name] = true
+		}
+	}
+
+	names := reposToRefresh(charts, cached, repoFileData, mappings)
+	failed := updateRepositories(ctx, names, repoUpdateAttempts, repoUpdateBackoff)
+	for _, name := range names {
+		if err, ok := failed[name]; ok {
+			addWarning(warnings, warnRepoUpdateFailed, "Failed to update repository %s, using its cached index: %v", name, err)
+		}
+	}
+	return ctx.Err()
+}
+
+// updateRepositories updates the given repositories, retrying the failed ones
+// up to attempts times in total, and returns the errors of the ones that
+// could not be updated
+func updateRepositories(ctx context.Context, names []string, attempts int, backoff time.Duration) map[string]error {
+	failed := make(map[string]error)
+	pending := names
+	for attempt := 1; attempt <= attempts && len(pending) > 0; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return failed
+			case <-time.After(time.Duration(attempt-1) * backoff):
+			}
+		}
+
+		var retry []string
+		for _, name := range pending {
+			if err := updateRepository(ctx, name); err != nil {
+				failed[name] = err
+				retry = append(retry, name)
+				continue
+			}
+			delete(failed, name)
+		}
+		pending = retry
+	}
+	return failed
+}
+
+// updateRepository runs `helm repo update` for a single repository
+func updateRepository(ctx context.Context, name string) error {
+	if dryRun {
+		dryRunf("%s repo update %s", helmBinary(), name)
+		return nil
+	}
+	ctx, cancel := withRepoTimeout(ctx)
+	defer cancel()
+	// #nosec G204 -- the Helm binary is provided by Helm itself when running the plugin
+	cmd := exec.CommandContext(ctx, helmBinary(), "repo", "update", name)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that only the repositories hosting installed charts are refreshed
+func TestReposToRefresh(t *testing.T) {
+	bitnami := newTestIndex("redis", "17.0.0")
+	bitnami.name = "bitnami"
+	bitnami.Entries["nginx"] = newTestIndex("nginx", "1.0.0").Entries["nginx"]
+	stable := newTestIndex("nginx", "1.0.0")
+	stable.name = "stable"
+	unused := newTestIndex("kafka", "1.0.0")
+	unused.name = "unused"
+	mirror := newTestIndex("unrelated", "1.0.0")
+	mirror.name = "mirror"
+
+	repoFile := &repo.File{Repositories: []*repo.Entry{
+		{Name: "bitnami"}, {Name: "stable"}, {Name: "unused"}, {Name: "mirror"}, {Name: "new"},
+	}}
+	mappings := repoMappings{Charts: map[string]string{"postgresql": "mirror", "kafka": "unused"}}
+
+	names := reposToRefresh(map[string]bool{"redis": true, "postgresql": true},
+		[]repoIndex{bitnami, stable, unused, mirror}, repoFile, mappings)
+	assert.Equal(t, []string{"bitnami", "mirror", "new"}, names)
+}
+
+// Test that only failed repositories are retried and the ones still failing are returned
+func TestUpdateRepositories(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := filepath.Join(dir, "helm")
+	// The broken repository always fails, the flaky one only on its first update
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+echo "$3" >> `+calls+`
+case "$3" in
+broken) exit 1 ;;
+flaky) [ -f `+dir+`/flaky ] && exit 0; touch `+dir+`/flaky; exit 1 ;;
+esac
+`), 0o700))
+	t.Setenv("HELM_BIN", script)
+
+	failed := updateRepositories(context.Background(), []string{"stable", "flaky", "broken"}, 3, 0)
+	require.Len(t, failed, 1)
+	assert.Contains(t, failed, "broken")
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "stable\nflaky\nbroken\nflaky\nbroken\nbroken\n", string(data))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// reportEnvelope is the full result of a scan, as written by -o json and -o yaml
+// and made available to report templates
+type reportEnvelope struct {
+	GeneratedAt time.Time          `json:"generatedAt"`
+	Version     string             `json:"version"`
+	Results     []ChartVersionInfo `json:"results"`
+	Warnings    []warning          `json:"warnings"`
+	Summary     *reportSummary     `json:"summary,omitempty"`
+}
+
+// newReportEnvelope wraps the outcome of a scan
+func newReportEnvelope(report scanReport, now time.Time) reportEnvelope {
+	summary := cfg.Vocabulary.translateSummary(summarize(report.results))
+	return reportEnvelope{
+		GeneratedAt: now.UTC(),
+		Version:     version,
+		Results:     cfg.Vocabulary.translate(report.results),
+		Warnings:    report.warnings,
+		Summary:     &summary,
+	}
+}
+
+// loadReport reads a report previously written with -o json or -o yaml,
+// optionally gzip-compressed. Reports written before warnings were added,
+// holding only the list of results, are accepted as well.
+func loadReport(path string) (reportEnvelope, error) {
+	var envelope reportEnvelope
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return envelope, fmt.Errorf("failed to read report: %w", err)
+	}
+	if data, err = decompressReport(data); err != nil {
+		return envelope, fmt.Errorf("failed to decompress report %s: %w", path, err)
+	}
+
+	envelope, err = decodeReport(data)
+	if err != nil {
+		return envelope, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	cfg.Vocabulary.canonicalize(envelope.Results)
+
+	return envelope, nil
+}
+
+// decodeReport parses a report in JSON or YAML, either a full envelope or
+// only the list of results
+func decodeReport(data []byte) (reportEnvelope, error) {
+	var envelope reportEnvelope
+
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return envelope, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(jsonData), []byte("[")) {
+		err = yaml.Unmarshal(jsonData, &envelope.Results)
+	} else {
+		err = yaml.Unmarshal(jsonData, &envelope)
+	}
+	return envelope, err
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// reportDiff describes what changed between two reports
+type reportDiff struct {
+	NewlyOutdated  []ChartVersionInfo `json:"newlyOutdated"`
+	Resolved       []ChartVersionInfo `json:"resolved"`
+	VersionChanges []versionChange    `json:"versionChanges"`
+}
+
+// versionChange is a release that is in both reports with different versions
+type versionChange struct {
+	Old ChartVersionInfo `json:"old"`
+	New ChartVersionInfo `json:"new"`
+}
+
+// newReportDiffCmd creates the report-diff subcommand
+func newReportDiffCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "report-diff OLD NEW",
+		Short: "show what changed between two reports written with -o json",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			oldReport, err := loadReport(args[0])
+			if err != nil {
+				return err
+			}
+			newReport, err := loadReport(args[1])
+			if err != nil {
+				return err
+			}
+			return printReportDiff(os.Stdout, output, diffReports(oldReport.Results, newReport.Results))
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputFormatPlain, "output format. Accepted formats: plain, json")
+
+	return cmd
+}
+
+// diffReports compares two reports. Releases are matched by cluster,
+// namespace and name; a release missing from the new report is resolved.
+func diffReports(oldReport, newReport []ChartVersionInfo) reportDiff {
+	diff := reportDiff{
+		NewlyOutdated:  []ChartVersionInfo{},
+		Resolved:       []ChartVersionInfo{},
+		VersionChanges: []versionChange{},
+	}
+
+	previous := make(map[string]ChartVersionInfo, len(oldReport))
+	for _, versionInfo := range oldReport {
+		previous[reportKey(versionInfo)] = versionInfo
+	}
+	current := make(map[string]ChartVersionInfo, len(newReport))
+	for _, versionInfo := range newReport {
+		current[reportKey(versionInfo)] = versionInfo
+	}
+
+	for _, key := range sortedFindingKeys(current) {
+		versionInfo := current[key]
+		old, existed := previous[key]
+		switch {
+		case versionInfo.Status == statusOutdated && (!existed || old.Status != statusOutdated):
+			diff.NewlyOutdated = append(diff.NewlyOutdated, versionInfo)
+		case existed && old.Status == statusOutdated && versionInfo.Status != statusOutdated:
+			diff.Resolved = append(diff.Resolved, old)
+		case existed && (old.InstalledVersion != versionInfo.InstalledVersion || old.LatestVersion != versionInfo.LatestVersion):
+			diff.VersionChanges = append(diff.VersionChanges, versionChange{Old: old, New: versionInfo})
+		}
+	}
+
+	for _, key := range sortedFindingKeys(previous) {
+		if _, exists := current[key]; !exists && previous[key].Status == statusOutdated {
+			diff.Resolved = append(diff.Resolved, previous[key])
+		}
+	}
+	sort.SliceStable(diff.Resolved, func(i, j int) bool {
+		return reportKey(diff.Resolved[i]) < reportKey(diff.Resolved[j])
+	})
+
+	return diff
+}
+
+// printReportDiff writes the diff in the requested format
+func printReportDiff(w io.Writer, output string, diff reportDiff) error {
+	switch output {
+	case outputFormatJSON:
+		outputBytes, err := json.MarshalIndent(diff, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatPlain:
+		fmt.Fprint(w, formatReportDiff(diff))
+	default:
+		return fmt.Errorf("invalid formatter: %s", output)
+	}
+	return nil
+}
+
+// formatReportDiff renders the diff for humans
+func formatReportDiff(diff reportDiff) string {
+	if len(diff.NewlyOutdated) == 0 && len(diff.Resolved) == 0 && len(diff.VersionChanges) == 0 {
+		return "No changes between the reports.\n"
+	}
+
+	var b strings.Builder
+	if len(diff.NewlyOutdated) > 0 {
+		fmt.Fprintf(&b, "Newly outdated (%d):\n", len(diff.NewlyOutdated))
+		for _, versionInfo := range diff.NewlyOutdated {
+			fmt.Fprintf(&b, "  %s (%s): %s --> %s\n", reportKey(versionInfo), versionInfo.ChartName, versionInfo.InstalledVersion, versionInfo.LatestVersion)
+		}
+	}
+	if len(diff.Resolved) > 0 {
+		fmt.Fprintf(&b, "Resolved (%d):\n", len(diff.Resolved))
+		for _, versionInfo := range diff.Resolved {
+			fmt.Fprintf(&b, "  %s (%s)\n", reportKey(versionInfo), versionInfo.ChartName)
+		}
+	}
+	if len(diff.VersionChanges) > 0 {
+		fmt.Fprintf(&b, "Version changes (%d):\n", len(diff.VersionChanges))
+		for _, change := range diff.VersionChanges {
+			fmt.Fprintf(&b, "  %s (%s): installed %s, latest %s\n",
+				reportKey(change.New),
+				change.New.ChartName,
+				versionTransition(change.Old.InstalledVersion, change.New.InstalledVersion),
+				versionTransition(change.Old.LatestVersion, change.New.LatestVersion))
+		}
+	}
+	return b.String()
+}
+
+// versionTransition renders a version that may have changed
+func versionTransition(oldVersion, newVersion string) string {
+	if oldVersion == newVersion {
+		return newVersion
+	}
+	return oldVersion + " --> " + newVersion
+}
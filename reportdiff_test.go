@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that two reports are compared release by release
+func TestDiffReports(t *testing.T) {
+	oldReport := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "apps", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "1.0.0", Status: statusUptodate},
+		{ReleaseName: "api", Namespace: "apps", ChartName: "api", InstalledVersion: "2.0.0", LatestVersion: "2.1.0", Status: statusOutdated},
+		{ReleaseName: "db", Namespace: "apps", ChartName: "postgresql", InstalledVersion: "1.0.0", LatestVersion: "1.2.0", Status: statusOutdated},
+		{ReleaseName: "old", Namespace: "apps", ChartName: "legacy", InstalledVersion: "0.1.0", LatestVersion: "0.2.0", Status: statusOutdated},
+	}
+	newReport := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "apps", ChartName: "nginx", InstalledVersion: "1.0.0", LatestVersion: "1.2.0", Status: statusOutdated},
+		{ReleaseName: "api", Namespace: "apps", ChartName: "api", InstalledVersion: "2.1.0", LatestVersion: "2.1.0", Status: statusUptodate},
+		{ReleaseName: "db", Namespace: "apps", ChartName: "postgresql", InstalledVersion: "1.0.0", LatestVersion: "1.3.0", Status: statusOutdated},
+	}
+
+	diff := diffReports(oldReport, newReport)
+
+	require.Len(t, diff.NewlyOutdated, 1)
+	assert.Equal(t, "web", diff.NewlyOutdated[0].ReleaseName)
+
+	require.Len(t, diff.Resolved, 2)
+	assert.Equal(t, "api", diff.Resolved[0].ReleaseName)
+	assert.Equal(t, "old", diff.Resolved[1].ReleaseName)
+
+	require.Len(t, diff.VersionChanges, 1)
+	assert.Equal(t, "1.2.0", diff.VersionChanges[0].Old.LatestVersion)
+	assert.Equal(t, "1.3.0", diff.VersionChanges[0].New.LatestVersion)
+
+	text := formatReportDiff(diff)
+	assert.Contains(t, text, "Newly outdated (1):\n  apps/web (nginx): 1.0.0 --> 1.2.0\n")
+	assert.Contains(t, text, "  apps/db (postgresql): installed 1.0.0, latest 1.2.0 --> 1.3.0\n")
+
+	assert.Equal(t, "No changes between the reports.\n", formatReportDiff(diffReports(newReport, newReport)))
+}
+
+// Test that reports written as JSON or YAML, with or without warnings, can be loaded
+func TestLoadReport(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "report.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"results": [{"releaseName": "web", "namespace": "apps", "status": "OUTDATED"}],
+		"warnings": [{"code": "W001", "name": "RepoUnresolved", "message": "unresolved"}]}`), 0o600))
+	report, err := loadReport(jsonPath)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, "web", report.Results[0].ReleaseName)
+	require.Len(t, report.Warnings, 1)
+	assert.Equal(t, warnRepoUnresolved, report.Warnings[0].Code)
+
+	legacyPath := filepath.Join(dir, "legacy.json")
+	require.NoError(t, os.WriteFile(legacyPath, []byte(`[{"releaseName": "web", "namespace": "apps", "status": "OUTDATED"}]`), 0o600))
+	report, err = loadReport(legacyPath)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, "web", report.Results[0].ReleaseName)
+
+	yamlPath := filepath.Join(dir, "report.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("- releasename: web\n  namespace: apps\n  status: OUTDATED\n"), 0o600))
+	report, err = loadReport(yamlPath)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, "web", report.Results[0].ReleaseName)
+
+	_, err = loadReport(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Constants for report files
+const (
+	gzipExtension   = ".gz"
+	checksumSuffix  = ".sha256"
+	reportFileMode  = 0o644
+	maxReportExpand = 1 << 30
+)
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// writeReportFile writes a report to path, gzip-compressed when the path ends
+// with .gz, and writes the SHA-256 checksum of the file to path.sha256 in the
+// format of sha256sum so it can be checked with `sha256sum -c`.
+func writeReportFile(path string, data []byte) error {
+	if strings.HasSuffix(path, gzipExtension) {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		zw.Name = strings.TrimSuffix(filepath.Base(path), gzipExtension)
+		if _, err := zw.Write(data); err != nil {
+			return fmt.Errorf("failed to compress report: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to compress report: %w", err)
+		}
+		data = compressed.Bytes()
+	}
+
+	if err := os.WriteFile(path, data, reportFileMode); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), filepath.Base(path))
+	if err := os.WriteFile(path+checksumSuffix, []byte(checksum), reportFileMode); err != nil {
+		return fmt.Errorf("failed to write report checksum: %w", err)
+	}
+	return nil
+}
+
+// decompressReport returns the content of a report, decompressing it when it
+// was written gzip-compressed
+func decompressReport(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(io.LimitReader(zr, maxReportExpand))
+}
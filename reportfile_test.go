@@ -0,0 +1,45 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that gzip-compressed report files come with a checksum and can be loaded again
+func TestWriteReportFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json.gz")
+	report := []byte(`{"results": [{"releaseName": "web", "status": "OUTDATED"}]}`)
+
+	require.NoError(t, writeReportFile(path, report))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, gzipMagic, data[:2])
+
+	checksum, err := os.ReadFile(path + checksumSuffix)
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:])+"  report.json.gz\n", string(checksum))
+
+	envelope, err := loadReport(path)
+	require.NoError(t, err)
+	require.Len(t, envelope.Results, 1)
+	assert.Equal(t, "web", envelope.Results[0].ReleaseName)
+}
+
+// Test that report files without .gz are written uncompressed
+func TestWriteReportFilePlain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, writeReportFile(path, []byte("[]\n")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", string(data))
+	assert.FileExists(t, path+checksumSuffix)
+}
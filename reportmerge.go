@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// newReportMergeCmd creates the report-merge subcommand
+func newReportMergeCmd() *cobra.Command {
+	var output, outputPath string
+
+	cmd := &cobra.Command{
+		Use:   "report-merge REPORT...",
+		Short: "merge reports from separate runs or clusters into one",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if signingEnabled() && outputPath == "" {
+				return fmt.Errorf("--sign and --sign-key require --output-file")
+			}
+			reports := make([][]ChartVersionInfo, 0, len(args))
+			var warnings []warning
+			for _, path := range args {
+				report, err := loadReport(path)
+				if err != nil {
+					return err
+				}
+				reports = append(reports, report.Results)
+				warnings = mergeWarnings(warnings, report.Warnings)
+			}
+			merged := mergeReports(reports...)
+			summary := summarize(merged)
+			envelope := reportEnvelope{
+				GeneratedAt: time.Now().UTC(),
+				Version:     version,
+				Results:     merged,
+				Warnings:    warnings,
+				Summary:     &summary,
+			}
+			if outputPath == "" {
+				return printReport(os.Stdout, output, envelope)
+			}
+			var buf bytes.Buffer
+			if err := printReport(&buf, output, envelope); err != nil {
+				return err
+			}
+			if err := writeReportFile(outputPath, buf.Bytes()); err != nil {
+				return err
+			}
+			if signingEnabled() {
+				return signReportFile(cmd.Context(), outputPath, signKey)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputFormatJSON, "output format. Accepted formats: json, yaml")
+	cmd.Flags().StringVar(&outputPath, "output-file", "", "write the merged report to this file instead of stdout, gzip-compressed if it ends with .gz, together with a .sha256 checksum file")
+
+	return cmd
+}
+
+// mergeReports combines reports into one. Releases are deduplicated by
+// cluster, namespace and name; the entry from the last report wins but keeps
+// the position of its first occurrence.
+func mergeReports(reports ...[]ChartVersionInfo) []ChartVersionInfo {
+	merged := []ChartVersionInfo{}
+	index := make(map[string]int)
+
+	for _, report := range reports {
+		for _, versionInfo := range report {
+			key := reportKey(versionInfo)
+			if i, exists := index[key]; exists {
+				merged[i] = versionInfo
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, versionInfo)
+		}
+	}
+
+	return merged
+}
+
+// mergeWarnings appends the warnings that are not already present
+func mergeWarnings(warnings, more []warning) []warning {
+	for _, w := range more {
+		if !containsWarning(warnings, w) {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
+
+// containsWarning reports whether warnings contains w
+func containsWarning(warnings []warning, w warning) bool {
+	for _, existing := range warnings {
+		if existing == w {
+			return true
+		}
+	}
+	return false
+}
+
+// printReport writes a report in a format that can be loaded again
+func printReport(w io.Writer, output string, envelope reportEnvelope) error {
+	envelope.Results = cfg.Vocabulary.translate(envelope.Results)
+	if envelope.Summary != nil {
+		summary := cfg.Vocabulary.translateSummary(*envelope.Summary)
+		envelope.Summary = &summary
+	}
+	switch output {
+	case outputFormatJSON:
+		outputBytes, err := json.MarshalIndent(envelope, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatYML, outputFormatYAML:
+		outputBytes, err := yaml.Marshal(envelope)
+		if err != nil {
+			return fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	default:
+		return fmt.Errorf("invalid formatter: %s", output)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that reports are merged and deduplicated by cluster, namespace and release
+func TestMergeReports(t *testing.T) {
+	a := []ChartVersionInfo{
+		{Cluster: "eu", ReleaseName: "web", Namespace: "apps", LatestVersion: "1.0.0"},
+		{Cluster: "eu", ReleaseName: "api", Namespace: "apps"},
+	}
+	b := []ChartVersionInfo{
+		{Cluster: "us", ReleaseName: "web", Namespace: "apps"},
+		{Cluster: "eu", ReleaseName: "web", Namespace: "apps", LatestVersion: "1.1.0"},
+	}
+
+	merged := mergeReports(a, b)
+	require.Len(t, merged, 3)
+	assert.Equal(t, "eu/apps/web", reportKey(merged[0]))
+	assert.Equal(t, "1.1.0", merged[0].LatestVersion)
+	assert.Equal(t, "eu/apps/api", reportKey(merged[1]))
+	assert.Equal(t, "us/apps/web", reportKey(merged[2]))
+}
+
+// Test that a merged report can be loaded again
+func TestPrintReportRoundTrip(t *testing.T) {
+	result := []ChartVersionInfo{{Cluster: "eu", ReleaseName: "web", Namespace: "apps", Status: statusOutdated}}
+	envelope := reportEnvelope{Results: result, Warnings: []warning{{Code: warnStaleIndex, Name: "StaleIndex", Message: "stale"}}}
+
+	for _, output := range []string{outputFormatJSON, outputFormatYAML} {
+		var buf bytes.Buffer
+		require.NoError(t, printReport(&buf, output, envelope))
+
+		path := filepath.Join(t.TempDir(), "report."+output)
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+		loaded, err := loadReport(path)
+		require.NoError(t, err)
+		require.Len(t, loaded.Results, 1, output)
+		assert.Equal(t, "eu/apps/web", reportKey(loaded.Results[0]), output)
+		assert.Equal(t, statusOutdated, loaded.Results[0].Status, output)
+		assert.Equal(t, envelope.Warnings, loaded.Warnings, output)
+	}
+
+	assert.Error(t, printReport(&bytes.Buffer{}, outputFormatTable, envelope))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/spf13/cobra"
+)
+
+// newReportCmd creates the report subcommand
+func newReportCmd() *cobra.Command {
+	var templateFile string
+
+	cmd := &cobra.Command{
+		Use:   "report --template FILE",
+		Short: "scan the releases and render the result through a Go template",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			tmpl, err := loadReportTemplate(templateFile)
+			if err != nil {
+				return err
+			}
+
+			report, err := scan(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			return renderReport(os.Stdout, tmpl, newReportEnvelope(report, time.Now()))
+		},
+	}
+
+	cmd.Flags().StringVar(&templateFile, "template", "", "path to a Go template (with sprig functions) rendering the result envelope")
+	_ = cmd.MarkFlagRequired("template")
+
+	return cmd
+}
+
+// loadReportTemplate parses a report template with the sprig functions available
+func loadReportTemplate(path string) (*template.Template, error) {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(sprig.TxtFuncMap()).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderReport executes the template and only writes its output when it succeeded
+func renderReport(w io.Writer, tmpl *template.Template, envelope reportEnvelope) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, envelope); err != nil {
+		return fmt.Errorf("failed to render report template: %w", err)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the result envelope is rendered through a user template with sprig functions
+func TestRenderReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(
+		"release,installed,latest\n"+
+			"{{- range .Results }}{{ if eq .Status \"OUTDATED\" }}\n{{ .ReleaseName }},{{ .InstalledVersion }},{{ .LatestVersion }}{{ end }}{{ end }}\n"+
+			"{{ len .Warnings }} warning(s), generated {{ .GeneratedAt | date \"2006-01-02\" }} by {{ upper \"whatup\" }}\n",
+	), 0o600))
+
+	tmpl, err := loadReportTemplate(path)
+	require.NoError(t, err)
+
+	report := scanReport{
+		results: []ChartVersionInfo{
+			{ReleaseName: "web", InstalledVersion: "1.0.0", LatestVersion: "1.2.0", Status: statusOutdated},
+			{ReleaseName: "api", InstalledVersion: "2.0.0", LatestVersion: "2.0.0", Status: statusUptodate},
+		},
+		warnings: []warning{{Code: warnRepoUnresolved, Name: "RepoUnresolved", Message: "The source repository could not be determined for 'db'"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, renderReport(&buf, tmpl, newReportEnvelope(report, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))))
+	assert.Equal(t, "release,installed,latest\nweb,1.0.0,1.2.0\n1 warning(s), generated 2024-03-01 by WHATUP\n", buf.String())
+
+	require.NoError(t, os.WriteFile(path, []byte("{{ .Missing }}"), 0o600))
+	tmpl, err = loadReportTemplate(path)
+	require.NoError(t, err)
+	buf.Reset()
+	assert.Error(t, renderReport(&buf, tmpl, newReportEnvelope(report, time.Now())))
+	assert.Empty(t, buf.String())
+}
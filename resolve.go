@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+)
+
+// repositoryAnnotation is the chart annotation naming the repository a chart
+// is published in
+const repositoryAnnotation = "artifacthub.io/repository"
+
+// chartURLAnnotation is the chart annotation recording the URL the chart
+// archive was downloaded from, e.g. for charts installed with
+// `helm install NAME URL`
+const chartURLAnnotation = "whatup.helm.sh/chart-url"
+
+// Confidence levels of the attribution of a chart to a repository, from the
+// most to the least reliable
+const (
+	// confidenceExact is an attribution given by the chart annotation, the
+	// mapping cache or an interactive choice
+	confidenceExact = "exact-annotation"
+	// confidenceURL is an attribution by the download URL of the chart, which
+	// belongs to the configured repository URL
+	confidenceURL = "url-match"
+	// confidenceIndex is an attribution to the only configured repository whose
+	// index provides the chart
+	confidenceIndex = "index-match"
+	// confidencePrefix is an attribution by the name of the chart, e.g. a chart
+	// rke2-cilium attributed to the repository rke2-charts
+	confidencePrefix = "prefix-heuristic"
+	// confidenceGuess is an attribution that can't be relied on, e.g. one of
+	// several repositories providing the chart
+	confidenceGuess = "guess"
+	// confidenceNone is shown for charts that could not be attributed
+	confidenceNone = "none"
+)
+
+// confidenceLevels lists the confidence levels from the most to the least reliable
+var confidenceLevels = []string{confidenceExact, confidenceURL, confidenceIndex, confidencePrefix, confidenceGuess}
+
+// confidenceRank returns the position of a confidence level in
+// confidenceLevels, lower being more reliable. Unknown levels rank last.
+func confidenceRank(confidence string) int {
+	for i, level := range confidenceLevels {
+		if level == confidence {
+			return i
+		}
+	}
+	return len(confidenceLevels)
+}
+
+// dropUnconfidentRepos reports the results whose repository attribution is
+// less confident than minimum as UNKNOWN instead of trusting the attribution
+func dropUnconfidentRepos(minimum string, result []ChartVersionInfo, warnings *[]warning) {
+	for i := range result {
+		if result[i].RepoName == "" || confidenceRank(result[i].RepoConfidence) <= confidenceRank(minimum) {
+			continue
+		}
+		addWarning(warnings, warnRepoUnresolved, "The source repository of '%s' is a %s attribution to %s, ignoring it",
+			result[i].ReleaseName, result[i].RepoConfidence, result[i].RepoName)
+		result[i] = ChartVersionInfo{
+			ReleaseName:      result[i].ReleaseName,
+			Namespace:        result[i].Namespace,
+			ChartName:        result[i].ChartName,
+			InstalledVersion: result[i].InstalledVersion,
+			Revision:         result[i].Revision,
+			Status:           statusUnknown,
+		}
+	}
+}
+
+// resolution is the repository a release's chart was attributed to
+type resolution struct {
+	ReleaseName string `json:"releaseName"`
+	Namespace   string `json:"namespace"`
+	Cluster     string `json:"cluster,omitempty"`
+	ChartName   string `json:"chartName"`
+	RepoName    string `json:"repoName,omitempty"`
+	Confidence  string `json:"confidence"`
+}
+
+// verifyRepoURL upgrades the confidence of an attribution to url-match when
+// the chart is downloaded from the URL of the attributed repository
+func verifyRepoURL(confidence, repoName string, entries helmChartVersions, repoFileData *helmRepoFile) string {
+	if confidence == confidenceExact || confidence == confidenceURL || len(entries) == 0 || repoFileData == nil {
+		return confidence
+	}
+	for _, repo := range repoFileData.Repositories {
+		if repo.Name != repoName || repo.URL == "" {
+			continue
+		}
+		for _, chartURL := range entries[0].URLs {
+			if strings.HasPrefix(chartURL, strings.TrimSuffix(repo.URL, "/")+"/") {
+				return confidenceURL
+			}
+		}
+	}
+	return confidence
+}
+
+// chartRepoURL returns the URL of a repository by name. OCI repositories
+// are named after their URL.
+func chartRepoURL(repoName string, repoFileData *helmRepoFile) string {
+	if strings.HasPrefix(repoName, "oci://") {
+		return repoName
+	}
+	if repoFileData == nil {
+		return ""
+	}
+	for _, repo := range repoFileData.Repositories {
+		if repo.Name == repoName {
+			return repo.URL
+		}
+	}
+	return ""
+}
+
+// chartSourceRepo returns the configured repository hosting the chart of the
+// release, by the URL it was downloaded from or, failing that, the sources
+// listed in its metadata. It is empty when no URL belongs to a repository.
+func chartSourceRepo(rel *helmRelease, repoFileData *helmRepoFile) string {
+	if repoFileData == nil || rel.Chart == nil || rel.Chart.Metadata == nil {
+		return ""
+	}
+	urls := rel.Chart.Metadata.Sources
+	if chartURL := rel.Chart.Metadata.Annotations[chartURLAnnotation]; chartURL != "" {
+		urls = append([]string{chartURL}, urls...)
+	}
+	for _, chartURL := range urls {
+		for _, repo := range repoFileData.Repositories {
+			repoURL := strings.TrimSuffix(repo.URL, "/")
+			if repoURL != "" && (chartURL == repoURL || strings.HasPrefix(chartURL, repoURL+"/")) {
+				return repo.Name
+			}
+		}
+	}
+	return ""
+}
+
+// resolveReleases attributes the chart of every installed release to a
+// repository. Unlike a scan it compares no versions, runs no hooks or
+// integrations and records nothing: the mapping cache is read, but never
+// prompted for nor saved.
+func resolveReleases() ([]resolution, []warning, error) {
+	suppressed, err := suppressedWarnings(suppressWarnings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clusters, err := selectedClusters()
+	if err != nil {
+		return nil, nil, err
+	}
+	targets, err := scanTargets(clusters)
+	if err != nil {
+		return nil, nil, err
+	}
+	scans := make([]*clusterScan, 0, len(targets))
+	for _, target := range targets {
+		cs, err := newClusterScan(target)
+		if err != nil {
+			return nil, nil, err
+		}
+		scans = append(scans, cs)
+	}
+
+	repositories, err := fetchIndices()
+	if err != nil {
+		return nil, nil, err
+	}
+	repoFileData, err := loadRepoFile(newSettings().RepositoryConfig)
+	if err != nil {
+		logger.Warn("failed to load repository file", "error", err)
+	}
+	chartRepoMap := buildChartRepoMap(repositories, repoFileData)
+
+	resolver, err := newRepoResolver(repoMappingsFile(), strictRepo)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolver.prompt = nil
+
+	var warnings []warning
+	var resolved []resolution
+	for _, cs := range scans {
+		for _, rel := range cs.releases {
+			r := resolution{
+				ReleaseName: rel.Name,
+				Namespace:   rel.Namespace,
+				Cluster:     cs.target.cluster.Name,
+				ChartName:   rel.Chart.Metadata.Name,
+				Confidence:  confidenceNone,
+			}
+			attribution, err := attributeRepo(rel, repositories, repoFileData, chartRepoMap, resolver)
+			switch {
+			case err != nil:
+				addWarning(&warnings, warnAmbiguousRepo, "Skipping '%s': %v", rel.Name, err)
+			case len(attribution.candidates) == 0:
+				addWarning(&warnings, warnRepoUnresolved, "The source repository could not be determined for '%s'", rel.Name)
+			default:
+				r.RepoName = attribution.repoName
+				r.Confidence = attribution.confidence
+			}
+			resolved = append(resolved, r)
+		}
+	}
+	return resolved, filterWarnings(warnings, suppressed), nil
+}
+
+// newResolveCmd creates the resolve subcommand
+func newResolveCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "resolve",
+		Short: "print the repository every installed chart is attributed to and how confident the attribution is",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			resolved, warnings, err := resolveReleases()
+			if err != nil {
+				return err
+			}
+			logWarnings(warnings)
+			return printResolutions(os.Stdout, output, resolved)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputFormatTable, "output format. Accepted formats: table, json")
+
+	return cmd
+}
+
+// printResolutions writes the attributions in the requested format
+func printResolutions(w io.Writer, output string, resolved []resolution) error {
+	switch output {
+	case outputFormatJSON:
+		outputBytes, err := json.MarshalIndent(resolved, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatTable:
+		if len(resolved) == 0 {
+			fmt.Fprintln(w, "No releases found.")
+			return nil
+		}
+		table := uitable.New()
+		table.MaxColWidth = 50
+		table.Separator = "  "
+		table.AddRow("NAME", "NAMESPACE", "CHART", "REPOSITORY", "CONFIDENCE")
+		for _, r := range resolved {
+			table.AddRow(r.ReleaseName, r.Namespace, r.ChartName, r.RepoName, r.Confidence)
+		}
+		fmt.Fprintln(w, table)
+	default:
+		return fmt.Errorf("invalid formatter: %s", output)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that charts are mapped to a repository whose index provides them
+func TestBuildChartRepoMap(t *testing.T) {
+	stable := newTestIndex("nginx", "1.0.0")
+	stable.name = "stable"
+	other := newTestIndex("redis", "2.0.0")
+	other.name = "other"
+
+	chartRepoMap := buildChartRepoMap([]repoIndex{stable, other}, &repo.File{
+		Repositories: []*repo.Entry{{Name: "unrelated"}},
+	})
+	assert.Equal(t, map[string]string{"nginx": "stable", "redis": "other"}, chartRepoMap)
+}
+
+// Test that every attribution is reported with its confidence
+func TestAttributeRepo(t *testing.T) {
+	annotated := newTestRelease("annotated", "default", "nginx", "1.0.0")
+	annotated.Chart.Metadata.Annotations = map[string]string{repositoryAnnotation: "stable"}
+
+	nginx := newTestIndex("nginx", "1.1.0")
+	nginx.name = "stable"
+	redis := newTestIndex("redis", "2.0.0")
+	redis.name = "bitnami"
+	redis.Entries["redis"][0].URLs = []string{"https://charts.bitnami.com/bitnami/redis-2.0.0.tgz"}
+	mysql := newTestIndex("mysql", "3.0.0")
+	mysql.name = "db"
+	repositories := []repoIndex{nginx, redis, mysql}
+
+	repoFile := &repo.File{Repositories: []*repo.Entry{
+		{Name: "stable", URL: "https://charts.example.com/stable"},
+		{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami"},
+		{Name: "db", URL: "https://db.example.com"},
+	}}
+
+	chartRepoMap := buildChartRepoMap(repositories, repoFile)
+	releases := []*helmRelease{
+		annotated,
+		newTestRelease("cache", "default", "redis", "2.0.0"),
+		newTestRelease("database", "default", "mysql", "3.0.0"),
+		newTestRelease("missing", "default", "missing", "1.0.0"),
+	}
+	var attributions []repoAttribution
+	for _, rel := range releases {
+		attribution, err := attributeRepo(rel, repositories, repoFile, chartRepoMap, nil)
+		require.NoError(t, err)
+		attributions = append(attributions, attribution)
+	}
+	assert.Equal(t, confidenceExact, attributions[0].confidence)
+	assert.Equal(t, "bitnami", attributions[1].repoName)
+	assert.Equal(t, confidenceURL, attributions[1].confidence)
+	assert.Equal(t, "db", attributions[2].repoName)
+	assert.Equal(t, confidenceIndex, attributions[2].confidence)
+	assert.Empty(t, attributions[3].candidates)
+
+	// The results of a scan carry the same attributions
+	var warnings []warning
+	result := processReleases(releases, repositories, repoFile, chartRepoMap, nil, nil, &warnings)
+	require.Len(t, result, 4)
+	assert.Equal(t, "bitnami", result[1].RepoName)
+	assert.Equal(t, confidenceURL, result[1].RepoConfidence)
+}
+
+// Test that the repository of a chart is resolved from its download URL and
+// remembered for later runs
+func TestChartSourceRepo(t *testing.T) {
+	bitnami := newTestIndex("nginx", "15.0.0")
+	bitnami.name = "bitnami"
+	mirror := newTestIndex("nginx", "16.0.0")
+	mirror.name = "mirror"
+	repositories := []repoIndex{bitnami, mirror}
+	repoFile := &repo.File{Repositories: []*repo.Entry{
+		{Name: "mirror", URL: "https://mirror.example.com/charts"},
+		{Name: "bitnami", URL: "https://charts.bitnami.com/bitnami/"},
+	}}
+
+	downloaded := newTestRelease("web", "default", "nginx", "15.0.0")
+	downloaded.Chart.Metadata.Annotations = map[string]string{chartURLAnnotation: "https://charts.bitnami.com/bitnami/nginx-15.0.0.tgz"}
+	assert.Equal(t, "bitnami", chartSourceRepo(downloaded, repoFile))
+
+	sourced := newTestRelease("web", "default", "nginx", "15.0.0")
+	sourced.Chart.Metadata.Sources = []string{"https://github.com/example/nginx", "https://mirror.example.com/charts"}
+	assert.Equal(t, "mirror", chartSourceRepo(sourced, repoFile))
+
+	assert.Empty(t, chartSourceRepo(newTestRelease("web", "default", "nginx", "15.0.0"), repoFile))
+
+	path := filepath.Join(t.TempDir(), "repo-mappings.yaml")
+	resolver := &repoResolver{path: path, mappings: repoMappings{Charts: map[string]string{}}, strict: true}
+
+	var warnings []warning
+	result := processReleases([]*helmRelease{downloaded}, repositories, repoFile, map[string]string{}, nil, resolver, &warnings)
+	require.Len(t, result, 1)
+	assert.Equal(t, "bitnami", result[0].RepoName)
+	assert.Equal(t, confidenceURL, result[0].RepoConfidence)
+	assert.Equal(t, "15.0.0", result[0].LatestVersion)
+	require.NoError(t, resolver.save())
+
+	mappings, err := loadRepoMappings(path)
+	require.NoError(t, err)
+	assert.Equal(t, "bitnami", mappings.Charts["nginx"])
+}
+
+// Test that the attributions are printed as a table
+func TestPrintResolutions(t *testing.T) {
+	var out bytes.Buffer
+	err := printResolutions(&out, outputFormatTable, []resolution{
+		{ReleaseName: "cache", Namespace: "default", ChartName: "redis", RepoName: "bitnami", Confidence: confidenceURL},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "CONFIDENCE")
+	assert.Contains(t, out.String(), "bitnami     url-match")
+
+	assert.Error(t, printResolutions(&out, "xml", nil))
+}
+
+// Test that attributions below the minimum confidence are reported as unknown
+func TestDropUnconfidentRepos(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", RepoName: "stable", RepoConfidence: confidenceURL, LatestVersion: "1.1.0", Status: statusOutdated},
+		{ReleaseName: "db", RepoName: "bitnami", RepoConfidence: confidenceGuess, LatestVersion: "2.1.0", Status: statusOutdated},
+	}
+
+	var warnings []warning
+	dropUnconfidentRepos(confidencePrefix, result, &warnings)
+
+	assert.Equal(t, statusOutdated, result[0].Status)
+	assert.Equal(t, statusUnknown, result[1].Status)
+	assert.Empty(t, result[1].RepoName)
+	assert.Empty(t, result[1].LatestVersion)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, warnRepoUnresolved, warnings[0].Code)
+}
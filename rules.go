@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// statusIgnored marks releases excluded from update checks by a rule
+const statusIgnored = "IGNORED"
+
+// versionRule restricts which versions of a chart are reported as updates.
+// A rule without any selector applies to every release.
+type versionRule struct {
+	// Source describes where the rule was defined, e.g. the file it was read from
+	Source string
+
+	// Selectors
+	Charts        []string
+	ChartPatterns []string
+	Releases      []string
+
+	// Ignore excludes the matching releases from update checks entirely
+	Ignore bool
+	// Constraint is a semver constraint that candidate versions have to satisfy
+	Constraint string
+	// VersionPattern is a regular expression that candidate versions have to match
+	VersionPattern string
+	// BlockedUpdates lists the update types (major, minor, patch) that are not reported
+	BlockedUpdates []string
+	// ApprovedVersions lists the only versions that are reported, e.g. the
+	// versions of a chart approved in a catalog
+	ApprovedVersions []string
+	// MinAge is the time a version has to be published before it is reported
+	MinAge time.Duration
+	// Severity is assigned to the matching releases when they are outdated
+	Severity string
+}
+
+// validate checks that the constraint and pattern of the rule can be parsed
+func (r *versionRule) validate() error {
+	if r.Constraint != "" {
+		if _, err := semver.NewConstraint(r.Constraint); err != nil {
+			return fmt.Errorf("invalid version constraint %q in %s: %w", r.Constraint, r.Source, err)
+		}
+	}
+	if r.VersionPattern != "" {
+		if _, err := regexp.Compile(r.VersionPattern); err != nil {
+			return fmt.Errorf("invalid version pattern %q in %s: %w", r.VersionPattern, r.Source, err)
+		}
+	}
+	for _, pattern := range r.ChartPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid chart pattern %q in %s: %w", pattern, r.Source, err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to the release. Release selectors
+// accept either the release name or namespace/name.
+func (r *versionRule) matches(releaseName, namespace, chartName string) bool {
+	if len(r.Charts) == 0 && len(r.ChartPatterns) == 0 && len(r.Releases) == 0 {
+		return true
+	}
+	for _, name := range r.Releases {
+		if name == releaseName || name == namespace+"/"+releaseName {
+			return true
+		}
+	}
+	for _, name := range r.Charts {
+		if name == chartName {
+			return true
+		}
+	}
+	for _, pattern := range r.ChartPatterns {
+		if regexp.MustCompile(pattern).MatchString(chartName) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleSet is the combination of all rules that apply to a release
+type ruleSet []versionRule
+
+// matchingRules returns the rules that apply to the release
+func matchingRules(rules []versionRule, releaseName, namespace, chartName string) ruleSet {
+	var set ruleSet
+	for i := range rules {
+		if rules[i].matches(releaseName, namespace, chartName) {
+			set = append(set, rules[i])
+		}
+	}
+	return set
+}
+
+// ignored reports whether any rule excludes the release from update checks
+func (s ruleSet) ignored() bool {
+	for _, rule := range s {
+		if rule.Ignore {
+			return true
+		}
+	}
+	return false
+}
+
+// allows reports whether every rule accepts candidate as an update of installed
+func (s ruleSet) allows(installed, candidate string) bool {
+	for _, rule := range s {
+		if rule.Constraint != "" {
+			constraint, err := semver.NewConstraint(rule.Constraint)
+			if err != nil {
+				return false
+			}
+			version, err := semver.NewVersion(candidate)
+			if err != nil || !constraint.Check(version) {
+				return false
+			}
+		}
+		if rule.VersionPattern != "" && !regexp.MustCompile(rule.VersionPattern).MatchString(candidate) {
+			return false
+		}
+		if len(rule.ApprovedVersions) > 0 && !containsString(rule.ApprovedVersions, candidate) {
+			return false
+		}
+		if len(rule.BlockedUpdates) > 0 {
+			kind := updateType(installed, candidate)
+			for _, blocked := range rule.BlockedUpdates {
+				if kind == blocked {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// catalogued reports whether a rule restricts the release to approved versions
+func (s ruleSet) catalogued() bool {
+	for _, rule := range s {
+		if len(rule.ApprovedVersions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// oldEnough reports whether a version published at created satisfies the
+// minimum age of every rule. Versions without a publication date pass.
+func (s ruleSet) oldEnough(created, now time.Time) bool {
+	if created.IsZero() {
+		return true
+	}
+	for _, rule := range s {
+		if rule.MinAge > 0 && now.Sub(created) < rule.MinAge {
+			return false
+		}
+	}
+	return true
+}
+
+// severity returns the severity assigned by the last rule setting one
+func (s ruleSet) severity() string {
+	severity := ""
+	for _, rule := range s {
+		if rule.Severity != "" {
+			severity = rule.Severity
+		}
+	}
+	return severity
+}
+
+// outside reports whether the installed version doesn't satisfy the
+// constraint of a rule or isn't an approved version, e.g. because the release
+// was pinned after it was upgraded past the allowed range
+func (s ruleSet) outside(installed string) bool {
+	for _, rule := range s {
+		if len(rule.ApprovedVersions) > 0 && !containsString(rule.ApprovedVersions, installed) {
+			return true
+		}
+		if rule.Constraint == "" {
+			continue
+		}
+		constraint, err := semver.NewConstraint(rule.Constraint)
+		if err != nil {
+			continue
+		}
+		version, err := semver.NewVersion(installed)
+		if err == nil && !constraint.Check(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadVersionRules collects the version rules from all configured sources
+func loadVersionRules(ctx context.Context) ([]versionRule, error) {
+	var rules []versionRule
+
+	// Releases ignored in the config file or with --ignore
+	if len(cfg.Ignore) > 0 {
+		rules = append(rules, versionRule{Source: "config file", Releases: cfg.Ignore, Ignore: true})
+	}
+	if len(ignoreReleases) > 0 {
+		rules = append(rules, versionRule{Source: "--ignore", Releases: ignoreReleases, Ignore: true})
+	}
+
+	// Versions within the stability window given with --min-version-age
+	if minVersionAge > 0 {
+		rules = append(rules, versionRule{Source: "--min-version-age", MinAge: time.Duration(minVersionAge) * 24 * time.Hour})
+	}
+
+	path := renovateFile
+	if path == "" {
+		path = cfg.RenovateConfig
+	}
+	if path != "" {
+		renovateRules, err := loadRenovateRules(path)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, renovateRules...)
+	}
+
+	if path := pinFile(); path != "" {
+		pinRules, err := loadPinRules(path)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, pinRules...)
+	}
+
+	if path := policyFile(); path != "" {
+		policyRules, err := loadPolicyRules(path)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, policyRules...)
+	}
+
+	if location := catalogLocation(); location != "" {
+		catalogRules, err := loadCatalogRules(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, catalogRules...)
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that releases ignored in the config file or with --ignore are reported as IGNORED
+func TestLoadVersionRulesIgnore(t *testing.T) {
+	cfg.Ignore = []string{"legacy"}
+	ignoreReleases = []string{"prod/db"}
+	defer func() { cfg.Ignore, ignoreReleases = nil, nil }()
+
+	rules, err := loadVersionRules(context.Background())
+	require.NoError(t, err)
+
+	releases := []*release.Release{
+		newTestRelease("legacy", "default", "legacy-app", "1.0.0"),
+		newTestRelease("db", "prod", "postgresql", "12.0.0"),
+		newTestRelease("db", "staging", "postgresql", "12.0.0"),
+	}
+	repositories := []repoIndex{
+		newTestIndex("legacy-app", "2.0.0"),
+		newTestIndex("postgresql", "13.0.0"),
+	}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, rules, nil, &warnings)
+	require.Len(t, result, 3)
+	assert.Equal(t, statusIgnored, result[0].Status)
+	assert.Equal(t, statusIgnored, result[1].Status)
+	assert.Equal(t, statusOutdated, result[2].Status)
+}
+
+// Test that --min-version-age skips versions published within the stability window
+func TestLoadVersionRulesMinVersionAge(t *testing.T) {
+	minVersionAge = 3
+	defer func() { minVersionAge = 0 }()
+
+	rules, err := loadVersionRules(context.Background())
+	require.NoError(t, err)
+
+	idx := newTestIndex("nginx", "1.2.0", "1.1.0", "1.0.0")
+	idx.Entries["nginx"][0].Created = time.Now().Add(-time.Hour)
+	idx.Entries["nginx"][1].Created = time.Now().Add(-5 * 24 * time.Hour)
+
+	var warnings []warning
+	result := processReleases([]*release.Release{newTestRelease("web", "default", "nginx", "1.0.0")},
+		[]repoIndex{idx}, &repo.File{}, map[string]string{}, rules, nil, &warnings)
+	require.Len(t, result, 1)
+	assert.Equal(t, "1.1.0", result[0].LatestVersion)
+}
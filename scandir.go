@@ -0,0 +1,565 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// chartDeclaration is a chart deployed by a file of a GitOps repository
+type chartDeclaration struct {
+	// Source is the file declaring the chart, relative to the scanned directory
+	Source    string
+	Release   string
+	Namespace string
+	Chart     string
+	Version   string
+	// Repository is the URL or the name of the repository providing the chart
+	Repository string
+}
+
+// helmfileDocument is the subset of a helmfile used by scan-dir
+type helmfileDocument struct {
+	Repositories []struct {
+		Name string `yaml:"name"`
+		URL  string `yaml:"url"`
+	} `yaml:"repositories"`
+	Releases []struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+		Chart     string `yaml:"chart"`
+		Version   string `yaml:"version"`
+	} `yaml:"releases"`
+}
+
+// chartFileDocument is the subset of a Chart.yaml used by scan-dir
+type chartFileDocument struct {
+	Name         string `yaml:"name"`
+	Dependencies []struct {
+		Name       string `yaml:"name"`
+		Version    string `yaml:"version"`
+		Repository string `yaml:"repository"`
+	} `yaml:"dependencies"`
+}
+
+// kustomizationDocument is the subset of a kustomization.yaml used by scan-dir
+type kustomizationDocument struct {
+	Namespace  string `yaml:"namespace"`
+	HelmCharts []struct {
+		Name        string `yaml:"name"`
+		Version     string `yaml:"version"`
+		Repo        string `yaml:"repo"`
+		ReleaseName string `yaml:"releaseName"`
+		Namespace   string `yaml:"namespace"`
+	} `yaml:"helmCharts"`
+}
+
+// fluxDocument is the subset of the Flux HelmRelease and HelmRepository
+// manifests used by scan-dir
+type fluxDocument struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec struct {
+		URL             string `yaml:"url"`
+		ReleaseName     string `yaml:"releaseName"`
+		TargetNamespace string `yaml:"targetNamespace"`
+		Chart           struct {
+			Spec struct {
+				Chart     string `yaml:"chart"`
+				Version   string `yaml:"version"`
+				SourceRef struct {
+					Kind      string `yaml:"kind"`
+					Name      string `yaml:"name"`
+					Namespace string `yaml:"namespace"`
+				} `yaml:"sourceRef"`
+			} `yaml:"spec"`
+		} `yaml:"chart"`
+	} `yaml:"spec"`
+}
+
+// fluxHelmRelease is a Flux HelmRelease waiting for its HelmRepository to be found
+type fluxHelmRelease struct {
+	declaration chartDeclaration
+	sourceRef   string
+}
+
+// newScanDirCmd creates the scan-dir subcommand
+func newScanDirCmd() *cobra.Command {
+	var recursive, fast bool
+
+	cmd := &cobra.Command{
+		Use:   "scan-dir DIR",
+		Short: "check the charts declared in helmfiles, Flux HelmReleases, Chart.yaml dependencies and kustomizations of a directory",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if fast {
+				if err := checkFastMode(); err != nil {
+					return err
+				}
+			}
+			suppressed, err := suppressedWarnings(suppressWarnings)
+			if err != nil {
+				return err
+			}
+			// OCI tags are only read from the cache with --fast
+			ociOffline = fast
+			declarations, warnings, err := discoverDeclarations(args[0], recursive)
+			if err != nil {
+				return err
+			}
+			if fast {
+				// Repositories are read from every file, but only changed charts are checked
+				changed, err := changedFiles(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				declarations = changedDeclarations(declarations, changed)
+			}
+			report, err := scanDeclarations(cmd, declarations, warnings)
+			if err != nil {
+				return err
+			}
+			report.warnings = filterWarnings(report.warnings, suppressed)
+			logWarnings(report.warnings)
+			if !quiet {
+				if err := formatAndPrintResults(os.Stdout, report); err != nil {
+					return err
+				}
+			}
+			if githubActions {
+				if err := reportToGitHubActions(os.Stdout, report.results); err != nil {
+					return err
+				}
+			}
+
+			if failOnOutdated || quiet {
+				if err := outdatedError(report.results); err != nil {
+					// Outdated charts are a result, not a usage error
+					cmd.SilenceUsage = true
+					cmd.SilenceErrors = quiet
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	addResultFlags(cmd.Flags())
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "also scan the subdirectories")
+	cmd.Flags().BoolVar(&fast, "fast", false, "only check the charts declared in files changed since the last commit, using cached data only, e.g. in a pre-commit hook")
+	cmd.Flags().DurationVar(&ociCacheTTL, "oci-cache-ttl", defaultOCICacheTTL, "reuse the tags listed from OCI registries for this long. 0 lists them on every run")
+
+	return cmd
+}
+
+// scanDeclarations checks the declared charts against the locally cached
+// repository indexes, without connecting to a cluster
+func scanDeclarations(cmd *cobra.Command, declarations []chartDeclaration, warnings []warning) (scanReport, error) {
+	report := scanReport{warnings: warnings, releases: len(declarations)}
+	if len(declarations) == 0 {
+		// Nothing to check, e.g. when no chart was changed with --fast
+		return report, nil
+	}
+
+	repositories, err := fetchIndices()
+	if err != nil {
+		return report, err
+	}
+	report.repositories = len(repositories)
+
+	repoFile := newSettings().RepositoryConfig
+	repoFileData, err := loadRepoFile(repoFile)
+	if err != nil {
+		return report, fmt.Errorf("failed to load repository file: %w", err)
+	}
+	if len(repoFileData.Repositories) == 0 {
+		addWarning(&report.warnings, warnNoRepositories, "No chart repositories are configured in %s. Add them with `helm repo add`", repoFile)
+	}
+
+	rules, err := loadVersionRules(cmd.Context())
+	if err != nil {
+		return report, err
+	}
+
+	checkIndexFreshness(repositories, time.Now(), &report.warnings)
+
+	// Charts of OCI registries have no index, their tags are listed instead
+	if ociDeclared(declarations) {
+		cache, err := loadOCITagCache(ociTagsFile(), ociCacheTTL, ociOffline)
+		if err != nil {
+			return report, err
+		}
+		list, err := listRegistryTags(newSettings())
+		if err != nil {
+			return report, err
+		}
+		repositories = append(repositories, fetchOCIIndexes(cmd.Context(), declarations, cache, list, &report.warnings)...)
+		if err := cache.save(); err != nil {
+			return report, err
+		}
+	}
+
+	configured := repositoryURLs(repoFileData)
+	chartRepoMap := buildChartRepoMap(repositories, repoFileData)
+	for _, declaration := range declarations {
+		repoName, candidates := declaredRepositories(declaration.Repository, configured, repositories)
+		var annotations map[string]string
+		if repoName != "" {
+			annotations = map[string]string{repositoryAnnotation: repoName}
+		}
+		installed, constraint := resolveDeclaredVersion(declaration, candidates, &report.warnings)
+		rel := newDeclaredRelease(declaration.Release, declaration.Namespace, declaration.Chart, installed, annotations)
+
+		result := processReleases([]*helmRelease{rel}, candidates, repoFileData, chartRepoMap, rules, nil, &report.warnings)
+		for i := range result {
+			result[i].Source = declaration.Source
+			result[i].VersionConstraint = constraint
+		}
+		report.results = append(report.results, result...)
+	}
+	return report, nil
+}
+
+// resolveDeclaredVersion returns the version a declaration deploys. A version
+// range such as ~12.0.0 is resolved to the highest version of the candidate
+// indexes satisfying it, like Helm does when installing the chart, and is
+// returned as the constraint. A range nothing satisfies is returned as is
+// with a warning.
+func resolveDeclaredVersion(declaration chartDeclaration, candidates []repoIndex, warnings *[]warning) (string, string) {
+	if _, err := semver.NewVersion(declaration.Version); err == nil || declaration.Version == "" {
+		return declaration.Version, ""
+	}
+	constraint, err := semver.NewConstraint(declaration.Version)
+	if err != nil {
+		return declaration.Version, ""
+	}
+
+	var resolved *semver.Version
+	for _, idx := range candidates {
+		for _, entry := range idx.Entries[declaration.Chart] {
+			version, err := semver.NewVersion(entry.Version)
+			if err != nil {
+				continue
+			}
+			if !devel && version.Prerelease() != "" {
+				continue
+			}
+			if constraint.Check(version) && (resolved == nil || version.GreaterThan(resolved)) {
+				resolved = version
+			}
+		}
+	}
+	if resolved == nil {
+		if len(candidates) > 0 {
+			addWarning(warnings, warnConstraintUnresolved, "No version of chart %s satisfies %s declared in %s", declaration.Chart, declaration.Version, declaration.Source)
+		}
+		return declaration.Version, declaration.Version
+	}
+	return resolved.Original(), declaration.Version
+}
+
+// declaredRepositories returns the name of the configured repository a
+// declaration refers to and the indexes to look the chart up in. OCI
+// repositories are looked up by URL among the indexes built from their tags.
+// A repository URL that is not configured is returned as is, with no index,
+// so the chart is reported as unknown with the repository to add. Without a
+// repository every index is searched.
+func declaredRepositories(repository string, configured map[string]string, repositories []repoIndex) (string, []repoIndex) {
+	if repository == "" {
+		return "", repositories
+	}
+
+	name := repository
+	if isRepositoryURL(repository) || strings.HasPrefix(repository, "oci://") {
+		name = ""
+		for configuredName, url := range configured {
+			if strings.TrimSuffix(url, "/") == strings.TrimSuffix(repository, "/") {
+				name = configuredName
+				break
+			}
+		}
+		if name == "" && strings.HasPrefix(repository, "oci://") {
+			name = strings.TrimSuffix(repository, "/")
+		}
+		if name == "" {
+			return repository, nil
+		}
+	}
+
+	for _, idx := range repositories {
+		if idx.name == name {
+			return name, []repoIndex{idx}
+		}
+	}
+	return name, nil
+}
+
+// discoverDeclarations finds the charts declared in the files of dir, and in
+// its subdirectories when recursive. Hidden directories such as .git are
+// skipped.
+func discoverDeclarations(dir string, recursive bool) ([]chartDeclaration, []warning, error) {
+	var declarations []chartDeclaration
+	var warnings []warning
+	var fluxReleases []fluxHelmRelease
+	fluxRepositories := map[string]string{}
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && (!recursive || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" && !strings.HasSuffix(path, ".yaml.gotmpl") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		source, err := filepath.Rel(dir, path)
+		if err != nil {
+			source = path
+		}
+
+		name := entry.Name()
+		switch {
+		case name == "Chart.yaml":
+			found, err := chartFileDeclarations(source, data)
+			if err != nil {
+				addWarning(&warnings, warnManifestUnparsed, "Skipping %s: %v", source, err)
+			}
+			declarations = append(declarations, found...)
+		case name == "kustomization.yaml" || name == "kustomization.yml":
+			found, err := kustomizationDeclarations(source, data)
+			if err != nil {
+				addWarning(&warnings, warnManifestUnparsed, "Skipping %s: %v", source, err)
+			}
+			declarations = append(declarations, found...)
+		case strings.HasPrefix(name, "helmfile") || filepath.Base(filepath.Dir(path)) == "helmfile.d":
+			found, err := helmfileDeclarations(source, data)
+			if err != nil {
+				addWarning(&warnings, warnManifestUnparsed, "Skipping %s: %v", source, err)
+			}
+			declarations = append(declarations, found...)
+		default:
+			// Other files may be anything, including chart templates, so
+			// files that can't be parsed are silently skipped
+			releases, repositories := fluxDeclarations(source, data)
+			fluxReleases = append(fluxReleases, releases...)
+			for key, url := range repositories {
+				fluxRepositories[key] = url
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, release := range fluxReleases {
+		release.declaration.Repository = fluxRepositories[release.sourceRef]
+		declarations = append(declarations, release.declaration)
+	}
+	sort.SliceStable(declarations, func(i, j int) bool {
+		return declarations[i].Source < declarations[j].Source
+	})
+	return declarations, warnings, nil
+}
+
+// decodeDocuments calls decode for every document of a multi-document YAML
+// file until the decoder reaches the end of the file
+func decodeDocuments(data []byte, decode func(*yaml.Decoder) error) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		err := decode(decoder)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// helmfileDeclarations returns the releases of a helmfile. Charts are
+// referenced as REPOSITORY/CHART, by OCI reference or by local path; local
+// charts are skipped.
+func helmfileDeclarations(source string, data []byte) ([]chartDeclaration, error) {
+	var declarations []chartDeclaration
+	err := decodeDocuments(data, func(decoder *yaml.Decoder) error {
+		var doc helmfileDocument
+		if err := decoder.Decode(&doc); err != nil {
+			return err
+		}
+
+		repositories := make(map[string]string, len(doc.Repositories))
+		for _, repository := range doc.Repositories {
+			repositories[repository.Name] = repository.URL
+		}
+
+		for _, release := range doc.Releases {
+			repository, chartName := splitChartReference(release.Chart)
+			if chartName == "" {
+				continue
+			}
+			if url, ok := repositories[repository]; ok {
+				repository = url
+			}
+			declarations = append(declarations, chartDeclaration{
+				Source:     source,
+				Release:    release.Name,
+				Namespace:  release.Namespace,
+				Chart:      chartName,
+				Version:    release.Version,
+				Repository: repository,
+			})
+		}
+		return nil
+	})
+	return declarations, err
+}
+
+// splitChartReference splits a helmfile chart reference into its repository
+// and chart name. Local charts return an empty chart name.
+func splitChartReference(reference string) (string, string) {
+	if strings.HasPrefix(reference, "oci://") {
+		i := strings.LastIndex(reference, "/")
+		return reference[:i], reference[i+1:]
+	}
+	if strings.HasPrefix(reference, ".") || strings.HasPrefix(reference, "/") {
+		return "", ""
+	}
+	repository, chartName, found := strings.Cut(reference, "/")
+	if !found || strings.Contains(chartName, "/") {
+		return "", ""
+	}
+	return repository, chartName
+}
+
+// chartFileDeclarations returns the dependencies of a Chart.yaml, reported as
+// releases named after the chart. Repositories referenced by name with
+// @NAME or alias:NAME are looked up among the configured ones.
+func chartFileDeclarations(source string, data []byte) ([]chartDeclaration, error) {
+	var doc chartFileDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var declarations []chartDeclaration
+	for _, dependency := range doc.Dependencies {
+		// Dependencies vendored from a local directory are not published
+		if dependency.Repository == "" || strings.HasPrefix(dependency.Repository, "file://") {
+			continue
+		}
+		repository := strings.TrimPrefix(strings.TrimPrefix(dependency.Repository, "@"), "alias:")
+		declarations = append(declarations, chartDeclaration{
+			Source:     source,
+			Release:    doc.Name,
+			Chart:      dependency.Name,
+			Version:    dependency.Version,
+			Repository: repository,
+		})
+	}
+	return declarations, nil
+}
+
+// kustomizationDeclarations returns the helmCharts of a kustomization.yaml
+func kustomizationDeclarations(source string, data []byte) ([]chartDeclaration, error) {
+	var doc kustomizationDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	declarations := make([]chartDeclaration, 0, len(doc.HelmCharts))
+	for _, helmChart := range doc.HelmCharts {
+		release := helmChart.ReleaseName
+		if release == "" {
+			release = helmChart.Name
+		}
+		namespace := helmChart.Namespace
+		if namespace == "" {
+			namespace = doc.Namespace
+		}
+		declarations = append(declarations, chartDeclaration{
+			Source:     source,
+			Release:    release,
+			Namespace:  namespace,
+			Chart:      helmChart.Name,
+			Version:    helmChart.Version,
+			Repository: helmChart.Repo,
+		})
+	}
+	return declarations, nil
+}
+
+// fluxDeclarations returns the Flux HelmReleases of a manifest, together with
+// the URLs of its HelmRepositories keyed by namespace/name. The repository of
+// a HelmRelease is resolved once every file was read since it is usually
+// declared in another one.
+func fluxDeclarations(source string, data []byte) ([]fluxHelmRelease, map[string]string) {
+	var releases []fluxHelmRelease
+	repositories := map[string]string{}
+	_ = decodeDocuments(data, func(decoder *yaml.Decoder) error {
+		var doc fluxDocument
+		if err := decoder.Decode(&doc); err != nil {
+			return err
+		}
+		if !strings.Contains(doc.APIVersion, "fluxcd.io") {
+			return nil
+		}
+
+		switch doc.Kind {
+		case "HelmRepository":
+			repositories[doc.Metadata.Namespace+"/"+doc.Metadata.Name] = doc.Spec.URL
+		case "HelmRelease":
+			chartSpec := doc.Spec.Chart.Spec
+			if chartSpec.Chart == "" || chartSpec.SourceRef.Kind != "HelmRepository" {
+				return nil
+			}
+			release := doc.Spec.ReleaseName
+			if release == "" {
+				release = doc.Metadata.Name
+			}
+			namespace := doc.Spec.TargetNamespace
+			if namespace == "" {
+				namespace = doc.Metadata.Namespace
+			}
+			sourceNamespace := chartSpec.SourceRef.Namespace
+			if sourceNamespace == "" {
+				sourceNamespace = doc.Metadata.Namespace
+			}
+			releases = append(releases, fluxHelmRelease{
+				declaration: chartDeclaration{
+					Source:    source,
+					Release:   release,
+					Namespace: namespace,
+					Chart:     chartSpec.Chart,
+					Version:   chartSpec.Version,
+				},
+				sourceRef: sourceNamespace + "/" + chartSpec.SourceRef.Name,
+			})
+		}
+		return nil
+	})
+	return releases, repositories
+}
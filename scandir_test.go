@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestFiles writes the files, keyed by their path relative to dir
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+// testGitOpsFiles is a monorepo deploying charts in every supported way
+var testGitOpsFiles = map[string]string{
+	"helmfile.yaml": `
+repositories:
+  - name: ingress
+    url: https://kubernetes.github.io/ingress-nginx
+releases:
+  - name: ingress
+    namespace: ingress
+    chart: ingress/ingress-nginx
+    version: 4.0.0
+  - name: local
+    chart: ./charts/local
+`,
+	"apps/Chart.yaml": `
+apiVersion: v2
+name: shop
+version: 1.0.0
+dependencies:
+  - name: postgresql
+    version: 12.0.0
+    repository: "@bitnami"
+  - name: common
+    version: 1.0.0
+    repository: file://../common
+`,
+	"apps/kustomization.yaml": `
+namespace: monitoring
+helmCharts:
+  - name: grafana
+    version: 7.0.0
+    repo: https://grafana.github.io/helm-charts
+`,
+	"clusters/prod/redis.yaml": `
+apiVersion: source.toolkit.fluxcd.io/v1
+kind: HelmRepository
+metadata:
+  name: bitnami
+  namespace: flux-system
+spec:
+  url: https://charts.bitnami.com/bitnami
+---
+apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: cache
+  namespace: flux-system
+spec:
+  targetNamespace: cache
+  chart:
+    spec:
+      chart: redis
+      version: 18.0.0
+      sourceRef:
+        kind: HelmRepository
+        name: bitnami
+`,
+	"apps/templates/deployment.yaml": "{{ .Values.broken }",
+	".git/helmfile.yaml":             "releases: [{name: hidden, chart: repo/hidden}]",
+}
+
+// Test that charts are discovered in helmfiles, Chart.yaml dependencies, kustomizations and Flux HelmReleases
+func TestDiscoverDeclarations(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, testGitOpsFiles)
+
+	declarations, warnings, err := discoverDeclarations(dir, true)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, []chartDeclaration{
+		{Source: "apps/Chart.yaml", Release: "shop", Chart: "postgresql", Version: "12.0.0", Repository: "bitnami"},
+		{Source: "apps/kustomization.yaml", Release: "grafana", Namespace: "monitoring", Chart: "grafana", Version: "7.0.0", Repository: "https://grafana.github.io/helm-charts"},
+		{Source: "clusters/prod/redis.yaml", Release: "cache", Namespace: "cache", Chart: "redis", Version: "18.0.0", Repository: "https://charts.bitnami.com/bitnami"},
+		{Source: "helmfile.yaml", Release: "ingress", Namespace: "ingress", Chart: "ingress-nginx", Version: "4.0.0", Repository: "https://kubernetes.github.io/ingress-nginx"},
+	}, declarations)
+
+	// Without --recursive only the files of the directory itself are read
+	declarations, _, err = discoverDeclarations(dir, false)
+	require.NoError(t, err)
+	require.Len(t, declarations, 1)
+	assert.Equal(t, "helmfile.yaml", declarations[0].Source)
+}
+
+// Test that broken helmfiles are reported as warnings
+func TestDiscoverDeclarationsUnparsed(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"helmfile.yaml": "releases: {{ .Values.releases }}"})
+
+	declarations, warnings, err := discoverDeclarations(dir, false)
+	require.NoError(t, err)
+	assert.Empty(t, declarations)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, warnManifestUnparsed, warnings[0].Code)
+}
+
+// Test that declared repositories are matched with the configured ones by URL or name
+func TestDeclaredRepositories(t *testing.T) {
+	repositories := []repoIndex{newTestIndex("redis", "18.0.0"), newTestIndex("postgresql", "12.0.0")}
+	configured := map[string]string{"redis": "https://charts.example.com/redis/", "postgresql": "https://charts.example.com/postgresql"}
+
+	name, candidates := declaredRepositories("https://charts.example.com/redis", configured, repositories)
+	assert.Equal(t, "redis", name)
+	assert.Equal(t, repositories[:1], candidates)
+
+	name, candidates = declaredRepositories("postgresql", configured, repositories)
+	assert.Equal(t, "postgresql", name)
+	assert.Equal(t, repositories[1:], candidates)
+
+	name, candidates = declaredRepositories("https://elsewhere.example.com", configured, repositories)
+	assert.Equal(t, "https://elsewhere.example.com", name)
+	assert.Empty(t, candidates)
+
+	name, candidates = declaredRepositories("", configured, repositories)
+	assert.Empty(t, name)
+	assert.Equal(t, repositories, candidates)
+}
+
+// Test that declared charts are checked against the cached repository indexes
+func TestScanDeclarations(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"repositories.yaml": `
+repositories:
+  - name: bitnami
+    url: https://charts.bitnami.com/bitnami
+`,
+		"cache/bitnami-index.yaml": `
+apiVersion: v1
+entries:
+  redis:
+    - name: redis
+      version: 18.1.0
+    - name: redis
+      version: 18.0.0
+`,
+	})
+	t.Setenv("HELM_REPOSITORY_CONFIG", filepath.Join(dir, "repositories.yaml"))
+	t.Setenv("HELM_REPOSITORY_CACHE", filepath.Join(dir, "cache"))
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	report, err := scanDeclarations(cmd, []chartDeclaration{
+		{Source: "redis.yaml", Release: "cache", Namespace: "cache", Chart: "redis", Version: "18.0.0", Repository: "https://charts.bitnami.com/bitnami"},
+		{Source: "kustomization.yaml", Release: "grafana", Chart: "grafana", Version: "7.0.0", Repository: "https://grafana.github.io/helm-charts"},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, report.results, 2)
+
+	assert.Equal(t, statusOutdated, report.results[0].Status)
+	assert.Equal(t, "18.1.0", report.results[0].LatestVersion)
+	assert.Equal(t, "bitnami", report.results[0].RepoName)
+	assert.Equal(t, "redis.yaml", report.results[0].Source)
+
+	assert.Equal(t, statusUnknown, report.results[1].Status)
+	require.NotNil(t, report.results[1].SuggestedRepository)
+	assert.Equal(t, "https://grafana.github.io/helm-charts", report.results[1].SuggestedRepository.URL)
+	assert.False(t, hasWarning(report.warnings, warnNoRepositories))
+
+	// Without a repositories file every chart is reported as unknown
+	t.Setenv("HELM_REPOSITORY_CONFIG", filepath.Join(dir, "missing.yaml"))
+	report, err = scanDeclarations(cmd, []chartDeclaration{
+		{Source: "redis.yaml", Release: "cache", Chart: "redis", Version: "18.0.0", Repository: "https://charts.bitnami.com/bitnami"},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, report.results, 1)
+	assert.Equal(t, statusUnknown, report.results[0].Status)
+	assert.True(t, hasWarning(report.warnings, warnNoRepositories))
+}
+
+// Test that declared version ranges are resolved to the highest satisfying
+// version instead of being compared as written
+func TestScanDeclarationsVersionRange(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"repositories.yaml": `
+repositories:
+  - name: bitnami
+    url: https://charts.bitnami.com/bitnami
+`,
+		"cache/bitnami-index.yaml": `
+apiVersion: v1
+entries:
+  redis:
+    - name: redis
+      version: 18.1.0
+    - name: redis
+      version: 18.0.2
+    - name: redis
+      version: 18.0.0
+`,
+	})
+	t.Setenv("HELM_REPOSITORY_CONFIG", filepath.Join(dir, "repositories.yaml"))
+	t.Setenv("HELM_REPOSITORY_CACHE", filepath.Join(dir, "cache"))
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	report, err := scanDeclarations(cmd, []chartDeclaration{
+		{Source: "a.yaml", Release: "patch", Chart: "redis", Version: "~18.0.0", Repository: "bitnami"},
+		{Source: "b.yaml", Release: "major", Chart: "redis", Version: ">=18.0.0 <19", Repository: "bitnami"},
+		{Source: "c.yaml", Release: "none", Chart: "redis", Version: "~17.0.0", Repository: "bitnami"},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, report.results, 3)
+
+	assert.Equal(t, statusOutdated, report.results[0].Status)
+	assert.Equal(t, "18.0.2", report.results[0].InstalledVersion)
+	assert.Equal(t, "~18.0.0", report.results[0].VersionConstraint)
+	assert.Equal(t, "MINOR", report.results[0].UpdateSeverity)
+
+	assert.Equal(t, statusUptodate, report.results[1].Status)
+	assert.Equal(t, "18.1.0", report.results[1].InstalledVersion)
+	assert.Equal(t, ">=18.0.0 <19", report.results[1].VersionConstraint)
+
+	assert.Equal(t, "~17.0.0", report.results[2].InstalledVersion)
+	assert.True(t, hasWarning(report.warnings, warnConstraintUnresolved))
+}
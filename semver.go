@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Update types describing the semver delta between two versions
+const (
+	updateMajor = "major"
+	updateMinor = "minor"
+	updatePatch = "patch"
+)
+
+// updateSeverities are the severities of updates, from the least to the most severe
+var updateSeverities = []string{severityTrivial, "PATCH", "MINOR", "MAJOR"}
+
+// updateSeverity returns the severity of the update from installed to latest,
+// or an empty string when there is no valid semver update
+func updateSeverity(installed, latest string) string {
+	return strings.ToUpper(updateType(installed, latest))
+}
+
+// severityRank returns the position of a severity in updateSeverities, -1
+// for releases without an update
+func severityRank(severity string) int {
+	for i, level := range updateSeverities {
+		if level == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// filterBySeverity drops the outdated releases whose update is less severe
+// than minimum. Releases with any other status are kept.
+func filterBySeverity(result []ChartVersionInfo, minimum string) []ChartVersionInfo {
+	filtered := result[:0]
+	for _, versionInfo := range result {
+		if versionInfo.Status == statusOutdated && severityRank(versionInfo.UpdateSeverity) < severityRank(minimum) {
+			continue
+		}
+		filtered = append(filtered, versionInfo)
+	}
+	return filtered
+}
+
+// newerVersion reports whether candidate is a newer version than installed.
+// Versions that aren't valid semver are only compared for equality.
+func newerVersion(installed, candidate string) bool {
+	from, err := semver.NewVersion(installed)
+	if err != nil {
+		return installed != candidate
+	}
+	to, err := semver.NewVersion(candidate)
+	if err != nil {
+		return installed != candidate
+	}
+	return to.GreaterThan(from)
+}
+
+// updateType classifies the update from installed to candidate as a major,
+// minor or patch update. It returns an empty string when either version is not
+// valid semver or the candidate is not newer than the installed version.
+func updateType(installed, candidate string) string {
+	from, err := semver.NewVersion(installed)
+	if err != nil {
+		return ""
+	}
+	to, err := semver.NewVersion(candidate)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case !to.GreaterThan(from):
+		return ""
+	case to.Major() != from.Major():
+		return updateMajor
+	case to.Minor() != from.Minor():
+		return updateMinor
+	default:
+		return updatePatch
+	}
+}
+
+// AvailableVersion is a released version between the installed and the latest version
+type AvailableVersion struct {
+	Version string     `json:"version"`
+	Created *time.Time `json:"created,omitempty"`
+}
+
+// availableVersions returns the released versions in the index that are newer
+// than installed and not newer than latest, oldest first. Pre-releases are
+// only included with --devel, like when looking up the latest version.
+func availableVersions(entries helmChartVersions, installed, latest string) []AvailableVersion {
+	from, err := semver.NewVersion(installed)
+	if err != nil {
+		return nil
+	}
+	to, err := semver.NewVersion(latest)
+	if err != nil {
+		return nil
+	}
+
+	type candidate struct {
+		version *semver.Version
+		created time.Time
+	}
+	seen := make(map[string]bool)
+	var candidates []candidate
+	for _, entry := range entries {
+		version, err := semver.NewVersion(entry.Version)
+		if err != nil || seen[version.String()] {
+			continue
+		}
+		if !devel && version.Prerelease() != "" {
+			continue
+		}
+		if version.GreaterThan(from) && !version.GreaterThan(to) {
+			seen[version.String()] = true
+			candidates = append(candidates, candidate{version: version, created: entry.Created})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].version.LessThan(candidates[j].version)
+	})
+
+	available := make([]AvailableVersion, 0, len(candidates))
+	for _, c := range candidates {
+		version := AvailableVersion{Version: c.version.Original()}
+		if !c.created.IsZero() {
+			created := c.created
+			version.Created = &created
+		}
+		available = append(available, version)
+	}
+	return available
+}
+
+// versionsBehind counts the released versions between installed and latest
+func versionsBehind(entries helmChartVersions, installed, latest string) int {
+	return len(availableVersions(entries, installed, latest))
+}
+
+// behindSuffix renders the number of versions a release is behind for the short output
+func behindSuffix(versionInfo ChartVersionInfo) string {
+	if versionInfo.VersionsBehind == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d versions behind)", versionInfo.VersionsBehind)
+}
+
+// appVersionOf returns the application version shipped by the given version
+// of a chart
+func appVersionOf(entries helmChartVersions, chartVersion string) string {
+	for _, entry := range entries {
+		if entry.Version == chartVersion {
+			return entry.AppVersion
+		}
+	}
+	return ""
+}
+
+// sameAppVersion reports whether the latest chart version ships the installed
+// application version. Unknown application versions are never the same.
+func sameAppVersion(versionInfo ChartVersionInfo) bool {
+	return versionInfo.InstalledAppVersion != "" &&
+		strings.TrimPrefix(versionInfo.InstalledAppVersion, "v") == strings.TrimPrefix(versionInfo.LatestAppVersion, "v")
+}
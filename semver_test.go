@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Test that the update severity is computed from the semver delta
+func TestUpdateSeverity(t *testing.T) {
+	assert.Equal(t, "MAJOR", updateSeverity("1.2.3", "2.0.0"))
+	assert.Equal(t, "MINOR", updateSeverity("1.2.3", "1.3.0"))
+	assert.Equal(t, "PATCH", updateSeverity("1.2.3", "1.2.4"))
+	assert.Empty(t, updateSeverity("1.2.3", "1.2.3"))
+	assert.Empty(t, updateSeverity("latest", "1.2.3"))
+}
+
+// Test that outdated releases get an update severity and --min-severity drops less severe updates
+func TestFilterBySeverity(t *testing.T) {
+	releases := []*release.Release{
+		newTestRelease("web", "default", "nginx", "1.0.0"),
+		newTestRelease("db", "default", "postgresql", "12.0.0"),
+		newTestRelease("cache", "default", "redis", "7.0.0"),
+	}
+	repositories := []repoIndex{
+		newTestIndex("nginx", "1.0.1", "1.0.0"),
+		newTestIndex("postgresql", "13.0.0", "12.0.0"),
+		newTestIndex("redis", "7.0.0"),
+	}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+	require.Len(t, result, 3)
+	assert.Equal(t, "PATCH", result[0].UpdateSeverity)
+	assert.Equal(t, "MAJOR", result[1].UpdateSeverity)
+	assert.Empty(t, result[2].UpdateSeverity)
+
+	result = filterBySeverity(result, "MINOR")
+	require.Len(t, result, 2)
+	assert.Equal(t, "db", result[0].ReleaseName)
+	assert.Equal(t, "cache", result[1].ReleaseName)
+}
+
+// Test that the versions between the installed and the latest version are counted
+func TestVersionsBehind(t *testing.T) {
+	entries := newTestIndex("nginx", "1.3.0", "1.3.0-rc.1", "1.2.0", "1.1.0", "1.0.1", "1.0.0", "0.9.0").Entries["nginx"]
+
+	assert.Equal(t, 4, versionsBehind(entries, "1.0.0", "1.3.0"))
+	assert.Equal(t, 2, versionsBehind(entries, "1.0.0", "1.1.0"))
+	assert.Equal(t, 0, versionsBehind(entries, "1.3.0", "1.3.0"))
+	assert.Equal(t, 0, versionsBehind(entries, "custom", "1.3.0"))
+
+	devel = true
+	defer func() { devel = false }()
+	assert.Equal(t, 5, versionsBehind(entries, "1.0.0", "1.3.0"))
+}
+
+// Test that the versions between the installed and the latest version are listed oldest first with their release dates
+func TestAvailableVersions(t *testing.T) {
+	idx := newTestIndex("nginx", "2.0.0", "1.1.0", "1.2.0", "1.0.0")
+	created := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	idx.Entries["nginx"][0].Created = created
+
+	available := availableVersions(idx.Entries["nginx"], "1.0.0", "2.0.0")
+	require.Len(t, available, 3)
+	assert.Equal(t, "1.1.0", available[0].Version)
+	assert.Nil(t, available[0].Created)
+	assert.Equal(t, "1.2.0", available[1].Version)
+	assert.Equal(t, "2.0.0", available[2].Version)
+	assert.Equal(t, created, *available[2].Created)
+}
+
+// Test that app versions are reported and --app-version-only ignores chart-only bumps
+func TestAppVersionOnly(t *testing.T) {
+	web := newTestRelease("web", "default", "nginx", "1.0.0")
+	web.Chart.Metadata.AppVersion = "1.25.0"
+	db := newTestRelease("db", "default", "postgresql", "12.0.0")
+	db.Chart.Metadata.AppVersion = "15.1"
+
+	nginx := newTestIndex("nginx", "1.0.1", "1.0.0")
+	nginx.Entries["nginx"][0].AppVersion = "v1.25.0"
+	postgresql := newTestIndex("postgresql", "12.1.0", "12.0.0")
+	postgresql.Entries["postgresql"][0].AppVersion = "15.2"
+	releases := []*release.Release{web, db}
+	repositories := []repoIndex{nginx, postgresql}
+
+	var warnings []warning
+	result := processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+	require.Len(t, result, 2)
+	assert.Equal(t, "1.25.0", result[0].InstalledAppVersion)
+	assert.Equal(t, "v1.25.0", result[0].LatestAppVersion)
+	assert.Equal(t, statusOutdated, result[0].Status)
+
+	appVersionOnly = true
+	defer func() { appVersionOnly = false }()
+
+	result = processReleases(releases, repositories, &repo.File{}, map[string]string{}, nil, nil, &warnings)
+	require.Len(t, result, 2)
+	assert.Equal(t, statusUptodate, result[0].Status)
+	assert.Equal(t, statusOutdated, result[1].Status)
+}
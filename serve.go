@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// serveShutdownTimeout bounds the time in-flight requests get to complete on shutdown
+const serveShutdownTimeout = 5 * time.Second
+
+// metricsExporter holds the metrics of the last successful scan
+type metricsExporter struct {
+	mu      sync.RWMutex
+	metrics []byte
+	// failures counts the scans that failed since the exporter started
+	failures int
+	// lastErr is the error of the last scan, nil if it succeeded
+	lastErr error
+	// started and updated are the times the exporter started and the last
+	// scan succeeded. The exporter is unhealthy when no scan succeeded for
+	// longer than maxAge, if set.
+	started time.Time
+	updated time.Time
+	maxAge  time.Duration
+}
+
+// update replaces the metrics with the results of a scan
+func (e *metricsExporter) update(result []ChartVersionInfo, now time.Time) {
+	var metrics bytes.Buffer
+	formatPrometheus(&metrics, result, now)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.metrics = metrics.Bytes()
+	e.updated = now
+	e.lastErr = nil
+}
+
+// fail records a failed scan. The metrics of the last successful scan are
+// kept, their timestamp tells how old they are.
+func (e *metricsExporter) fail(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures++
+	e.lastErr = err
+}
+
+// health returns why the exporter is unhealthy: the last scan failed, or no
+// scan succeeded for longer than maxAge, e.g. because scans hang
+func (e *metricsExporter) health(now time.Time) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.lastErr != nil {
+		return fmt.Errorf("the last scan failed: %w", e.lastErr)
+	}
+	last := e.updated
+	if last.IsZero() {
+		last = e.started
+	}
+	if e.maxAge > 0 && now.Sub(last) > e.maxAge {
+		return fmt.Errorf("no scan succeeded since %s", last.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ServeHTTP serves the metrics, or 503 until the first scan succeeded
+func (e *metricsExporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.metrics == nil {
+		http.Error(w, "the first scan has not completed yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(e.metrics)
+	fmt.Fprintln(w, "# HELP helm_whatup_scan_failures_total Number of scans that failed since the exporter started.")
+	fmt.Fprintln(w, "# TYPE helm_whatup_scan_failures_total counter")
+	fmt.Fprintf(w, "helm_whatup_scan_failures_total %d\n", e.failures)
+}
+
+// newServeMux routes /metrics to the exporter and answers /healthz with 503
+// while the exporter is unhealthy
+func newServeMux(exporter *metricsExporter) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		if err := exporter.health(time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// rescan scans the releases every interval until ctx is done and updates the
+// exporter with the results. Failed scans are printed and retried at the
+// next interval.
+func rescan(ctx context.Context, interval time.Duration, exporter *metricsExporter, scanFn func(context.Context) (scanReport, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := scanFn(ctx)
+		if err != nil {
+			logger.Error("scan failed", "error", err)
+			exporter.fail(err)
+		} else {
+			exporter.update(report.results, time.Now())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newServeCmd creates the serve subcommand
+func newServeCmd() *cobra.Command {
+	var (
+		listen   string
+		interval time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "periodically rescan the cluster and expose the results as Prometheus metrics on /metrics",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if interval <= 0 {
+				return fmt.Errorf("invalid --interval %s, it must be positive", interval)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// Two intervals leave room for a scan slower than the interval
+			exporter := &metricsExporter{started: time.Now(), maxAge: 2 * interval}
+			server := &http.Server{
+				Addr:              listen,
+				Handler:           newServeMux(exporter),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			go rescan(ctx, interval, exporter, scan)
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+				defer cancel()
+				_ = server.Shutdown(shutdownCtx)
+			}()
+
+			logger.Info("serving metrics", "address", listen, "interval", interval)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("failed to serve metrics: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", ":9571", "address to serve /metrics and /healthz on. /healthz fails after a failed scan or when no scan succeeded for two intervals")
+	cmd.Flags().DurationVar(&interval, "interval", time.Hour, "time between two scans")
+
+	return cmd
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the metrics of the last successful scan are served
+func TestServeMetrics(t *testing.T) {
+	exporter := &metricsExporter{}
+	server := httptest.NewServer(newServeMux(exporter))
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, _ := get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = get("/metrics")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	scans := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	rescan(ctx, time.Millisecond, exporter, func(context.Context) (scanReport, error) {
+		scans++
+		if scans == 2 {
+			cancel()
+			return scanReport{}, errors.New("cluster unreachable")
+		}
+		return scanReport{results: []ChartVersionInfo{
+			{ReleaseName: "web", Namespace: "prod", ChartName: "nginx", RepoName: "bitnami", Status: statusOutdated, VersionsBehind: 2},
+		}}, nil
+	})
+	assert.Equal(t, 2, scans)
+
+	status, body := get("/metrics")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `helm_whatup_release_outdated{release="web",namespace="prod",chart="nginx",repo="bitnami",priority="",update_severity=""} 1`)
+	assert.Contains(t, body, "helm_whatup_scan_failures_total 1\n")
+
+	status, body = get("/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, body, "cluster unreachable")
+}
+
+// Test that the exporter is unhealthy when no scan succeeded for too long
+func TestMetricsExporterHealth(t *testing.T) {
+	started := time.Now()
+	exporter := &metricsExporter{started: started, maxAge: time.Hour}
+
+	assert.NoError(t, exporter.health(started.Add(time.Minute)))
+	assert.Error(t, exporter.health(started.Add(2*time.Hour)))
+
+	exporter.update(nil, started.Add(90*time.Minute))
+	assert.NoError(t, exporter.health(started.Add(2*time.Hour)))
+	assert.Error(t, exporter.health(started.Add(3*time.Hour)))
+
+	exporter.fail(errors.New("timeout"))
+	assert.ErrorContains(t, exporter.health(started.Add(100*time.Minute)), "timeout")
+}
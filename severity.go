@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// severityVerdict is the answer of a severity hook for a single result. Empty
+// fields leave the result unchanged.
+type severityVerdict struct {
+	Severity string `json:"severity"`
+	Status   string `json:"status"`
+}
+
+// runSeverityHook invokes the hook once per result with the result as JSON on
+// stdin and applies the verdict it prints as JSON on stdout. Failures are
+// reported as warnings and leave the result unchanged.
+func runSeverityHook(ctx context.Context, hook string, result []ChartVersionInfo, warnings *[]warning) {
+	for i := range result {
+		verdict, err := callSeverityHook(ctx, hook, result[i])
+		if err != nil {
+			addWarning(warnings, warnSeverityHookFailed, "Severity hook failed for release %s: %v", result[i].ReleaseName, err)
+			continue
+		}
+		if verdict.Status != "" && !containsString(knownStatuses, verdict.Status) {
+			addWarning(warnings, warnSeverityHookFailed, "Severity hook returned unknown status %q for release %s", verdict.Status, result[i].ReleaseName)
+			continue
+		}
+
+		if verdict.Severity != "" {
+			result[i].Severity = verdict.Severity
+		}
+		if verdict.Status != "" {
+			result[i].Status = verdict.Status
+		}
+	}
+}
+
+// callSeverityHook runs the hook for a single result
+func callSeverityHook(ctx context.Context, hook string, versionInfo ChartVersionInfo) (severityVerdict, error) {
+	var verdict severityVerdict
+
+	input, err := json.Marshal(versionInfo)
+	if err != nil {
+		return verdict, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	var output bytes.Buffer
+	// #nosec G204 -- the hook is provided by the user running the plugin
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return verdict, err
+	}
+
+	if err := json.Unmarshal(output.Bytes(), &verdict); err != nil {
+		return verdict, fmt.Errorf("invalid output: %w", err)
+	}
+	return verdict, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeHook writes an executable shell script to a temporary directory
+func writeHook(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o700)) // #nosec G306 -- the hook must be executable
+	return path
+}
+
+// Test that the verdict of the severity hook is applied to every result
+func TestRunSeverityHook(t *testing.T) {
+	hook := writeHook(t, `if grep -q '"releaseName":"db"'; then
+  echo '{"severity": "critical", "status": "IGNORED"}'
+else
+  echo '{"severity": "low"}'
+fi
+`)
+
+	result := []ChartVersionInfo{
+		{ReleaseName: "db", Status: statusOutdated},
+		{ReleaseName: "web", Status: statusOutdated},
+	}
+	var warnings []warning
+	runSeverityHook(context.Background(), hook, result, &warnings)
+
+	assert.Empty(t, warnings)
+	assert.Equal(t, "critical", result[0].Severity)
+	assert.Equal(t, statusIgnored, result[0].Status)
+	assert.Equal(t, "low", result[1].Severity)
+	assert.Equal(t, statusOutdated, result[1].Status)
+}
+
+// Test that failing hooks and unknown statuses leave the results unchanged
+func TestRunSeverityHookFailures(t *testing.T) {
+	result := []ChartVersionInfo{{ReleaseName: "db", Status: statusOutdated}}
+
+	for _, script := range []string{"exit 1\n", "echo not-json\n", `echo '{"status": "BROKEN"}'` + "\n"} {
+		var warnings []warning
+		runSeverityHook(context.Background(), writeHook(t, script), result, &warnings)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, warnSeverityHookFailed, warnings[0].Code)
+		assert.Equal(t, statusOutdated, result[0].Status)
+		assert.Empty(t, result[0].Severity)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// bundleSuffix is appended to the path of a signed report for its cosign bundle
+const bundleSuffix = ".bundle"
+
+// cosignBinary returns the cosign binary used to sign reports
+func cosignBinary() string {
+	if bin := os.Getenv("COSIGN_BIN"); bin != "" {
+		return bin
+	}
+	return "cosign"
+}
+
+// signingEnabled reports whether written reports are signed
+func signingEnabled() bool {
+	return signReports || signKey != ""
+}
+
+// signArgs returns the cosign arguments signing a report file. Without a key
+// cosign signs keyless with a short-lived certificate from Fulcio and records
+// the signature in the Rekor transparency log.
+func signArgs(path, key string) []string {
+	args := []string{"sign-blob", "--yes", "--bundle", path + bundleSuffix}
+	if key != "" {
+		args = append(args, "--key", key)
+	}
+	return append(args, path)
+}
+
+// signReportFile signs a report file with cosign, writing the signature, and
+// the certificate for keyless signing, to the bundle next to the report
+func signReportFile(ctx context.Context, path, key string) error {
+	args := signArgs(path, key)
+	if dryRun {
+		dryRunf("%s %s", cosignBinary(), strings.Join(args, " "))
+		return nil
+	}
+	// #nosec G204 -- cosign is provided by the user running the plugin
+	cmd := exec.CommandContext(ctx, cosignBinary(), args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to sign report %s: %w", path, err)
+	}
+	return nil
+}
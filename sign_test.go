@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that reports are signed keyless unless a key is given
+func TestSignArgs(t *testing.T) {
+	assert.Equal(t, []string{"sign-blob", "--yes", "--bundle", "r.json.bundle", "r.json"}, signArgs("r.json", ""))
+	assert.Equal(t, []string{"sign-blob", "--yes", "--bundle", "r.json.bundle", "--key", "cosign.key", "r.json"}, signArgs("r.json", "cosign.key"))
+}
+
+// Test that cosign is run for the report file and its failures are returned
+func TestSignReportFile(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	script := filepath.Join(dir, "cosign")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" > "+args+"\n"), 0o700))
+	t.Setenv("COSIGN_BIN", script)
+
+	require.NoError(t, signReportFile(context.Background(), "report.json.gz", "cosign.key"))
+	data, err := os.ReadFile(args)
+	require.NoError(t, err)
+	assert.Equal(t, "sign-blob --yes --bundle report.json.gz.bundle --key cosign.key report.json.gz\n", string(data))
+
+	t.Setenv("COSIGN_BIN", "false")
+	assert.Error(t, signReportFile(context.Background(), "report.json.gz", ""))
+}
+
+// Test that --dry-run only prints the signing command
+func TestSignReportFileDryRun(t *testing.T) {
+	var out bytes.Buffer
+	dryRun, dryRunOut = true, &out
+	defer func() { dryRun, dryRunOut = false, os.Stderr }()
+	t.Setenv("COSIGN_BIN", "false")
+
+	require.NoError(t, signReportFile(context.Background(), "report.json", ""))
+	assert.Equal(t, "DRY-RUN: false sign-blob --yes --bundle report.json.bundle report.json\n", out.String())
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultStatsdPrefix prefixes the names of the metrics sent to StatsD
+	defaultStatsdPrefix = "helm_whatup"
+	// statsdMaxPacket keeps packets below the MTU of common networks
+	statsdMaxPacket = 1432
+	// statsdTimeout bounds sending the metrics of a run
+	statsdTimeout = 5 * time.Second
+)
+
+// statsdConfig configures sending summary metrics to StatsD at the end of a run
+type statsdConfig struct {
+	// Address is the HOST:PORT of the StatsD or DogStatsD agent, over UDP
+	Address string `yaml:"address"`
+	Prefix  string `yaml:"prefix"`
+	// DogStatsD sends the namespace as a tag instead of in the metric name
+	DogStatsD bool `yaml:"dogstatsd"`
+}
+
+// statsdAddress returns the StatsD address given with --statsd or in the config file
+func statsdAddress() string {
+	if statsdAddr != "" {
+		return statsdAddr
+	}
+	return cfg.Statsd.Address
+}
+
+// statsdMetrics renders the summary metrics of a scan as StatsD gauges: the
+// number of releases, of outdated and vulnerable releases and of outdated
+// releases per namespace
+func statsdMetrics(config statsdConfig, releases int, result []ChartVersionInfo) []string {
+	prefix := config.Prefix
+	if prefix == "" {
+		prefix = defaultStatsdPrefix
+	}
+
+	outdated, vulnerable := 0, 0
+	perNamespace := make(map[string]int)
+	for _, versionInfo := range result {
+		// Gauges keep their last value, so namespaces without outdated
+		// releases are sent as well
+		perNamespace[versionInfo.Namespace] += 0
+		switch versionInfo.Status {
+		case statusOutdated:
+			outdated++
+			perNamespace[versionInfo.Namespace]++
+		case statusVulnerable:
+			vulnerable++
+		}
+	}
+
+	metrics := []string{
+		fmt.Sprintf("%s.releases:%d|g", prefix, releases),
+		fmt.Sprintf("%s.outdated:%d|g", prefix, outdated),
+		fmt.Sprintf("%s.vulnerable:%d|g", prefix, vulnerable),
+	}
+
+	namespaces := make([]string, 0, len(perNamespace))
+	for namespace := range perNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		if config.DogStatsD {
+			metrics = append(metrics, fmt.Sprintf("%s.namespace.outdated:%d|g|#namespace:%s", prefix, perNamespace[namespace], namespace))
+		} else {
+			metrics = append(metrics, fmt.Sprintf("%s.namespace.%s.outdated:%d|g", prefix, namespace, perNamespace[namespace]))
+		}
+	}
+	return metrics
+}
+
+// statsdPackets batches the metrics into packets of at most statsdMaxPacket bytes
+func statsdPackets(metrics []string) []string {
+	var packets []string
+	var packet strings.Builder
+	for _, metric := range metrics {
+		if packet.Len() > 0 && packet.Len()+1+len(metric) > statsdMaxPacket {
+			packets = append(packets, packet.String())
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(metric)
+	}
+	if packet.Len() > 0 {
+		packets = append(packets, packet.String())
+	}
+	return packets
+}
+
+// sendStatsdMetrics sends the metrics to the StatsD agent at address over UDP
+func sendStatsdMetrics(address string, metrics []string) error {
+	conn, err := net.DialTimeout("udp", address, statsdTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to StatsD at %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(statsdTimeout)); err != nil {
+		return fmt.Errorf("failed to send metrics to StatsD: %w", err)
+	}
+	for _, packet := range statsdPackets(metrics) {
+		if _, err := conn.Write([]byte(packet)); err != nil {
+			return fmt.Errorf("failed to send metrics to StatsD: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordStatsdMetrics sends the summary metrics of a successful scan to
+// StatsD if an address is configured. Failures are printed so they never mask
+// the scan result.
+func recordStatsdMetrics(report scanReport, scanErr error) {
+	address := statsdAddress()
+	if address == "" || scanErr != nil {
+		return
+	}
+	metrics := statsdMetrics(cfg.Statsd, report.releases, report.results)
+
+	if dryRun {
+		dryRunf("send %d metrics to StatsD at %s", len(metrics), address)
+		return
+	}
+
+	if err := sendStatsdMetrics(address, metrics); err != nil {
+		logger.Warn("failed to send metrics to StatsD", "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the summary metrics are rendered for StatsD and DogStatsD
+func TestStatsdMetrics(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "prod", Status: statusOutdated},
+		{ReleaseName: "api", Namespace: "prod", Status: statusOutdated},
+		{ReleaseName: "db", Namespace: "data", Status: statusUptodate},
+		{ReleaseName: "auth", Namespace: "auth", Status: statusVulnerable},
+	}
+
+	assert.Equal(t, []string{
+		"helm_whatup.releases:5|g",
+		"helm_whatup.outdated:2|g",
+		"helm_whatup.vulnerable:1|g",
+		"helm_whatup.namespace.auth.outdated:0|g",
+		"helm_whatup.namespace.data.outdated:0|g",
+		"helm_whatup.namespace.prod.outdated:2|g",
+	}, statsdMetrics(statsdConfig{}, 5, result))
+
+	metrics := statsdMetrics(statsdConfig{Prefix: "whatup", DogStatsD: true}, 5, result)
+	assert.Equal(t, "whatup.namespace.outdated:2|g|#namespace:prod", metrics[5])
+
+	long := make([]string, 100)
+	for i := range long {
+		long[i] = strings.Repeat("x", 50)
+	}
+	packets := statsdPackets(long)
+	require.Len(t, packets, 4)
+	for _, packet := range packets {
+		assert.LessOrEqual(t, len(packet), statsdMaxPacket)
+	}
+}
+
+// Test that the metrics are sent over UDP
+func TestSendStatsdMetrics(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, sendStatsdMetrics(conn.LocalAddr().String(), []string{"helm_whatup.outdated:2|g", "helm_whatup.releases:5|g"}))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, statsdMaxPacket)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "helm_whatup.outdated:2|g\nhelm_whatup.releases:5|g", string(buf[:n]))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reportSummary counts the results of a scan by status, and the outdated
+// releases by update severity
+type reportSummary struct {
+	Total      int            `json:"total"`
+	Outdated   int            `json:"outdated"`
+	Vulnerable int            `json:"vulnerable"`
+	UpToDate   int            `json:"upToDate"`
+	Unknown    int            `json:"unknown"`
+	Ignored    int            `json:"ignored"`
+	Severities map[string]int `json:"severities,omitempty"`
+}
+
+// summarize counts the results of a scan
+func summarize(result []ChartVersionInfo) reportSummary {
+	summary := reportSummary{Total: len(result)}
+	for _, versionInfo := range result {
+		switch versionInfo.Status {
+		case statusOutdated:
+			summary.Outdated++
+			if versionInfo.UpdateSeverity != "" {
+				if summary.Severities == nil {
+					summary.Severities = map[string]int{}
+				}
+				summary.Severities[versionInfo.UpdateSeverity]++
+			}
+		case statusVulnerable:
+			summary.Vulnerable++
+		case statusUptodate:
+			summary.UpToDate++
+		case statusUnknown:
+			summary.Unknown++
+		case statusIgnored:
+			summary.Ignored++
+		}
+	}
+	return summary
+}
+
+// String renders the summary as the footer of the table and plain output, e.g.
+// "12 releases checked: 3 outdated (1 MAJOR, 2 PATCH), 8 up to date, 1 unknown"
+func (s reportSummary) String() string {
+	outdated := fmt.Sprintf("%d outdated", s.Outdated)
+	var severities []string
+	// From the most to the least severe
+	for i := len(updateSeverities) - 1; i >= 0; i-- {
+		if count := s.Severities[updateSeverities[i]]; count > 0 {
+			severities = append(severities, fmt.Sprintf("%d %s", count, cfg.Vocabulary.severity(updateSeverities[i])))
+		}
+	}
+	if len(severities) > 0 {
+		outdated += " (" + strings.Join(severities, ", ") + ")"
+	}
+
+	parts := []string{outdated}
+	if s.Vulnerable > 0 {
+		parts = append(parts, fmt.Sprintf("%d vulnerable", s.Vulnerable))
+	}
+	parts = append(parts, fmt.Sprintf("%d up to date", s.UpToDate), fmt.Sprintf("%d unknown", s.Unknown))
+	if s.Ignored > 0 {
+		parts = append(parts, fmt.Sprintf("%d ignored", s.Ignored))
+	}
+
+	noun := "releases"
+	if s.Total == 1 {
+		noun = "release"
+	}
+	return fmt.Sprintf("%d %s checked: %s", s.Total, noun, strings.Join(parts, ", "))
+}
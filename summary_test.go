@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that results are counted by status and outdated releases by update severity
+func TestSummarize(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "api", Status: statusOutdated, UpdateSeverity: "MAJOR"},
+		{ReleaseName: "web", Status: statusOutdated, UpdateSeverity: "PATCH"},
+		{ReleaseName: "cache", Status: statusOutdated, UpdateSeverity: "PATCH"},
+		{ReleaseName: "db", Status: statusUptodate},
+		{ReleaseName: "legacy", Status: statusUnknown},
+	}
+
+	summary := summarize(result)
+	assert.Equal(t, reportSummary{
+		Total:      5,
+		Outdated:   3,
+		UpToDate:   1,
+		Unknown:    1,
+		Severities: map[string]int{"MAJOR": 1, "PATCH": 2},
+	}, summary)
+	assert.Equal(t, "5 releases checked: 3 outdated (1 MAJOR, 2 PATCH), 1 up to date, 1 unknown", summary.String())
+
+	envelope := newReportEnvelope(scanReport{results: result}, time.Now())
+	data, err := json.Marshal(envelope)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"summary":{"total":5,"outdated":3,"vulnerable":0,"upToDate":1,"unknown":1,"ignored":0,"severities":{"MAJOR":1,"PATCH":2}}`)
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Constants for rendering tables
+const (
+	tableSeparator     = "  "
+	tableEllipsis      = "…"
+	minTableColumnSize = 8
+)
+
+// Column alignments
+const (
+	alignLeft = iota
+	alignRight
+)
+
+// tableColumn describes a column of the table output
+type tableColumn struct {
+	header string
+	value  func(ChartVersionInfo) string
+	// optional columns are only shown when at least one row has a value
+	optional bool
+	align    int
+}
+
+// tableOptions controls how a table is rendered
+type tableOptions struct {
+	// width is the maximum line width, 0 for unlimited
+	width int
+	// rowSeparators draws a line between rows
+	rowSeparators bool
+	// colors holds the color of every cell of the rows, nil for no colors
+	colors [][]string
+	// noHeader leaves out the header row
+	noHeader bool
+}
+
+// tableColumns are the columns of the table output, in display order
+var tableColumns = []tableColumn{
+	{header: "CLUSTER", value: func(v ChartVersionInfo) string { return v.Cluster }, optional: true},
+	{header: "SOURCE", value: func(v ChartVersionInfo) string { return v.Source }, optional: true},
+	{header: "NAME", value: func(v ChartVersionInfo) string { return v.ReleaseName }},
+	{header: "NAMESPACE", value: func(v ChartVersionInfo) string { return v.Namespace }},
+	{header: "INSTALLED VERSION", value: func(v ChartVersionInfo) string { return v.InstalledVersion }},
+	{header: "LATEST VERSION", value: func(v ChartVersionInfo) string { return v.LatestVersion }},
+	{header: "UPSTREAM VERSION", value: func(v ChartVersionInfo) string { return v.UpstreamVersion }, optional: true},
+	{header: "BEHIND", value: func(v ChartVersionInfo) string { return countColumn(v.VersionsBehind) }, optional: true, align: alignRight},
+	{header: "SEVERITY", value: func(v ChartVersionInfo) string { return cfg.Vocabulary.severity(v.UpdateSeverity) }, optional: true},
+	{header: "CHART", value: func(v ChartVersionInfo) string { return v.ChartName }},
+	{header: "CATEGORY", value: func(v ChartVersionInfo) string { return v.Category }, optional: true},
+	{header: "REPOSITORY", value: func(v ChartVersionInfo) string { return v.RepoName }},
+	{header: "GIT SOURCE", value: gitSourceColumn, optional: true},
+	{header: "HELM STATUS", value: releaseStatusColumn, optional: true},
+	{header: "ADVISORIES", value: advisoriesColumn, optional: true},
+	{header: "PRIORITY", value: func(v ChartVersionInfo) string { return cfg.Vocabulary.priority(v.Priority) }, optional: true},
+	{header: "ARTIFACTHUB", value: artifactHubColumn, optional: true},
+	{header: "CREATED BY", value: func(v ChartVersionInfo) string { return v.CreatedBy }, optional: true},
+	{header: "OWNER", value: func(v ChartVersionInfo) string { return v.Owner }, optional: true},
+	{header: "VALUES", value: valuesColumn, optional: true},
+	{header: "PODS", value: podsColumn, optional: true},
+	{header: "FLAGS", value: func(v ChartVersionInfo) string { return strings.Join(v.Flags, ",") }, optional: true},
+	{header: "ORDER", value: func(v ChartVersionInfo) string { return countColumn(v.UpgradeOrder) }, optional: true, align: alignRight},
+}
+
+// wideColumns are the extra columns of -o wide, each inserted after the
+// column of the default table it follows
+var wideColumns = []struct {
+	after  string
+	column tableColumn
+}{
+	{"LATEST VERSION", tableColumn{header: "APP VERSION", value: func(v ChartVersionInfo) string { return v.InstalledAppVersion }}},
+	{"LATEST VERSION", tableColumn{header: "LATEST APP VERSION", value: func(v ChartVersionInfo) string { return v.LatestAppVersion }}},
+	{"CHART", tableColumn{header: "DEPRECATED", value: deprecatedColumn}},
+	{"REPOSITORY", tableColumn{header: "REPOSITORY URL", value: func(v ChartVersionInfo) string { return v.RepoURL }}},
+	{"REPOSITORY", tableColumn{header: "LAST DEPLOYED", value: lastDeployedColumn}},
+}
+
+// wideTableColumns returns the columns of -o wide: the columns of the
+// default table, with the versions behind always shown, and the wide columns
+func wideTableColumns() []tableColumn {
+	columns := make([]tableColumn, 0, len(tableColumns)+len(wideColumns))
+	for _, column := range tableColumns {
+		if column.header == "BEHIND" {
+			column.optional = false
+		}
+		columns = append(columns, column)
+		for _, extra := range wideColumns {
+			if extra.after == column.header {
+				columns = append(columns, extra.column)
+			}
+		}
+	}
+	return columns
+}
+
+// deprecatedColumn marks the releases of deprecated charts
+func deprecatedColumn(versionInfo ChartVersionInfo) string {
+	if versionInfo.Deprecated {
+		return "yes"
+	}
+	return ""
+}
+
+// lastDeployedColumn renders the day a release was last deployed
+func lastDeployedColumn(versionInfo ChartVersionInfo) string {
+	if versionInfo.LastDeployed == nil {
+		return ""
+	}
+	return versionInfo.LastDeployed.Format("2006-01-02")
+}
+
+// visibleColumns returns the columns to display for the given rows
+func visibleColumns(columns []tableColumn, rows []ChartVersionInfo) []tableColumn {
+	var visible []tableColumn
+	for _, column := range columns {
+		if !column.optional {
+			visible = append(visible, column)
+			continue
+		}
+		for _, row := range rows {
+			if column.value(row) != "" {
+				visible = append(visible, column)
+				break
+			}
+		}
+	}
+	return visible
+}
+
+// tableRow returns the cells of a row for the given columns
+func tableRow(columns []tableColumn, versionInfo ChartVersionInfo) []string {
+	row := make([]string, 0, len(columns))
+	for _, column := range columns {
+		row = append(row, column.value(versionInfo))
+	}
+	return row
+}
+
+// tableHeader returns the header cells for the given columns
+func tableHeader(columns []tableColumn) []string {
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, column.header)
+	}
+	return header
+}
+
+// countColumn renders a number such as the upgrade order, leaving the cell
+// empty when it is zero or unknown
+func countColumn(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// terminalWidth returns the width of the terminal stdout is attached to, or 0
+// when output is redirected so that piped tables are never truncated. The
+// COLUMNS environment variable takes precedence.
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// renderTable writes the header and rows with aligned columns. When the table
+// is wider than opts.width, the widest columns are shrunk first and cells
+// that don't fit are truncated with an ellipsis.
+func renderTable(w io.Writer, columns []tableColumn, header []string, rows [][]string, opts tableOptions) {
+	widths := make([]int, len(header))
+	if !opts.noHeader {
+		for i, cell := range header {
+			widths[i] = utf8.RuneCountInString(cell)
+		}
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	fitColumns(widths, opts.width)
+
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	total += len(tableSeparator) * (len(widths) - 1)
+
+	writeRow := func(row, colors []string) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cell = truncateCell(cell, widths[i])
+			padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			// Colors are applied after measuring so escape sequences don't count
+			if colors != nil {
+				cell = colorize(cell, colors[i])
+			}
+			if columns[i].align == alignRight {
+				cells[i] = padding + cell
+			} else {
+				cells[i] = cell + padding
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, tableSeparator), " "))
+	}
+
+	if !opts.noHeader {
+		writeRow(header, nil)
+	}
+	for i, row := range rows {
+		if opts.rowSeparators {
+			fmt.Fprintln(w, strings.Repeat("-", total))
+		}
+		var colors []string
+		if opts.colors != nil {
+			colors = opts.colors[i]
+		}
+		writeRow(row, colors)
+	}
+}
+
+// fitColumns shrinks the widest columns until the table fits into width,
+// keeping every column at least minTableColumnSize wide
+func fitColumns(widths []int, width int) {
+	if width <= 0 || len(widths) == 0 {
+		return
+	}
+	available := width - len(tableSeparator)*(len(widths)-1)
+	for {
+		total, widest := 0, 0
+		for i, w := range widths {
+			total += w
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= available || widths[widest] <= minTableColumnSize {
+			return
+		}
+		widths[widest] = max(minTableColumnSize, widths[widest]-(total-available))
+	}
+}
+
+// truncateCell shortens a cell to width, marking the truncation with an ellipsis
+func truncateCell(cell string, width int) string {
+	if utf8.RuneCountInString(cell) <= width {
+		return cell
+	}
+	runes := []rune(cell)
+	return string(runes[:width-1]) + tableEllipsis
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that tables are aligned, shrunk to the terminal width and truncated with an ellipsis
+func TestRenderTable(t *testing.T) {
+	columns := []tableColumn{{header: "NAME"}, {header: "CHART"}, {header: "ORDER", align: alignRight}}
+	header := tableHeader(columns)
+	rows := [][]string{
+		{"web", "oci://registry.example.com/platform/charts/ingress-nginx", "1"},
+		{"api", "nginx", "12"},
+	}
+
+	var out bytes.Buffer
+	renderTable(&out, columns, header, rows, tableOptions{})
+	assert.Equal(t, ""+
+		"NAME  CHART                                                     ORDER\n"+
+		"web   oci://registry.example.com/platform/charts/ingress-nginx      1\n"+
+		"api   nginx                                                        12\n", out.String())
+
+	out.Reset()
+	renderTable(&out, columns, header, rows, tableOptions{width: 40, rowSeparators: true})
+	assert.Equal(t, ""+
+		"NAME  CHART                        ORDER\n"+
+		"----------------------------------------\n"+
+		"web   oci://registry.example.com…      1\n"+
+		"----------------------------------------\n"+
+		"api   nginx                           12\n", out.String())
+}
+
+// Test that --no-headers leaves out the header and sizes the columns by the rows only
+func TestRenderTableNoHeader(t *testing.T) {
+	columns := []tableColumn{{header: "NAME"}, {header: "NAMESPACE"}}
+	rows := [][]string{{"web", "default"}, {"api", "kube-system"}}
+
+	var out bytes.Buffer
+	renderTable(&out, columns, tableHeader(columns), rows, tableOptions{noHeader: true})
+	assert.Equal(t, ""+
+		"web  default\n"+
+		"api  kube-system\n", out.String())
+}
+
+// Test that -o wide adds the app versions, repository URL, deprecation and last deployment
+func TestWideOutput(t *testing.T) {
+	defer func() { outputFormat = outputFormatTable }()
+	deployed := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	report := scanReport{results: []ChartVersionInfo{{
+		ReleaseName:         "web",
+		Namespace:           "default",
+		ChartName:           "nginx",
+		InstalledVersion:    "1.0.0",
+		LatestVersion:       "1.2.0",
+		RepoName:            "bitnami",
+		RepoURL:             "https://charts.bitnami.com/bitnami",
+		Status:              statusOutdated,
+		InstalledAppVersion: "1.25.0",
+		LatestAppVersion:    "1.27.0",
+		VersionsBehind:      2,
+		Deprecated:          true,
+		LastDeployed:        &deployed,
+	}}}
+
+	var out bytes.Buffer
+	outputFormat = outputFormatWide
+	require.NoError(t, formatAndPrintResults(&out, report))
+	assert.Contains(t, out.String(), ""+
+		"NAME  NAMESPACE  INSTALLED VERSION  LATEST VERSION  APP VERSION  LATEST APP VERSION  BEHIND  CHART  DEPRECATED  REPOSITORY  REPOSITORY URL                      LAST DEPLOYED\n"+
+		"web   default    1.0.0              1.2.0           1.25.0       1.27.0                   2  nginx  yes         bitnami     https://charts.bitnami.com/bitnami  2024-05-02\n")
+
+	// The default table stays compact
+	out.Reset()
+	outputFormat = outputFormatTable
+	require.NoError(t, formatAndPrintResults(&out, report))
+	assert.NotContains(t, out.String(), "REPOSITORY URL")
+	assert.NotContains(t, out.String(), "APP VERSION")
+}
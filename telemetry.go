@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+)
+
+// telemetryTimeout bounds the upload of the stats of a run
+const telemetryTimeout = 5 * time.Second
+
+// telemetryConfig configures the opt-in collection of run stats
+type telemetryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Endpoint receives the stats of every run as JSON, e.g. to aggregate
+	// them across an organization
+	Endpoint string `yaml:"endpoint"`
+}
+
+// runStats are the anonymous stats of a single scan. They hold no names of
+// users, clusters, releases or charts.
+type runStats struct {
+	Timestamp    time.Time     `json:"timestamp"`
+	Version      string        `json:"version"`
+	Duration     time.Duration `json:"duration"`
+	Releases     int           `json:"releases"`
+	Repositories int           `json:"repositories"`
+	Outdated     int           `json:"outdated"`
+	// IndexHits counts the releases whose chart was found in a cached repository index
+	IndexHits int  `json:"indexHits"`
+	Failed    bool `json:"failed,omitempty"`
+}
+
+// statsSummary aggregates the recorded runs for the stats subcommand
+type statsSummary struct {
+	Runs            int           `json:"runs"`
+	FailedRuns      int           `json:"failedRuns"`
+	FirstRun        *time.Time    `json:"firstRun,omitempty"`
+	LastRun         *time.Time    `json:"lastRun,omitempty"`
+	ReleasesScanned int           `json:"releasesScanned"`
+	MaxReleases     int           `json:"maxReleases"`
+	AverageDuration time.Duration `json:"averageDuration"`
+	OutdatedRate    float64       `json:"outdatedRate"`
+	IndexHitRate    float64       `json:"indexHitRate"`
+}
+
+// telemetryEnabled reports whether run stats are recorded, by flag or config
+func telemetryEnabled() bool {
+	return telemetry || cfg.Telemetry.Enabled
+}
+
+// statsFile returns the location of the local run stats
+func statsFile() string {
+	return helmCachePath("whatup", "stats.jsonl")
+}
+
+// newRunStats computes the stats of a scan that started at start
+func newRunStats(report scanReport, start, now time.Time, scanErr error) runStats {
+	stats := runStats{
+		Timestamp:    now.UTC(),
+		Version:      version,
+		Duration:     now.Sub(start),
+		Releases:     report.releases,
+		Repositories: report.repositories,
+		Failed:       scanErr != nil,
+	}
+	for _, versionInfo := range report.results {
+		if versionInfo.Status == statusOutdated {
+			stats.Outdated++
+		}
+		if versionInfo.Status != statusUnknown {
+			stats.IndexHits++
+		}
+	}
+	return stats
+}
+
+// recordStats appends the stats of a scan to the local stats file and sends
+// them to the configured endpoint when telemetry was opted into. Failures are
+// printed so they never mask the scan result.
+func recordStats(ctx context.Context, report scanReport, start time.Time, scanErr error) {
+	if !telemetryEnabled() {
+		return
+	}
+	stats := newRunStats(report, start, time.Now(), scanErr)
+
+	if dryRun {
+		dryRunf("append run stats to %s", statsFile())
+	} else if err := appendRunStats(statsFile(), stats); err != nil {
+		logger.Warn("failed to record run stats", "error", err)
+	}
+
+	if endpoint := cfg.Telemetry.Endpoint; endpoint != "" {
+		ctx, cancel := context.WithTimeout(ctx, telemetryTimeout)
+		defer cancel()
+		if err := postJSON(ctx, &http.Client{Timeout: telemetryTimeout}, endpoint, nil, stats); err != nil {
+			logger.Warn("failed to send run stats", "error", err)
+		}
+	}
+}
+
+// appendRunStats appends the stats of a run to the file at path, one JSON
+// record per line
+func appendRunStats(path string, stats runStats) error {
+	line, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run stats: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open stats file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	return nil
+}
+
+// loadRunStats reads the recorded runs. A missing file holds no runs.
+func loadRunStats(path string) ([]runStats, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stats file: %w", err)
+	}
+	defer f.Close()
+
+	var runs []runStats
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var stats runStats
+		if err := json.Unmarshal(scanner.Bytes(), &stats); err != nil {
+			return nil, fmt.Errorf("failed to parse line %d of %s: %w", line, path, err)
+		}
+		runs = append(runs, stats)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stats file: %w", err)
+	}
+	return runs, nil
+}
+
+// summarizeRuns aggregates the recorded runs
+func summarizeRuns(runs []runStats) statsSummary {
+	var summary statsSummary
+	var duration time.Duration
+	outdated, hits := 0, 0
+	for i := range runs {
+		run := runs[i]
+		summary.Runs++
+		if run.Failed {
+			summary.FailedRuns++
+		}
+		if summary.FirstRun == nil || run.Timestamp.Before(*summary.FirstRun) {
+			summary.FirstRun = &runs[i].Timestamp
+		}
+		if summary.LastRun == nil || run.Timestamp.After(*summary.LastRun) {
+			summary.LastRun = &runs[i].Timestamp
+		}
+		summary.ReleasesScanned += run.Releases
+		summary.MaxReleases = max(summary.MaxReleases, run.Releases)
+		duration += run.Duration
+		outdated += run.Outdated
+		hits += run.IndexHits
+	}
+	if summary.Runs > 0 {
+		summary.AverageDuration = duration / time.Duration(summary.Runs)
+	}
+	if summary.ReleasesScanned > 0 {
+		summary.OutdatedRate = float64(outdated) / float64(summary.ReleasesScanned)
+		summary.IndexHitRate = float64(hits) / float64(summary.ReleasesScanned)
+	}
+	return summary
+}
+
+// newStatsCmd creates the stats subcommand
+func newStatsCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "summarize the run stats recorded with --telemetry",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			runs, err := loadRunStats(statsFile())
+			if err != nil {
+				return err
+			}
+			return printStatsSummary(os.Stdout, output, summarizeRuns(runs))
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputFormatTable, "output format. Accepted formats: table, json")
+
+	return cmd
+}
+
+// printStatsSummary writes the summary in the requested format
+func printStatsSummary(w io.Writer, output string, summary statsSummary) error {
+	switch output {
+	case outputFormatJSON:
+		outputBytes, err := json.MarshalIndent(summary, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatTable:
+		if summary.Runs == 0 {
+			fmt.Fprintln(w, "No runs recorded. Pass --telemetry or set telemetry.enabled in the config file to record them.")
+			return nil
+		}
+		table := uitable.New()
+		table.AddRow("RUNS", fmt.Sprintf("%d (%d failed)", summary.Runs, summary.FailedRuns))
+		table.AddRow("PERIOD", fmt.Sprintf("%s - %s", summary.FirstRun.Format(time.DateOnly), summary.LastRun.Format(time.DateOnly)))
+		table.AddRow("RELEASES SCANNED", fmt.Sprintf("%d (at most %d in a run)", summary.ReleasesScanned, summary.MaxReleases))
+		table.AddRow("AVERAGE DURATION", summary.AverageDuration.Round(time.Millisecond).String())
+		table.AddRow("OUTDATED", fmt.Sprintf("%.1f%%", summary.OutdatedRate*100))
+		table.AddRow("INDEX HIT RATE", fmt.Sprintf("%.1f%%", summary.IndexHitRate*100))
+		fmt.Fprintln(w, table)
+	default:
+		return fmt.Errorf("invalid formatter: %s", output)
+	}
+	return nil
+}
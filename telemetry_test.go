@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the stats of a run are recorded locally and sent to the endpoint
+func TestRecordStats(t *testing.T) {
+	t.Setenv("HELM_CACHE_HOME", t.TempDir())
+
+	var received runStats
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	cfg = Config{Telemetry: telemetryConfig{Enabled: true, Endpoint: server.URL}}
+	defer func() { cfg = Config{} }()
+
+	report := scanReport{
+		releases:     3,
+		repositories: 2,
+		results: []ChartVersionInfo{
+			{ReleaseName: "web", Status: statusOutdated},
+			{ReleaseName: "db", Status: statusUptodate},
+			{ReleaseName: "private", Status: statusUnknown},
+		},
+	}
+	recordStats(context.Background(), report, time.Now().Add(-2*time.Second), nil)
+	recordStats(context.Background(), scanReport{}, time.Now(), errors.New("cluster unreachable"))
+
+	runs, err := loadRunStats(filepath.Join(t.TempDir(), "missing.jsonl"))
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	runs, err = loadRunStats(statsFile())
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, 3, runs[0].Releases)
+	assert.Equal(t, 1, runs[0].Outdated)
+	assert.Equal(t, 2, runs[0].IndexHits)
+	assert.GreaterOrEqual(t, runs[0].Duration, 2*time.Second)
+	assert.True(t, runs[1].Failed)
+	assert.True(t, received.Failed)
+
+	summary := summarizeRuns(runs)
+	assert.Equal(t, 2, summary.Runs)
+	assert.Equal(t, 1, summary.FailedRuns)
+	assert.Equal(t, 3, summary.ReleasesScanned)
+	assert.InDelta(t, 1.0/3, summary.OutdatedRate, 0.001)
+	assert.InDelta(t, 2.0/3, summary.IndexHitRate, 0.001)
+
+	var out bytes.Buffer
+	require.NoError(t, printStatsSummary(&out, outputFormatTable, summary))
+	assert.Contains(t, out.String(), "66.7%")
+}
+
+// Test that nothing is recorded without opting in
+func TestRecordStatsDisabled(t *testing.T) {
+	t.Setenv("HELM_CACHE_HOME", t.TempDir())
+
+	recordStats(context.Background(), scanReport{releases: 1}, time.Now(), nil)
+
+	runs, err := loadRunStats(statsFile())
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
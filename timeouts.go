@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// applyKubeTimeout limits every request to the Kubernetes API made with the
+// settings to timeout. Zero keeps the client default of no timeout.
+func applyKubeTimeout(settings *helmSettings, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	flags, ok := settings.RESTClientGetter().(*genericclioptions.ConfigFlags)
+	if !ok {
+		return
+	}
+	value := timeout.String()
+	flags.Timeout = &value
+}
+
+// repoClientTimeout returns the timeout of requests to chart repositories:
+// --repo-timeout when given, otherwise fallback
+func repoClientTimeout(fallback time.Duration) time.Duration {
+	if repoTimeout > 0 {
+		return repoTimeout
+	}
+	return fallback
+}
+
+// withRepoTimeout bounds an operation on a chart repository, such as
+// `helm repo update`, by --repo-timeout when given
+func withRepoTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if repoTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, repoTimeout)
+}
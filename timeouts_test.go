@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+// Test that --kube-timeout ends up in the REST config of the Kubernetes clients
+func TestApplyKubeTimeout(t *testing.T) {
+	settings := cli.New()
+	settings.KubeAPIServer = "https://127.0.0.1:6443"
+	applyKubeTimeout(settings, 90*time.Second)
+
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, restConfig.Timeout)
+
+	settings = cli.New()
+	settings.KubeAPIServer = "https://127.0.0.1:6443"
+	applyKubeTimeout(settings, 0)
+
+	restConfig, err = settings.RESTClientGetter().ToRESTConfig()
+	require.NoError(t, err)
+	assert.Zero(t, restConfig.Timeout)
+}
+
+// Test that --repo-timeout overrides the built-in repository timeouts
+func TestRepoTimeout(t *testing.T) {
+	assert.Equal(t, probeTimeout, repoClientTimeout(probeTimeout))
+	ctx, cancel := withRepoTimeout(context.Background())
+	_, hasDeadline := ctx.Deadline()
+	cancel()
+	assert.False(t, hasDeadline)
+
+	repoTimeout = 5 * time.Second
+	defer func() { repoTimeout = 0 }()
+
+	assert.Equal(t, 5*time.Second, repoClientTimeout(probeTimeout))
+	ctx, cancel = withRepoTimeout(context.Background())
+	defer cancel()
+	deadline, hasDeadline := ctx.Deadline()
+	assert.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(5*time.Second), deadline, time.Second)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// severityTrivial is the update severity of new chart versions that only
+// change documentation or metadata, set with --trivial-updates
+const severityTrivial = "TRIVIAL"
+
+// chartDownloadTimeout bounds the download of a single chart archive
+const chartDownloadTimeout = 60 * time.Second
+
+// maxChartSize limits the size of a downloaded chart archive
+const maxChartSize = 64 << 20
+
+// docFilePrefixes are the chart files that don't affect what gets deployed,
+// matched case-insensitively against their base name
+var docFilePrefixes = []string{"readme", "changelog", "license", "notice", "contributing"}
+
+// checkTrivialUpdates downloads the latest version of the charts of outdated
+// releases and downgrades the update to TRIVIAL when it renders the same
+// objects as the installed chart: the same templates, CRDs, default values,
+// schema and dependencies, only the documentation or Chart.yaml metadata
+// differ. Charts that can't be downloaded, like those of OCI repositories,
+// keep their severity; download failures are reported as warnings.
+func checkTrivialUpdates(ctx context.Context, repositories []repoIndex, repoFileData *helmRepoFile, releases []*helmRelease, result []ChartVersionInfo, warnings *[]warning) {
+	installed := make(map[string]*helmRelease, len(releases))
+	for _, rel := range releases {
+		installed[rel.Namespace+"/"+rel.Name] = rel
+	}
+
+	client := &http.Client{Timeout: repoClientTimeout(chartDownloadTimeout)}
+	// Digests of the downloaded charts, empty for those that failed
+	digests := map[string]string{}
+	for i := range result {
+		versionInfo := &result[i]
+		if versionInfo.Status != statusOutdated || versionInfo.UpdateSeverity == "" {
+			continue
+		}
+		rel := installed[findingKey(*versionInfo)]
+		if rel == nil || rel.Chart == nil {
+			continue
+		}
+		chartURL := latestChartURL(repositories, repoFileData, versionInfo.RepoName, versionInfo.ChartName, versionInfo.LatestVersion)
+		if chartURL == "" {
+			continue
+		}
+
+		digest, seen := digests[chartURL]
+		if !seen {
+			latest, err := downloadChart(ctx, client, repoEntry(repoFileData, versionInfo.RepoName), chartURL)
+			if err != nil {
+				addWarning(warnings, warnChartDownloadFailed, "Failed to download chart %s %s: %v", versionInfo.ChartName, versionInfo.LatestVersion, err)
+			} else {
+				digest = chartContentDigest(latest)
+			}
+			digests[chartURL] = digest
+		}
+		if digest != "" && digest == chartContentDigest(rel.Chart) {
+			versionInfo.UpdateSeverity = severityTrivial
+		}
+	}
+}
+
+// latestChartURL returns the absolute URL of the archive of a chart version
+// listed in a repository index, or an empty string when it isn't listed
+func latestChartURL(repositories []repoIndex, repoFileData *helmRepoFile, repoName, chartName, version string) string {
+	for _, repository := range repositories {
+		if repository.name != repoName {
+			continue
+		}
+		for _, entry := range repository.Entries[chartName] {
+			if entry.Version != version || len(entry.URLs) == 0 {
+				continue
+			}
+			ref, err := url.Parse(entry.URLs[0])
+			if err != nil {
+				return ""
+			}
+			if ref.IsAbs() {
+				return ref.String()
+			}
+			base, err := url.Parse(strings.TrimSuffix(chartRepoURL(repoName, repoFileData), "/") + "/")
+			if err != nil || !base.IsAbs() {
+				return ""
+			}
+			return base.ResolveReference(ref).String()
+		}
+	}
+	return ""
+}
+
+// repoEntry returns the configured repository of the given name, or nil
+func repoEntry(repoFileData *helmRepoFile, repoName string) *helmRepoEntry {
+	if repoFileData == nil {
+		return nil
+	}
+	for _, entry := range repoFileData.Repositories {
+		if entry.Name == repoName {
+			return entry
+		}
+	}
+	return nil
+}
+
+// downloadChart downloads and loads a chart archive. The credentials of the
+// repository are only sent to its own host, unless the repository is
+// configured to pass them to every host, like Helm does.
+func downloadChart(ctx context.Context, client *http.Client, entry *helmRepoEntry, chartURL string) (*helmChart, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, chartURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chart request: %w", err)
+	}
+	if entry != nil && entry.Username != "" {
+		if repoURL, err := url.Parse(entry.URL); err == nil && (repoURL.Host == req.URL.Host || entry.PassCredentialsAll) {
+			req.SetBasicAuth(entry.Username, entry.Password)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", chartURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", chartURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChartSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", chartURL, err)
+	}
+	loaded, err := loadChartArchive(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", chartURL, err)
+	}
+	return loaded, nil
+}
+
+// chartContentDigest hashes the parts of a chart that affect what gets
+// deployed. Subcharts are covered by the dependency versions, since installed
+// releases don't store them.
+func chartContentDigest(c *helmChart) string {
+	h := sha256.New()
+	write := func(kind, name string, data []byte) {
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00", kind, name, len(data))
+		h.Write(data)
+	}
+
+	templates := make(map[string][]byte, len(c.Templates))
+	for _, file := range c.Templates {
+		templates[file.Name] = file.Data
+	}
+	for _, file := range c.Files {
+		if !docFile(file.Name) {
+			templates[file.Name] = file.Data
+		}
+	}
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		write("file", name, templates[name])
+	}
+
+	// Values are compared once parsed, as installed releases don't keep
+	// values.yaml, and encoding/json sorts the keys
+	values, _ := json.Marshal(c.Values)
+	write("values", "", values)
+	write("schema", "", c.Schema)
+
+	if c.Metadata != nil {
+		for _, dependency := range c.Metadata.Dependencies {
+			write("dependency", dependency.Name, []byte(dependency.Version+"\x00"+dependency.Repository))
+		}
+	}
+	if c.Lock != nil {
+		for _, dependency := range c.Lock.Dependencies {
+			write("lock", dependency.Name, []byte(dependency.Version))
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// docFile reports whether a chart file is documentation, e.g. README.md
+func docFile(name string) bool {
+	base := strings.ToLower(path.Base(name))
+	for _, prefix := range docFilePrefixes {
+		if strings.HasPrefix(base, prefix) {
+			return true
+		}
+	}
+	return false
+}
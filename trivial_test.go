@@ -0,0 +1,92 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// packageTestChart returns a chart archive with the given files
+func packageTestChart(t *testing.T, chartName string, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: chartName + "/" + name, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// Test that updates only changing the documentation of a chart are classified as TRIVIAL
+func TestCheckTrivialUpdates(t *testing.T) {
+	template := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n"
+	archives := map[string][]byte{
+		"/docs-1.0.1.tgz": packageTestChart(t, "docs", map[string]string{
+			"Chart.yaml":            "apiVersion: v2\nname: docs\nversion: 1.0.1\ndescription: Better words\n",
+			"values.yaml":           "replicas: 1\n",
+			"templates/config.yaml": template,
+			"README.md":             "# docs\n",
+		}),
+		"/app-1.1.0.tgz": packageTestChart(t, "app", map[string]string{
+			"Chart.yaml":            "apiVersion: v2\nname: app\nversion: 1.1.0\n",
+			"values.yaml":           "replicas: 2\n",
+			"templates/config.yaml": template,
+		}),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		archive, ok := archives[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	idx := repo.NewIndexFile()
+	idx.Entries["docs"] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: "docs", Version: "1.0.1"}, URLs: []string{"docs-1.0.1.tgz"}}}
+	idx.Entries["app"] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: "app", Version: "1.1.0"}, URLs: []string{server.URL + "/app-1.1.0.tgz"}}}
+	idx.Entries["gone"] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: "gone", Version: "2.0.0"}, URLs: []string{"gone-2.0.0.tgz"}}}
+	repositories := []repoIndex{{name: "stable", helmIndexFile: idx}}
+	repoFileData := &repo.File{Repositories: []*repo.Entry{{Name: "stable", URL: server.URL}}}
+
+	installed := func(name, version string) *release.Release {
+		rel := newTestRelease(name, "default", name, version)
+		rel.Chart.Templates = []*chart.File{{Name: "templates/config.yaml", Data: []byte(template)}}
+		rel.Chart.Values = map[string]interface{}{"replicas": float64(1)}
+		rel.Chart.Files = []*chart.File{{Name: "README.md", Data: []byte("# old docs\n")}}
+		return rel
+	}
+	releases := []*release.Release{installed("docs", "1.0.0"), installed("app", "1.0.0"), installed("gone", "1.0.0")}
+
+	result := []ChartVersionInfo{
+		{ReleaseName: "docs", Namespace: "default", ChartName: "docs", RepoName: "stable", LatestVersion: "1.0.1", Status: statusOutdated, UpdateSeverity: "PATCH"},
+		{ReleaseName: "app", Namespace: "default", ChartName: "app", RepoName: "stable", LatestVersion: "1.1.0", Status: statusOutdated, UpdateSeverity: "MINOR"},
+		{ReleaseName: "gone", Namespace: "default", ChartName: "gone", RepoName: "stable", LatestVersion: "2.0.0", Status: statusOutdated, UpdateSeverity: "MAJOR"},
+	}
+	var warnings []warning
+	checkTrivialUpdates(context.Background(), repositories, repoFileData, releases, result, &warnings)
+
+	assert.Equal(t, severityTrivial, result[0].UpdateSeverity)
+	assert.Equal(t, "MINOR", result[1].UpdateSeverity)
+	assert.Equal(t, "MAJOR", result[2].UpdateSeverity)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, warnChartDownloadFailed, warnings[0].Code)
+
+	// TRIVIAL updates are the least severe
+	assert.Len(t, filterBySeverity(result, "PATCH"), 2)
+}
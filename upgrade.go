@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+)
+
+// Outcomes of the tests run after an upgrade
+const (
+	testsPassed = "passed"
+	testsFailed = "failed"
+)
+
+// upgradeOptions controls how the upgrade subcommand applies upgrades
+type upgradeOptions struct {
+	plan     bool
+	runTests bool
+	atomic   bool
+}
+
+// upgradeResult is the outcome of the upgrade of a single release, as listed
+// in the apply report
+type upgradeResult struct {
+	ReleaseName string `json:"releaseName"`
+	Namespace   string `json:"namespace"`
+	ChartName   string `json:"chartName"`
+	FromVersion string `json:"fromVersion"`
+	ToVersion   string `json:"toVersion"`
+	Upgraded    bool   `json:"upgraded"`
+	Tests       string `json:"tests,omitempty"`
+	RolledBack  bool   `json:"rolledBack,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// upgradePlanStep is a proposed upgrade together with its backout procedure
+type upgradePlanStep struct {
+	ReleaseName     string `json:"releaseName"`
+	Namespace       string `json:"namespace"`
+	ChartName       string `json:"chartName"`
+	FromVersion     string `json:"fromVersion"`
+	ToVersion       string `json:"toVersion"`
+	Revision        int    `json:"revision"`
+	UpgradeCommand  string `json:"upgradeCommand"`
+	RollbackCommand string `json:"rollbackCommand"`
+}
+
+// failed reports whether the upgrade or its tests failed
+func (r upgradeResult) failed() bool {
+	return r.Error != "" || r.Tests == testsFailed
+}
+
+// newUpgradeCmd creates the upgrade subcommand
+func newUpgradeCmd() *cobra.Command {
+	var output string
+	var opts upgradeOptions
+
+	cmd := &cobra.Command{
+		Use:               "upgrade [RELEASE...]",
+		Short:             "scan the releases and upgrade the outdated ones to their latest version",
+		ValidArgsFunction: completeReleases,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := scan(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			candidates, err := upgradeCandidates(report.results, args)
+			if err != nil {
+				return err
+			}
+
+			if opts.plan {
+				return printUpgradePlan(os.Stdout, output, upgradePlan(candidates, opts))
+			}
+
+			results := applyUpgrades(cmd.Context(), candidates, opts)
+			recordUpgrades(results)
+			if err := printUpgradeResults(os.Stdout, output, results); err != nil {
+				return err
+			}
+
+			for _, result := range results {
+				if result.failed() {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("upgrade of release %s failed", result.ReleaseName)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputFormatTable, "output format of the apply report. Accepted formats: table, json")
+	cmd.Flags().BoolVar(&opts.plan, "plan", false, "only print the proposed upgrades with the commands to apply and to roll back each of them")
+	cmd.Flags().BoolVar(&opts.runTests, "run-tests", false, "run `helm test` for every upgraded release and report whether the tests passed")
+	cmd.Flags().BoolVar(&opts.atomic, "atomic", false, "roll a release back to its previous revision when its upgrade fails (helm upgrade --atomic) or, with --run-tests, when its tests fail")
+
+	return cmd
+}
+
+// upgradeCandidates returns the outdated results to upgrade, limited to the
+// given release names (or namespace/name) when there are any
+func upgradeCandidates(result []ChartVersionInfo, names []string) ([]ChartVersionInfo, error) {
+	var candidates []ChartVersionInfo
+	matched := make(map[string]bool, len(names))
+	for _, versionInfo := range result {
+		if len(names) > 0 {
+			selected := false
+			for _, name := range names {
+				if name == versionInfo.ReleaseName || name == findingKey(versionInfo) {
+					matched[name] = true
+					selected = true
+				}
+			}
+			if !selected {
+				continue
+			}
+		}
+		if versionInfo.Status != statusOutdated {
+			continue
+		}
+		if versionInfo.CreatedBy != "" {
+			// Upgrading it with Helm would fight the tool managing the release
+			if len(names) > 0 {
+				return nil, fmt.Errorf("release %s is managed by %s, upgrade it there", versionInfo.ReleaseName, versionInfo.CreatedBy)
+			}
+			continue
+		}
+		if hasFlag(versionInfo, flagAutoManaged) {
+			// The GitOps controller would revert the upgrade
+			if len(names) > 0 {
+				return nil, fmt.Errorf("release %s is managed by a GitOps controller, upgrade it there", versionInfo.ReleaseName)
+			}
+			continue
+		}
+		if !semverUpgrade(versionInfo.InstalledVersion, versionInfo.LatestVersion) {
+			// Never downgrade, whatever the status says
+			if len(names) > 0 {
+				return nil, fmt.Errorf("release %s can't be upgraded from %s to %s, which is not a newer version", versionInfo.ReleaseName, versionInfo.InstalledVersion, versionInfo.LatestVersion)
+			}
+			logger.Warn("skipping upgrade to a version that is not newer", "release", findingKey(versionInfo), "installed", versionInfo.InstalledVersion, "latest", versionInfo.LatestVersion)
+			continue
+		}
+		if !reliableRepo(versionInfo) {
+			// Upgrading from a guessed repository could install another chart
+			if len(names) > 0 {
+				return nil, fmt.Errorf("the repository of release %s was guessed (%s), set the %s annotation or a repository mapping to upgrade it", versionInfo.ReleaseName, versionInfo.RepoConfidence, repositoryAnnotation)
+			}
+			logger.Warn("skipping upgrade of a release whose repository was guessed", "release", findingKey(versionInfo), "repository", versionInfo.RepoName, "confidence", versionInfo.RepoConfidence)
+			continue
+		}
+		if versionInfo.Cluster != "" {
+			return nil, fmt.Errorf("upgrading releases of several clusters is not supported, select a single cluster with --kube-context")
+		}
+		candidates = append(candidates, versionInfo)
+	}
+
+	for _, name := range names {
+		if !matched[name] {
+			return nil, fmt.Errorf("release %s not found", name)
+		}
+	}
+	return candidates, nil
+}
+
+// semverUpgrade reports whether latest is a valid semver version greater than
+// the installed one
+func semverUpgrade(installed, latest string) bool {
+	return updateType(installed, latest) != ""
+}
+
+// reliableRepo reports whether the repository of a release is known well
+// enough to upgrade from it: given by the chart annotation or a repository
+// mapping, or matching the download URL of the installed chart
+func reliableRepo(versionInfo ChartVersionInfo) bool {
+	if versionInfo.RepoName == "" || versionInfo.RepoName == "unknown" {
+		return false
+	}
+	return versionInfo.RepoConfidence == confidenceExact || versionInfo.RepoConfidence == confidenceURL
+}
+
+// applyUpgrades upgrades every candidate to its latest version, optionally
+// runs its tests and rolls it back when they fail and opts.atomic is set.
+// Failures are recorded in the results and don't stop the other upgrades.
+func applyUpgrades(ctx context.Context, candidates []ChartVersionInfo, opts upgradeOptions) []upgradeResult {
+	results := make([]upgradeResult, 0, len(candidates))
+	for _, versionInfo := range candidates {
+		result := upgradeResult{
+			ReleaseName: versionInfo.ReleaseName,
+			Namespace:   versionInfo.Namespace,
+			ChartName:   versionInfo.ChartName,
+			FromVersion: versionInfo.InstalledVersion,
+			ToVersion:   versionInfo.LatestVersion,
+		}
+
+		if err := runHelm(ctx, upgradeArgs(versionInfo, opts)...); err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Upgraded = true
+
+		if !opts.runTests {
+			results = append(results, result)
+			continue
+		}
+
+		result.Tests = testsPassed
+		if err := runHelm(ctx, "test", versionInfo.ReleaseName, "--namespace", versionInfo.Namespace); err != nil {
+			result.Tests = testsFailed
+			if opts.atomic {
+				if err := runHelm(ctx, rollbackArgs(versionInfo)...); err != nil {
+					result.Error = fmt.Sprintf("rollback failed: %v", err)
+				} else {
+					result.RolledBack = true
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// upgradeArgs returns the Helm arguments upgrading a release to its latest
+// version while keeping the values it was installed with. Charts whose
+// repository is only known by its URL are upgraded with --repo, and failed
+// upgrades are rolled back by Helm with opts.atomic.
+func upgradeArgs(versionInfo ChartVersionInfo, opts upgradeOptions) []string {
+	chartRef := versionInfo.RepoName + "/" + versionInfo.ChartName
+	var repoArgs []string
+	if isRepositoryURL(versionInfo.RepoName) {
+		chartRef = versionInfo.ChartName
+		repoArgs = []string{"--repo", versionInfo.RepoName}
+	}
+
+	args := []string{
+		"upgrade", versionInfo.ReleaseName, chartRef,
+		"--namespace", versionInfo.Namespace,
+		"--version", versionInfo.LatestVersion,
+		"--reuse-values",
+		"--wait",
+	}
+	if opts.atomic {
+		args = append(args, "--atomic")
+	}
+	return append(args, repoArgs...)
+}
+
+// rollbackArgs returns the Helm arguments rolling a release back to the
+// revision it had before the upgrade, or to the previous revision when the
+// revision is unknown
+func rollbackArgs(versionInfo ChartVersionInfo) []string {
+	args := []string{"rollback", versionInfo.ReleaseName}
+	if versionInfo.Revision > 0 {
+		args = append(args, strconv.Itoa(versionInfo.Revision))
+	}
+	return append(args, "--namespace", versionInfo.Namespace, "--wait")
+}
+
+// upgradePlan returns the proposed upgrades of the candidates with the exact
+// commands to apply them and to roll them back to their current revision
+func upgradePlan(candidates []ChartVersionInfo, opts upgradeOptions) []upgradePlanStep {
+	plan := make([]upgradePlanStep, 0, len(candidates))
+	for _, versionInfo := range candidates {
+		plan = append(plan, upgradePlanStep{
+			ReleaseName:     versionInfo.ReleaseName,
+			Namespace:       versionInfo.Namespace,
+			ChartName:       versionInfo.ChartName,
+			FromVersion:     versionInfo.InstalledVersion,
+			ToVersion:       versionInfo.LatestVersion,
+			Revision:        versionInfo.Revision,
+			UpgradeCommand:  helmCommandLine(upgradeArgs(versionInfo, opts)),
+			RollbackCommand: helmCommandLine(rollbackArgs(versionInfo)),
+		})
+	}
+	return plan
+}
+
+// printUpgradePlan writes the plan in the requested format. The table format
+// lists every step with its commands so it can be pasted into a change ticket.
+func printUpgradePlan(w io.Writer, output string, plan []upgradePlanStep) error {
+	switch output {
+	case outputFormatJSON:
+		outputBytes, err := json.MarshalIndent(plan, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatTable:
+		if len(plan) == 0 {
+			fmt.Fprintln(w, "No releases need upgrades.")
+			return nil
+		}
+		for i, step := range plan {
+			fmt.Fprintf(w, "%d. %s/%s (%s): %s --> %s\n", i+1, step.Namespace, step.ReleaseName, step.ChartName, step.FromVersion, step.ToVersion)
+			fmt.Fprintf(w, "   Upgrade:  %s\n", step.UpgradeCommand)
+			fmt.Fprintf(w, "   Rollback: %s\n", step.RollbackCommand)
+		}
+	default:
+		return fmt.Errorf("invalid formatter: %s", output)
+	}
+	return nil
+}
+
+// helmCommandLine renders the Helm command run with args against the cluster
+// selected for whatup
+func helmCommandLine(args []string) string {
+	return helmBinary() + " " + strings.Join(helmClusterArgs(args), " ")
+}
+
+// helmClusterArgs adds the cluster selection flags of whatup to Helm arguments
+func helmClusterArgs(args []string) []string {
+	if kubeConfig != "" {
+		args = append(args, "--kubeconfig", kubeConfig)
+	}
+	if kubeContext != "" {
+		args = append(args, "--kube-context", kubeContext)
+	}
+	return args
+}
+
+// runHelm runs the Helm binary against the cluster selected for whatup
+func runHelm(ctx context.Context, args ...string) error {
+	args = helmClusterArgs(args)
+	if dryRun {
+		dryRunf("%s %s", helmBinary(), strings.Join(args, " "))
+		return nil
+	}
+	// #nosec G204 -- the Helm binary is provided by Helm itself when running the plugin
+	cmd := exec.CommandContext(ctx, helmBinary(), args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", helmBinary(), args[0], err)
+	}
+	return nil
+}
+
+// printUpgradeResults writes the apply report in the requested format
+func printUpgradeResults(w io.Writer, output string, results []upgradeResult) error {
+	switch output {
+	case outputFormatJSON:
+		outputBytes, err := json.MarshalIndent(results, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(w, string(outputBytes))
+	case outputFormatTable:
+		if len(results) == 0 {
+			fmt.Fprintln(w, "No releases need upgrades.")
+			return nil
+		}
+		table := uitable.New()
+		table.MaxColWidth = 50
+		table.Separator = "  "
+		table.AddRow("NAME", "NAMESPACE", "CHART", "FROM", "TO", "UPGRADED", "TESTS", "ROLLED BACK", "ERROR")
+		for _, result := range results {
+			table.AddRow(result.ReleaseName, result.Namespace, result.ChartName, result.FromVersion, result.ToVersion,
+				result.Upgraded, result.Tests, result.RolledBack, result.Error)
+		}
+		fmt.Fprintln(w, table)
+	default:
+		return fmt.Errorf("invalid formatter: %s", output)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHelm installs a Helm binary whose `helm test` exits with the given code
+func fakeHelm(t *testing.T, testExitCode string) {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "helm")
+	script := "#!/bin/sh\nif [ \"$1\" = test ]; then exit " + testExitCode + "; fi\nexit 0\n"
+	require.NoError(t, os.WriteFile(bin, []byte(script), 0o700))
+	t.Setenv("HELM_BIN", bin)
+}
+
+// Test that tests run after upgrades and failing releases are rolled back with --atomic
+func TestApplyUpgrades(t *testing.T) {
+	candidates := []ChartVersionInfo{{ReleaseName: "web", Namespace: "apps", ChartName: "nginx", RepoName: "bitnami", InstalledVersion: "1.0.0", LatestVersion: "1.1.0"}}
+	ctx := context.Background()
+
+	fakeHelm(t, "0")
+	results := applyUpgrades(ctx, candidates, upgradeOptions{runTests: true, atomic: true})
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Upgraded)
+	assert.Equal(t, testsPassed, results[0].Tests)
+	assert.False(t, results[0].failed())
+
+	fakeHelm(t, "1")
+	results = applyUpgrades(ctx, candidates, upgradeOptions{runTests: true})
+	assert.Equal(t, testsFailed, results[0].Tests)
+	assert.False(t, results[0].RolledBack)
+	assert.True(t, results[0].failed())
+
+	results = applyUpgrades(ctx, candidates, upgradeOptions{runTests: true, atomic: true})
+	assert.Equal(t, testsFailed, results[0].Tests)
+	assert.True(t, results[0].RolledBack)
+
+	results = applyUpgrades(ctx, candidates, upgradeOptions{})
+	assert.Empty(t, results[0].Tests)
+	assert.False(t, results[0].failed())
+
+	var out bytes.Buffer
+	require.NoError(t, printUpgradeResults(&out, outputFormatJSON, results))
+	assert.Contains(t, out.String(), `"upgraded": true`)
+}
+
+// Test that only outdated releases, optionally selected by name, are upgraded
+func TestUpgradeCandidates(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "apps", RepoName: "bitnami", RepoConfidence: confidenceExact, Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "1.1.0"},
+		{ReleaseName: "api", Namespace: "apps", RepoName: "stable", RepoConfidence: confidenceURL, Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "1.1.0"},
+		{ReleaseName: "db", Namespace: "data", RepoName: "bitnami", RepoConfidence: confidenceExact, Status: statusUptodate},
+	}
+
+	candidates, err := upgradeCandidates(result, nil)
+	require.NoError(t, err)
+	assert.Len(t, candidates, 2)
+
+	candidates, err = upgradeCandidates(result, []string{"apps/api", "db"})
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "api", candidates[0].ReleaseName)
+
+	_, err = upgradeCandidates(result, []string{"missing"})
+	assert.EqualError(t, err, "release missing not found")
+
+	assert.Equal(t, []string{"upgrade", "web", "nginx", "--namespace", "apps", "--version", "1.1.0", "--reuse-values", "--wait", "--repo", "https://charts.example.com"},
+		upgradeArgs(ChartVersionInfo{ReleaseName: "web", Namespace: "apps", ChartName: "nginx", RepoName: "https://charts.example.com", LatestVersion: "1.1.0"}, upgradeOptions{}))
+	assert.Equal(t, []string{"upgrade", "web", "bitnami/nginx", "--namespace", "apps", "--version", "1.1.0", "--reuse-values", "--wait", "--atomic"},
+		upgradeArgs(ChartVersionInfo{ReleaseName: "web", Namespace: "apps", ChartName: "nginx", RepoName: "bitnami", LatestVersion: "1.1.0"}, upgradeOptions{atomic: true}))
+}
+
+// Test that every step of the plan comes with the command rolling back to the current revision
+func TestUpgradePlan(t *testing.T) {
+	t.Setenv("HELM_BIN", "helm")
+	candidates := []ChartVersionInfo{{ReleaseName: "web", Namespace: "apps", ChartName: "nginx", RepoName: "bitnami", InstalledVersion: "1.0.0", LatestVersion: "1.1.0", Revision: 7}}
+
+	plan := upgradePlan(candidates, upgradeOptions{})
+	require.Len(t, plan, 1)
+	assert.Equal(t, 7, plan[0].Revision)
+	assert.Equal(t, "helm upgrade web bitnami/nginx --namespace apps --version 1.1.0 --reuse-values --wait", plan[0].UpgradeCommand)
+	assert.Equal(t, "helm rollback web 7 --namespace apps --wait", plan[0].RollbackCommand)
+
+	var out bytes.Buffer
+	require.NoError(t, printUpgradePlan(&out, outputFormatTable, plan))
+	assert.Equal(t, "1. apps/web (nginx): 1.0.0 --> 1.1.0\n"+
+		"   Upgrade:  helm upgrade web bitnami/nginx --namespace apps --version 1.1.0 --reuse-values --wait\n"+
+		"   Rollback: helm rollback web 7 --namespace apps --wait\n", out.String())
+}
+
+// Test that releases whose repository was guessed are never upgraded
+func TestUpgradeCandidatesGuessedRepo(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "apps", RepoName: "bitnami", RepoConfidence: confidenceExact, Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "1.1.0"},
+		{ReleaseName: "cache", Namespace: "apps", RepoName: "stable", RepoConfidence: confidenceIndex, Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "1.1.0"},
+		{ReleaseName: "db", Namespace: "apps", RepoName: "unknown", RepoConfidence: confidenceGuess, Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "1.1.0"},
+	}
+
+	candidates, err := upgradeCandidates(result, nil)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "web", candidates[0].ReleaseName)
+
+	_, err = upgradeCandidates(result, []string{"db"})
+	assert.ErrorContains(t, err, "the repository of release db was guessed")
+}
+
+// Test that every attempted upgrade is recorded in the audit log with its result
+func TestRecordUpgrades(t *testing.T) {
+	auditLog = filepath.Join(t.TempDir(), "audit.log")
+	defer func() { auditLog = "" }()
+
+	recordUpgrades([]upgradeResult{
+		{ReleaseName: "web", Namespace: "apps", FromVersion: "1.0.0", ToVersion: "1.1.0", Upgraded: true},
+		{ReleaseName: "api", Namespace: "apps", FromVersion: "2.0.0", ToVersion: "3.0.0", Error: "helm upgrade: exit status 1"},
+		{ReleaseName: "db", Namespace: "data", FromVersion: "1.0.0", ToVersion: "1.0.1", Upgraded: true, Tests: testsFailed, RolledBack: true},
+	})
+
+	data, err := os.ReadFile(auditLog)
+	require.NoError(t, err)
+	var entry auditEntry
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.Equal(t, auditEventUpgrade, entry.Event)
+	require.Len(t, entry.Actions, 3)
+	assert.Equal(t, auditAction{Action: auditEventUpgrade, ReleaseName: "web", Namespace: "apps", FromVersion: "1.0.0", ToVersion: "1.1.0", Result: auditResultUpgraded}, entry.Actions[0])
+	assert.Equal(t, auditResultFailed, entry.Actions[1].Result)
+	assert.Equal(t, "helm upgrade: exit status 1", entry.Actions[1].Error)
+	assert.Equal(t, auditResultRolledBack, entry.Actions[2].Result)
+}
+
+// Test that releases are never downgraded, even when reported as outdated
+func TestUpgradeCandidatesDowngrade(t *testing.T) {
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "apps", RepoName: "bitnami", RepoConfidence: confidenceExact, Status: statusOutdated, InstalledVersion: "2.0.0", LatestVersion: "1.9.0"},
+		{ReleaseName: "api", Namespace: "apps", RepoName: "bitnami", RepoConfidence: confidenceExact, Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "latest"},
+		{ReleaseName: "db", Namespace: "apps", RepoName: "bitnami", RepoConfidence: confidenceExact, Status: statusOutdated, InstalledVersion: "1.0.0", LatestVersion: "1.0.1"},
+	}
+
+	candidates, err := upgradeCandidates(result, nil)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "db", candidates[0].ReleaseName)
+
+	_, err = upgradeCandidates(result, []string{"web"})
+	assert.EqualError(t, err, "release web can't be upgraded from 2.0.0 to 1.9.0, which is not a newer version")
+}
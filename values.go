@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ValuesInfo summarizes the values a release was installed or upgraded with.
+// Helm stores the merge of all values files and --set flags, so the two
+// sources cannot be told apart; the number of overridden keys is a proxy for
+// the effort of reviewing an upgrade.
+type ValuesInfo struct {
+	UserSupplied bool `json:"userSupplied"`
+	Overrides    int  `json:"overrides"`
+}
+
+// addValuesInfo records for every result whether its release was configured
+// with user-supplied values and how many keys they set
+func addValuesInfo(releases []*helmRelease, result []ChartVersionInfo) {
+	infos := make(map[string]*ValuesInfo, len(releases))
+	for _, rel := range releases {
+		overrides := countValues(rel.Config)
+		infos[rel.Namespace+"/"+rel.Name] = &ValuesInfo{UserSupplied: overrides > 0, Overrides: overrides}
+	}
+
+	for i := range result {
+		if info, ok := infos[findingKey(result[i])]; ok {
+			result[i].Values = info
+		}
+	}
+}
+
+// countValues counts the leaf keys of a values tree. Lists count as a single value.
+func countValues(values map[string]interface{}) int {
+	count := 0
+	for _, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			count += countValues(nested)
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// valuesColumn renders the values summary of a release
+func valuesColumn(versionInfo ChartVersionInfo) string {
+	switch {
+	case versionInfo.Values == nil:
+		return ""
+	case !versionInfo.Values.UserSupplied:
+		return "defaults"
+	case versionInfo.Values.Overrides == 1:
+		return "1 override"
+	default:
+		return strconv.Itoa(versionInfo.Values.Overrides) + " overrides"
+	}
+}
+
+// valuesHint returns the hint shown for releases with user-supplied values
+func valuesHint(versionInfo ChartVersionInfo) string {
+	return fmt.Sprintf("Release %s overrides %d chart values; review them against the changes of the new version.",
+		versionInfo.ReleaseName,
+		versionInfo.Values.Overrides)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that the user-supplied values of every release are summarized
+func TestAddValuesInfo(t *testing.T) {
+	custom := newTestRelease("web", "apps", "nginx", "1.0.0")
+	custom.Config = map[string]interface{}{
+		"replicaCount": 3,
+		"image":        map[string]interface{}{"tag": "1.25", "pullPolicy": "Always"},
+		"extraArgs":    []interface{}{"--verbose"},
+		"podLabels":    map[string]interface{}{},
+	}
+	plain := newTestRelease("db", "data", "postgresql", "1.0.0")
+
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Namespace: "apps"},
+		{ReleaseName: "db", Namespace: "data"},
+		{ReleaseName: "gone", Namespace: "apps"},
+	}
+	addValuesInfo([]*helmRelease{custom, plain}, result)
+
+	require.NotNil(t, result[0].Values)
+	assert.Equal(t, ValuesInfo{UserSupplied: true, Overrides: 5}, *result[0].Values)
+	assert.Equal(t, "5 overrides", valuesColumn(result[0]))
+	assert.Contains(t, valuesHint(result[0]), "overrides 5 chart values")
+	require.NotNil(t, result[1].Values)
+	assert.False(t, result[1].Values.UserSupplied)
+	assert.Equal(t, "defaults", valuesColumn(result[1]))
+	assert.Empty(t, valuesColumn(result[2]))
+}
@@ -0,0 +1,134 @@
+package main
+
+import "fmt"
+
+// knownStatuses are the statuses a release can be reported with
+var knownStatuses = []string{statusOutdated, statusUptodate, statusUnknown, statusIgnored, statusVulnerable}
+
+// vocabularyConfig renames statuses, priorities and severities in the output
+// so they align with the taxonomy of downstream systems. Severities cover both
+// the update severities and the severities set by version rules. Checks always
+// work on the built-in names.
+type vocabularyConfig struct {
+	Statuses   map[string]string `yaml:"statuses"`
+	Priorities map[string]string `yaml:"priorities"`
+	Severities map[string]string `yaml:"severities"`
+}
+
+// validate checks that only known names are renamed and that no two names collide
+func (v vocabularyConfig) validate() error {
+	known := map[string]bool{}
+	for _, status := range knownStatuses {
+		known[status] = true
+	}
+	if err := validateNames("status", v.Statuses, known); err != nil {
+		return err
+	}
+
+	known = map[string]bool{}
+	for priority := range priorityRanks {
+		known[priority] = true
+	}
+	if err := validateNames("priority", v.Priorities, known); err != nil {
+		return err
+	}
+
+	// Rules may set any severity, so only update severities are known
+	// beforehand
+	return validateNames("severity", v.Severities, nil)
+}
+
+// validateNames checks a single renaming table of the vocabulary
+func validateNames(kind string, names map[string]string, known map[string]bool) error {
+	used := map[string]string{}
+	for name, renamed := range names {
+		if known != nil && !known[name] {
+			return fmt.Errorf("unknown %s %q in vocabulary", kind, name)
+		}
+		if renamed == "" {
+			return fmt.Errorf("empty name for %s %q in vocabulary", kind, name)
+		}
+		if other, ok := used[renamed]; ok {
+			return fmt.Errorf("%ss %q and %q are both renamed to %q in vocabulary", kind, other, name, renamed)
+		}
+		used[renamed] = name
+	}
+	return nil
+}
+
+// translate returns a copy of the results using the configured names
+func (v vocabularyConfig) translate(result []ChartVersionInfo) []ChartVersionInfo {
+	if len(v.Statuses) == 0 && len(v.Priorities) == 0 && len(v.Severities) == 0 {
+		return result
+	}
+
+	translated := make([]ChartVersionInfo, len(result))
+	for i, versionInfo := range result {
+		versionInfo.Status = v.status(versionInfo.Status)
+		versionInfo.Priority = v.priority(versionInfo.Priority)
+		versionInfo.UpdateSeverity = v.severity(versionInfo.UpdateSeverity)
+		versionInfo.Severity = v.severity(versionInfo.Severity)
+		translated[i] = versionInfo
+	}
+	return translated
+}
+
+// translateSummary returns a copy of the summary counting the severities by
+// their configured names
+func (v vocabularyConfig) translateSummary(summary reportSummary) reportSummary {
+	if len(v.Severities) == 0 || summary.Severities == nil {
+		return summary
+	}
+	severities := make(map[string]int, len(summary.Severities))
+	for name, count := range summary.Severities {
+		severities[v.severity(name)] += count
+	}
+	summary.Severities = severities
+	return summary
+}
+
+// status returns the configured name of a status
+func (v vocabularyConfig) status(name string) string {
+	return rename(v.Statuses, name)
+}
+
+// priority returns the configured name of a priority
+func (v vocabularyConfig) priority(name string) string {
+	return rename(v.Priorities, name)
+}
+
+// severity returns the configured name of an update or rule severity
+func (v vocabularyConfig) severity(name string) string {
+	return rename(v.Severities, name)
+}
+
+// rename returns the name configured in a renaming table, or the name itself
+func rename(names map[string]string, name string) string {
+	if renamed, ok := names[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+// canonicalize maps the configured names back to the built-in ones, e.g. for
+// reports read from disk
+func (v vocabularyConfig) canonicalize(result []ChartVersionInfo) {
+	statuses := reverseNames(v.Statuses)
+	priorities := reverseNames(v.Priorities)
+	severities := reverseNames(v.Severities)
+	for i := range result {
+		result[i].Status = rename(statuses, result[i].Status)
+		result[i].Priority = rename(priorities, result[i].Priority)
+		result[i].UpdateSeverity = rename(severities, result[i].UpdateSeverity)
+		result[i].Severity = rename(severities, result[i].Severity)
+	}
+}
+
+// reverseNames inverts a renaming table
+func reverseNames(names map[string]string) map[string]string {
+	reversed := make(map[string]string, len(names))
+	for name, renamed := range names {
+		reversed[renamed] = name
+	}
+	return reversed
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that statuses and priorities are renamed in output and mapped back when reading reports
+func TestVocabulary(t *testing.T) {
+	vocabulary := vocabularyConfig{
+		Statuses:   map[string]string{statusOutdated: "STALE", statusUptodate: "CURRENT"},
+		Priorities: map[string]string{priorityCritical: "P1"},
+		Severities: map[string]string{"MAJOR": "high", "blocker": "S1"},
+	}
+	assert.NoError(t, vocabulary.validate())
+
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", Status: statusOutdated, Priority: priorityCritical, UpdateSeverity: "MAJOR", Severity: "blocker"},
+		{ReleaseName: "api", Status: statusUnknown, Priority: priorityLow},
+	}
+
+	translated := vocabulary.translate(result)
+	assert.Equal(t, "STALE", translated[0].Status)
+	assert.Equal(t, "P1", translated[0].Priority)
+	assert.Equal(t, "high", translated[0].UpdateSeverity)
+	assert.Equal(t, "S1", translated[0].Severity)
+	assert.Equal(t, statusUnknown, translated[1].Status)
+	assert.Equal(t, priorityLow, translated[1].Priority)
+	assert.Equal(t, statusOutdated, result[0].Status, "the results themselves are left untouched")
+
+	vocabulary.canonicalize(translated)
+	assert.Equal(t, result, translated)
+
+	assert.Error(t, vocabularyConfig{Statuses: map[string]string{"BROKEN": "X"}}.validate())
+	assert.Error(t, vocabularyConfig{Statuses: map[string]string{statusOutdated: ""}}.validate())
+	assert.Error(t, vocabularyConfig{Statuses: map[string]string{statusOutdated: "X", statusUnknown: "X"}}.validate())
+	assert.Error(t, vocabularyConfig{Priorities: map[string]string{"urgent": "P0"}}.validate())
+	assert.Error(t, vocabularyConfig{Severities: map[string]string{"MAJOR": "X", "MINOR": "X"}}.validate())
+}
+
+// Test that the formatters emit the names of the vocabulary
+func TestVocabularyFormatters(t *testing.T) {
+	cfg = Config{Vocabulary: vocabularyConfig{
+		Statuses:   map[string]string{statusOutdated: "STALE"},
+		Priorities: map[string]string{priorityCritical: "P1"},
+		Severities: map[string]string{"MAJOR": "high"},
+	}}
+	defer func() { cfg = Config{} }()
+
+	result := []ChartVersionInfo{
+		{ReleaseName: "web", ChartName: "nginx", Status: statusOutdated, Priority: priorityCritical, UpdateSeverity: "MAJOR", InstalledVersion: "1.0.0", LatestVersion: "2.0.0"},
+	}
+
+	assert.Contains(t, formatMarkdown(result), "| high |")
+
+	var metrics bytes.Buffer
+	formatPrometheus(&metrics, result, time.Now())
+	assert.Contains(t, metrics.String(), `priority="P1",update_severity="high"} 1`)
+
+	var page bytes.Buffer
+	require.NoError(t, formatHTML(&page, scanReport{results: result}, time.Now()))
+	assert.Contains(t, page.String(), `<td class="status-OUTDATED">STALE</td>`)
+	assert.Contains(t, page.String(), `>high</span>`)
+
+	assert.Contains(t, alertRules(alertOptions{})[0].Expr, `priority="P1",update_severity="high"`)
+
+	summary := summarize(result)
+	assert.Contains(t, summary.String(), "1 high")
+	assert.Equal(t, map[string]int{"high": 1}, newReportEnvelope(scanReport{results: result}, time.Now()).Summary.Severities)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// warningCode is the stable identifier of a class of warnings
+type warningCode string
+
+// Warning codes. Codes are never reused so automation can rely on them.
+const (
+	warnRepoUnresolved       warningCode = "W001"
+	warnStaleIndex           warningCode = "W002"
+	warnAmbiguousRepo        warningCode = "W003"
+	warnRepoNotAdded         warningCode = "W004"
+	warnProbeFailed          warningCode = "W005"
+	warnArtifactHubFailed    warningCode = "W006"
+	warnDependencyCycle      warningCode = "W007"
+	warnEventFailed          warningCode = "W008"
+	warnNotifyFailed         warningCode = "W009"
+	warnOwnerLookupFailed    warningCode = "W010"
+	warnPodLookupFailed      warningCode = "W011"
+	warnSeverityHookFailed   warningCode = "W012"
+	warnInvalidPin           warningCode = "W013"
+	warnRepoUpdateFailed     warningCode = "W014"
+	warnManifestUnparsed     warningCode = "W015"
+	warnNoRepositories       warningCode = "W016"
+	warnOCITagsFailed        warningCode = "W017"
+	warnGitSourceFailed      warningCode = "W018"
+	warnChartDownloadFailed  warningCode = "W019"
+	warnConstraintUnresolved warningCode = "W020"
+	warnNoAllowedVersion     warningCode = "W021"
+	warnRepoAddFailed        warningCode = "W022"
+)
+
+// warningNames are the names of the warning codes
+var warningNames = map[warningCode]string{
+	warnRepoUnresolved:       "RepoUnresolved",
+	warnStaleIndex:           "StaleIndex",
+	warnAmbiguousRepo:        "AmbiguousRepo",
+	warnRepoNotAdded:         "RepoNotAdded",
+	warnProbeFailed:          "ProbeFailed",
+	warnArtifactHubFailed:    "ArtifactHubFailed",
+	warnDependencyCycle:      "DependencyCycle",
+	warnEventFailed:          "EventFailed",
+	warnNotifyFailed:         "NotifyFailed",
+	warnOwnerLookupFailed:    "OwnerLookupFailed",
+	warnPodLookupFailed:      "PodLookupFailed",
+	warnSeverityHookFailed:   "SeverityHookFailed",
+	warnInvalidPin:           "InvalidPin",
+	warnRepoUpdateFailed:     "RepoUpdateFailed",
+	warnManifestUnparsed:     "ManifestUnparsed",
+	warnNoRepositories:       "NoRepositories",
+	warnOCITagsFailed:        "OCITagsFailed",
+	warnGitSourceFailed:      "GitSourceFailed",
+	warnChartDownloadFailed:  "ChartDownloadFailed",
+	warnConstraintUnresolved: "ConstraintUnresolved",
+	warnNoAllowedVersion:     "NoAllowedVersion",
+	warnRepoAddFailed:        "RepoAddFailed",
+}
+
+// staleIndexAge is the age above which a repository index is reported as stale
+const staleIndexAge = 7 * 24 * time.Hour
+
+// warning is a problem found during the scan that doesn't abort it
+type warning struct {
+	Code    warningCode `json:"code"`
+	Name    string      `json:"name"`
+	Message string      `json:"message"`
+}
+
+// String renders the warning for the plain output
+func (w warning) String() string {
+	return fmt.Sprintf("[%s %s] %s", w.Code, w.Name, w.Message)
+}
+
+// addWarning appends a warning with the given code to warnings
+func addWarning(warnings *[]warning, code warningCode, format string, v ...interface{}) {
+	*warnings = append(*warnings, warning{Code: code, Name: warningNames[code], Message: fmt.Sprintf(format, v...)})
+}
+
+// hasWarning reports whether a warning with the given code was found
+func hasWarning(warnings []warning, code warningCode) bool {
+	for _, w := range warnings {
+		if w.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIndexFreshness warns about repository indices that were not updated recently
+func checkIndexFreshness(repositories []repoIndex, now time.Time, warnings *[]warning) {
+	for _, idx := range repositories {
+		if !idx.Generated.IsZero() && now.Sub(idx.Generated) > staleIndexAge {
+			addWarning(warnings, warnStaleIndex, "The index of repository '%s' was generated %d days ago. Run `helm repo update` to refresh it",
+				idx.name, int(now.Sub(idx.Generated).Hours()/24))
+		}
+	}
+}
+
+// suppressedWarnings parses the codes or names given to --suppress-warnings
+func suppressedWarnings(values []string) (map[warningCode]bool, error) {
+	suppressed := make(map[warningCode]bool, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		found := false
+		for code, name := range warningNames {
+			if strings.EqualFold(value, string(code)) || strings.EqualFold(value, name) {
+				suppressed[code] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown warning code: %s (known codes: %s)", value, knownWarningCodes())
+		}
+	}
+	return suppressed, nil
+}
+
+// filterWarnings drops the suppressed warnings
+func filterWarnings(warnings []warning, suppressed map[warningCode]bool) []warning {
+	if len(suppressed) == 0 {
+		return warnings
+	}
+	var kept []warning
+	for _, w := range warnings {
+		if !suppressed[w.Code] {
+			kept = append(kept, w)
+		}
+	}
+	return kept
+}
+
+// knownWarningCodes lists the warning codes and names for error messages
+func knownWarningCodes() string {
+	var codes []string
+	for code, name := range warningNames {
+		codes = append(codes, fmt.Sprintf("%s %s", code, name))
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ", ")
+}
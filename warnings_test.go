@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that warnings carry stable codes and can be suppressed by code or name
+func TestWarnings(t *testing.T) {
+	var warnings []warning
+	addWarning(&warnings, warnRepoUnresolved, "The source repository could not be determined for '%s'", "web")
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	fresh := newTestIndex("nginx", "1.0.0")
+	fresh.Generated = now.Add(-time.Hour)
+	stale := newTestIndex("redis", "1.0.0")
+	stale.Generated = now.AddDate(0, 0, -30)
+	checkIndexFreshness([]repoIndex{fresh, stale}, now, &warnings)
+
+	require.Len(t, warnings, 2)
+	assert.Equal(t, "[W001 RepoUnresolved] The source repository could not be determined for 'web'", warnings[0].String())
+	assert.Equal(t, warnStaleIndex, warnings[1].Code)
+	assert.Contains(t, warnings[1].Message, "'redis' was generated 30 days ago")
+
+	suppressed, err := suppressedWarnings([]string{"w001"})
+	require.NoError(t, err)
+	assert.Equal(t, []warning{warnings[1]}, filterWarnings(warnings, suppressed))
+
+	suppressed, err = suppressedWarnings([]string{"RepoUnresolved", "StaleIndex"})
+	require.NoError(t, err)
+	assert.Empty(t, filterWarnings(warnings, suppressed))
+
+	_, err = suppressedWarnings([]string{"W999"})
+	assert.ErrorContains(t, err, "W001 RepoUnresolved")
+}
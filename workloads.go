@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// instanceLabel is the recommended label linking workloads to their Helm release
+const instanceLabel = "app.kubernetes.io/instance"
+
+// WorkloadStatus describes the pods running for a release
+type WorkloadStatus struct {
+	Pods      int  `json:"pods"`
+	ReadyPods int  `json:"readyPods"`
+	Healthy   bool `json:"healthy"`
+}
+
+// podReady reports whether the pod has the Ready condition
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// correlateWorkloadStatus looks up the pods of every release through the
+// instance label and records how many are running and ready. Pods are listed
+// once per namespace.
+func correlateWorkloadStatus(ctx context.Context, clientset kubernetes.Interface, result []ChartVersionInfo, warnings *[]warning) {
+	statuses := make(map[string]*WorkloadStatus)
+	listed := make(map[string]bool)
+
+	for _, versionInfo := range result {
+		namespace := versionInfo.Namespace
+		if listed[namespace] {
+			continue
+		}
+		listed[namespace] = true
+
+		pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: instanceLabel})
+		if err != nil {
+			addWarning(warnings, warnPodLookupFailed, "Failed to list pods in namespace '%s': %v", namespace, err)
+			continue
+		}
+
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+
+			key := namespace + "/" + pod.Labels[instanceLabel]
+			status := statuses[key]
+			if status == nil {
+				status = &WorkloadStatus{}
+				statuses[key] = status
+			}
+			status.Pods++
+			if podReady(pod) {
+				status.ReadyPods++
+			}
+		}
+	}
+
+	for i := range result {
+		status := statuses[findingKey(result[i])]
+		if status == nil {
+			status = &WorkloadStatus{}
+		}
+		status.Healthy = status.Pods > 0 && status.ReadyPods == status.Pods
+		result[i].Workload = status
+	}
+}
+
+// podsColumn renders the workload status for the table output
+func podsColumn(versionInfo ChartVersionInfo) string {
+	if versionInfo.Workload == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", versionInfo.Workload.ReadyPods, versionInfo.Workload.Pods)
+}
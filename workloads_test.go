@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestPod creates a running pod belonging to the given release
+func newTestPod(name, namespace, instance string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{instanceLabel: instance}},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+// Test that releases are correlated with their pods
+func TestCorrelateWorkloadStatus(t *testing.T) {
+	clientset := fake.NewClientset(
+		newTestPod("api-1", "apps", "api", true),
+		newTestPod("api-2", "apps", "api", true),
+		newTestPod("web-1", "apps", "web", false),
+	)
+	result := []ChartVersionInfo{
+		{ReleaseName: "api", Namespace: "apps"},
+		{ReleaseName: "web", Namespace: "apps"},
+		{ReleaseName: "idle", Namespace: "apps"},
+	}
+
+	var warnings []warning
+	correlateWorkloadStatus(context.Background(), clientset, result, &warnings)
+	assert.Empty(t, warnings)
+
+	require.NotNil(t, result[0].Workload)
+	assert.Equal(t, WorkloadStatus{Pods: 2, ReadyPods: 2, Healthy: true}, *result[0].Workload)
+	assert.Equal(t, WorkloadStatus{Pods: 1, ReadyPods: 0, Healthy: false}, *result[1].Workload)
+	assert.Equal(t, WorkloadStatus{}, *result[2].Workload)
+	assert.Equal(t, "2/2", podsColumn(result[0]))
+}